@@ -175,7 +175,7 @@ func main() {
 	}
 
 	// Create a machine instance with the document as StateExtender
-	sm, err := machine.New(definition, doc)
+	sm, err := machine.New(definition, machine.WithExtender(doc))
 	if err != nil {
 		log.Fatalf("Failed to create machine: %v", err)
 	}
@@ -14,6 +14,8 @@ package builder
 
 import (
 	"fmt"
+	"maps"
+	"slices"
 
 	"github.com/dr-dobermann/gonfa/pkg/definition"
 	"github.com/dr-dobermann/gonfa/pkg/gonfa"
@@ -26,7 +28,13 @@ type Builder struct {
 	states         map[gonfa.State]definition.StateConfig
 	transitions    []definition.Transition
 	hooks          definition.Hooks
+	mergeHooks     bool // WithHooks merges instead of replacing
 	lastTransition *definition.Transition
+	defaultGuards  []gonfa.Guard       // prepended to every new transition
+	declared       []gonfa.State       // states declared by States
+	strict         bool                // only declared states may be used
+	options        []definition.Option // passed to definition.New
+	err            error               // first configuration error, reported by Build
 }
 
 // New creates a new Builder instance.
@@ -49,6 +57,102 @@ func (b *Builder) FinalStates(states ...gonfa.State) *Builder {
 	return b
 }
 
+// States explicitly declares states of the state machine. A declared state
+// is registered even if it has no actions and no special role. Can be
+// called multiple times to declare more states.
+func (b *Builder) States(states ...gonfa.State) *Builder {
+	for _, s := range states {
+		if !slices.Contains(b.declared, s) {
+			b.declared = append(b.declared, s)
+		}
+		if _, ok := b.states[s]; !ok {
+			b.states[s] = definition.StateConfig{}
+		}
+	}
+	return b
+}
+
+// StrictStates makes Build fail if the initial state, a final state, a
+// state with actions or a transition refers to a state not declared with
+// States. It catches misspelled state names before the graph validation.
+func (b *Builder) StrictStates() *Builder {
+	b.strict = true
+	return b
+}
+
+// DeclareEvents declares the event vocabulary of the state machine (see
+// definition.DeclareEvents). Transitions on undeclared events are reported
+// by BuildWithWarnings. Can be called multiple times to declare more events.
+func (b *Builder) DeclareEvents(events ...gonfa.Event) *Builder {
+	b.options = append(b.options, definition.DeclareEvents(events...))
+	return b
+}
+
+// RequireAllEventsReachable makes Build fail if an event declared with
+// DeclareEvents isn't used in any transition.
+func (b *Builder) RequireAllEventsReachable() *Builder {
+	b.options = append(b.options, definition.RequireAllEventsReachable())
+	return b
+}
+
+// WithContextValues sets machine-wide default values which machines add to
+// the context passed to every guard, action and hook (see
+// definition.WithContextValues). Values in the caller's context win over
+// the defaults. Can be called multiple times to add more values.
+func (b *Builder) WithContextValues(values map[any]any) *Builder {
+	b.options = append(b.options, definition.WithContextValues(values))
+	return b
+}
+
+// FinalStateIf adds a conditional final state: the machine in this state
+// is accepting only if all the guards pass (see Machine.IsInFinalStateCtx).
+func (b *Builder) FinalStateIf(s gonfa.State, guards ...gonfa.Guard) *Builder {
+	b.FinalStates(s)
+	config := b.states[s]
+	config.AcceptIf = append(config.AcceptIf, guards...)
+	b.states[s] = config
+	return b
+}
+
+// Pipeline wires a linear chain of transitions states[0] -events[0]->
+// states[1] -events[1]-> ... and makes the first state initial and the last
+// one final. It requires at least two states and len(events) ==
+// len(states)-1; otherwise Build returns an error.
+// The last wired transition becomes the "last" transition for subsequent
+// WithGuards/WithActions calls.
+func (b *Builder) Pipeline(
+	states []gonfa.State,
+	events []gonfa.Event,
+) *Builder {
+	if len(states) < 2 {
+		b.setErr(fmt.Errorf("pipeline requires at least two states, got %d",
+			len(states)))
+		return b
+	}
+
+	if len(events) != len(states)-1 {
+		b.setErr(fmt.Errorf(
+			"pipeline of %d states requires %d events, got %d",
+			len(states), len(states)-1, len(events)))
+		return b
+	}
+
+	b.InitialState(states[0])
+	b.FinalStates(states[len(states)-1])
+	for i, e := range events {
+		b.AddTransition(states[i], states[i+1], e)
+	}
+
+	return b
+}
+
+// setErr remembers the first configuration error to be reported by Build.
+func (b *Builder) setErr(err error) {
+	if b.err == nil {
+		b.err = err
+	}
+}
+
 // OnEntry defines actions to be executed upon EVERY entry into the
 // specified state.
 func (b *Builder) OnEntry(s gonfa.State, actions ...gonfa.Action) *Builder {
@@ -67,17 +171,96 @@ func (b *Builder) OnExit(s gonfa.State, actions ...gonfa.Action) *Builder {
 	return b
 }
 
+// OnActionError sets the error state of the from state: if an OnExit or
+// transition action fails during a transition leaving from, the machine
+// moves to the to state instead of staying in from. It models try/catch
+// flows (see definition.StateConfig.ErrorState).
+func (b *Builder) OnActionError(from gonfa.State, to gonfa.State) *Builder {
+	config := b.states[from]
+	config.ErrorState = to
+	b.states[from] = config
+
+	if _, ok := b.states[to]; !ok {
+		b.states[to] = definition.StateConfig{}
+	}
+	return b
+}
+
+// OnEvent defines actions to be executed after ANY transition triggered by
+// the event is committed, regardless of its source and target states.
+// They run after the transition's AfterActions and before the OnSuccess
+// hooks. Like AfterActions, their failures don't revert the transition.
+// Note that WithHooks replaces event actions defined before it unless
+// WithHooksMerge is set.
+func (b *Builder) OnEvent(event gonfa.Event, actions ...gonfa.Action) *Builder {
+	if b.hooks.OnEvent == nil {
+		b.hooks.OnEvent = make(map[gonfa.Event][]gonfa.Action)
+	}
+	b.hooks.OnEvent[event] = append(b.hooks.OnEvent[event], actions...)
+	return b
+}
+
+// OnEntryIf defines actions to be executed upon entry into the specified
+// state only if the guard passes at that moment. The guard is evaluated
+// separately before each action and should be free of side effects.
+func (b *Builder) OnEntryIf(
+	s gonfa.State,
+	guard gonfa.Guard,
+	actions ...gonfa.Action,
+) *Builder {
+	return b.OnEntry(s, guardActions(guard, actions)...)
+}
+
+// OnExitIf defines actions to be executed upon exit from the specified
+// state only if the guard passes at that moment. The guard is evaluated
+// separately before each action and should be free of side effects.
+func (b *Builder) OnExitIf(
+	s gonfa.State,
+	guard gonfa.Guard,
+	actions ...gonfa.Action,
+) *Builder {
+	return b.OnExit(s, guardActions(guard, actions)...)
+}
+
+// guardActions wraps every action into a definition.GuardedAction.
+func guardActions(guard gonfa.Guard, actions []gonfa.Action) []gonfa.Action {
+	guarded := make([]gonfa.Action, len(actions))
+	for i, a := range actions {
+		guarded[i] = &definition.GuardedAction{Guard: guard, Action: a}
+	}
+
+	return guarded
+}
+
+// WithDefaultGuards adds guards which are prepended to every transition
+// added afterward, until ClearDefaultGuards is called. It's handy when a
+// whole section of a workflow requires the same condition (e.g. "user
+// authenticated"). Guards added by WithGuards follow the default ones.
+// Transitions added before the call aren't affected.
+func (b *Builder) WithDefaultGuards(guards ...gonfa.Guard) *Builder {
+	b.defaultGuards = append(b.defaultGuards, guards...)
+	return b
+}
+
+// ClearDefaultGuards stops adding the default guards to new transitions.
+func (b *Builder) ClearDefaultGuards() *Builder {
+	b.defaultGuards = nil
+	return b
+}
+
 // AddTransition adds a new transition and makes it the "last" transition
 // for subsequent WithGuards/WithActions calls.
+// The transition gets the current default guards (see WithDefaultGuards).
 func (b *Builder) AddTransition(
 	from gonfa.State,
 	to gonfa.State,
 	on gonfa.Event,
 ) *Builder {
 	transition := definition.Transition{
-		From: from,
-		To:   to,
-		On:   on,
+		From:   from,
+		To:     to,
+		On:     on,
+		Guards: slices.Clone(b.defaultGuards),
 	}
 	b.transitions = append(b.transitions, transition)
 	// Point to the last added transition for subsequent modifications
@@ -94,6 +277,21 @@ func (b *Builder) WithGuards(guards ...gonfa.Guard) *Builder {
 	return b
 }
 
+// WithPreconditions adds preconditions to the LAST added transition.
+// Preconditions look like guards but express invariants which must hold
+// whenever the event is fired from the transition's source state: if any of
+// them returns false, Fire fails with an error (and runs the failure hooks)
+// instead of silently trying the next transition. Use guards for business
+// rules that legitimately block a transition and preconditions for
+// conditions whose violation means a bug.
+func (b *Builder) WithPreconditions(preconditions ...gonfa.Guard) *Builder {
+	if b.lastTransition != nil {
+		b.lastTransition.Preconditions = append(
+			b.lastTransition.Preconditions, preconditions...)
+	}
+	return b
+}
+
 // WithActions adds actions to the LAST added transition.
 // Returns an error in Build() if called before AddTransition.
 func (b *Builder) WithActions(actions ...gonfa.Action) *Builder {
@@ -104,12 +302,126 @@ func (b *Builder) WithActions(actions ...gonfa.Action) *Builder {
 	return b
 }
 
-// WithHooks sets global hooks for the state machine.
+// WithAfterActions adds actions to the LAST added transition which run only
+// after the transition is committed (the OnEntry actions of the target
+// state succeeded). Unlike WithActions, their failures don't revert the transition:
+// the errors are returned from Fire together with a successful result.
+// Use them for fire-and-forget side effects like notifications.
+func (b *Builder) WithAfterActions(actions ...gonfa.Action) *Builder {
+	if b.lastTransition != nil {
+		b.lastTransition.AfterActions = append(
+			b.lastTransition.AfterActions, actions...)
+	}
+	return b
+}
+
+// WithCompensation adds compensating actions to the LAST added transition.
+// They run only if the transition is committed within a machine saga which
+// is aborted later (see machine.Machine.BeginSaga).
+func (b *Builder) WithCompensation(actions ...gonfa.Action) *Builder {
+	if b.lastTransition != nil {
+		b.lastTransition.Compensations = append(
+			b.lastTransition.Compensations, actions...)
+	}
+	return b
+}
+
+// WithAsyncAction adds an async action to the LAST added transition: the
+// action is started in a goroutine once the transition is committed, and
+// the machine fires onDone when it succeeds or onFail when it fails. The
+// target of the transition is the pending state which must have
+// transitions on both events, otherwise Build fails.
+// See machine.Machine.Fire for the execution details.
+func (b *Builder) WithAsyncAction(
+	action gonfa.Action,
+	onDone gonfa.Event,
+	onFail gonfa.Event,
+) *Builder {
+	if b.lastTransition != nil {
+		b.lastTransition.AsyncActions = append(b.lastTransition.AsyncActions,
+			definition.AsyncAction{Action: action, OnDone: onDone, OnFail: onFail})
+	}
+	return b
+}
+
+// WithTags adds tags to the LAST added transition. Tags are metadata for
+// external tools (see definition.Definition.TransitionsWithTag) and don't
+// affect runtime behavior.
+func (b *Builder) WithTags(tags ...string) *Builder {
+	if b.lastTransition != nil {
+		b.lastTransition.Tags = append(b.lastTransition.Tags, tags...)
+	}
+	return b
+}
+
+// WithLabel sets the human-readable label of the LAST added transition.
+// Machines record it in the history entries of the transition (see
+// gonfa.HistoryEntry.Label).
+func (b *Builder) WithLabel(label string) *Builder {
+	if b.lastTransition != nil {
+		b.lastTransition.Label = label
+	}
+	return b
+}
+
+// WithInternal marks the LAST added transition as internal: if it's a
+// self-transition, machines run its actions without executing the OnExit
+// and OnEntry actions of the state (see definition.Transition.Internal).
+func (b *Builder) WithInternal() *Builder {
+	if b.lastTransition != nil {
+		b.lastTransition.Internal = true
+	}
+	return b
+}
+
+// WithDisabled marks the LAST added transition as disabled, so machines skip
+// it until it's enabled at runtime (see machine.Machine.SetTransitionEnabled).
+func (b *Builder) WithDisabled() *Builder {
+	if b.lastTransition != nil {
+		b.lastTransition.Disabled = true
+	}
+	return b
+}
+
+// WithWeight sets the relative weight of the LAST added transition used by
+// weighted selection strategies.
+func (b *Builder) WithWeight(weight float64) *Builder {
+	if b.lastTransition != nil {
+		b.lastTransition.Weight = weight
+	}
+	return b
+}
+
+// WithPriority sets the priority of the LAST added transition. Competing
+// transitions with higher priority are attempted first.
+func (b *Builder) WithPriority(priority int) *Builder {
+	if b.lastTransition != nil {
+		b.lastTransition.Priority = priority
+	}
+	return b
+}
+
+// WithHooks sets global hooks for the state machine. After WithHooksMerge
+// the hooks are merged with the ones defined before (see
+// definition.MergeHooks) instead of replacing them.
 func (b *Builder) WithHooks(hooks definition.Hooks) *Builder {
+	if b.mergeHooks {
+		b.hooks = definition.MergeHooks(b.hooks, hooks)
+		return b
+	}
+
 	b.hooks = hooks
 	return b
 }
 
+// WithHooksMerge makes subsequent WithHooks calls append their hooks to the
+// ones already defined, so hooks could be layered, e.g. a shared
+// instrumentation layer plus definition-specific hooks.
+func (b *Builder) WithHooksMerge() *Builder {
+	b.mergeHooks = true
+	return b
+}
+
 // WithSuccessHooks sets global success hooks for the state machine.
 func (b *Builder) WithSuccessHooks(actions ...gonfa.Action) *Builder {
 	b.hooks.OnSuccess = append(b.hooks.OnSuccess, actions...)
@@ -125,6 +437,10 @@ func (b *Builder) WithFailureHooks(actions ...gonfa.Action) *Builder {
 // Build finalizes the building process and returns an immutable Definition.
 // Returns an error if the configuration is invalid.
 func (b *Builder) Build() (*definition.Definition, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+
 	if b.initialState == "" {
 		return nil, fmt.Errorf("initial state must be set")
 	}
@@ -133,6 +449,12 @@ func (b *Builder) Build() (*definition.Definition, error) {
 		return nil, fmt.Errorf("at least one transition must be defined")
 	}
 
+	if b.strict {
+		if err := b.checkDeclared(); err != nil {
+			return nil, err
+		}
+	}
+
 	// Ensure all states referenced in transitions are in the states map
 	allStates := make(map[gonfa.State]definition.StateConfig)
 
@@ -169,5 +491,58 @@ func (b *Builder) Build() (*definition.Definition, error) {
 		allStates,
 		b.transitions,
 		b.hooks,
+		b.options...,
 	)
 }
+
+// checkDeclared checks that all used states are declared with States.
+func (b *Builder) checkDeclared() error {
+	undeclared := func(s gonfa.State) bool {
+		return !slices.Contains(b.declared, s)
+	}
+
+	if undeclared(b.initialState) {
+		return fmt.Errorf("initial state '%s' isn't declared", b.initialState)
+	}
+
+	for _, s := range b.finalStates {
+		if undeclared(s) {
+			return fmt.Errorf("final state '%s' isn't declared", s)
+		}
+	}
+
+	for _, s := range slices.Sorted(maps.Keys(b.states)) {
+		if undeclared(s) {
+			return fmt.Errorf("configured state '%s' isn't declared", s)
+		}
+	}
+
+	for _, t := range b.transitions {
+		for _, s := range []gonfa.State{t.From, t.To} {
+			if undeclared(s) {
+				return fmt.Errorf(
+					"state '%s' of transition from '%s' to '%s' "+
+						"on event '%s' isn't declared",
+					s, t.From, t.To, t.On)
+			}
+		}
+	}
+
+	return nil
+}
+
+// BuildWithWarnings works like Build and additionally runs
+// definition.Lint on the built Definition. Warnings don't prevent the
+// Definition from being returned.
+func (b *Builder) BuildWithWarnings() (
+	*definition.Definition,
+	[]definition.Warning,
+	error,
+) {
+	def, err := b.Build()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return def, definition.Lint(def), nil
+}
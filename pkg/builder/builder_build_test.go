@@ -6,6 +6,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/dr-dobermann/gonfa/pkg/definition"
 	"github.com/dr-dobermann/gonfa/pkg/gonfa"
 )
 
@@ -106,3 +107,137 @@ func TestBuildWithHooks(t *testing.T) {
 	assert.Contains(t, hooks.OnSuccess, successAction)
 	assert.Contains(t, hooks.OnFailure, failureAction)
 }
+
+func TestBuildWithWarnings(t *testing.T) {
+	t.Run("valid definition without warnings", func(t *testing.T) {
+		def, warnings, err := New().
+			InitialState("Start").
+			FinalStates("End").
+			AddTransition("Start", "End", "ToEnd").
+			BuildWithWarnings()
+
+		require.NoError(t, err)
+		assert.NotNil(t, def)
+		assert.Empty(t, warnings)
+	})
+
+	t.Run("build error", func(t *testing.T) {
+		def, warnings, err := New().BuildWithWarnings()
+
+		assert.Error(t, err)
+		assert.Nil(t, def)
+		assert.Nil(t, warnings)
+	})
+}
+
+func TestDeclareEvents(t *testing.T) {
+	t.Run("undeclared event warning", func(t *testing.T) {
+		def, warnings, err := New().
+			InitialState("Start").
+			FinalStates("End").
+			DeclareEvents("Submit").
+			DeclareEvents("Approve").
+			AddTransition("Start", "Middle", "Submit").
+			AddTransition("Middle", "End", "Aprove").
+			BuildWithWarnings()
+		require.NoError(t, err)
+
+		events, ok := def.DeclaredEvents()
+		assert.True(t, ok)
+		assert.Equal(t, []gonfa.Event{"Approve", "Submit"}, events)
+
+		require.Len(t, warnings, 1)
+		assert.Equal(t, definition.LintUndeclaredEvent, warnings[0].Check)
+	})
+
+	t.Run("unused declared event", func(t *testing.T) {
+		_, err := New().
+			InitialState("Start").
+			FinalStates("End").
+			DeclareEvents("Finish", "Cancel").
+			RequireAllEventsReachable().
+			AddTransition("Start", "End", "Finish").
+			Build()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "declared event 'Cancel'")
+	})
+}
+
+func TestWithContextValues(t *testing.T) {
+	def, err := New().
+		InitialState("Draft").
+		FinalStates("Done").
+		AddTransition("Draft", "Done", "Finish").
+		WithContextValues(map[any]any{"tenant": "acme", "beta": false}).
+		WithContextValues(map[any]any{"beta": true}).
+		Build()
+	require.NoError(t, err)
+
+	assert.Equal(t, map[any]any{"tenant": "acme", "beta": true},
+		def.ContextValues())
+}
+
+func TestStates(t *testing.T) {
+	t.Run("declared states are registered", func(t *testing.T) {
+		b := New().
+			States("Draft", "Review", "Done").
+			InitialState("Draft").
+			FinalStates("Done").
+			AddTransition("Draft", "Review", "Submit").
+			AddTransition("Review", "Done", "Approve")
+
+		def, err := b.Build()
+		require.NoError(t, err)
+		assert.Len(t, def.States(), 3)
+		assert.Len(t, b.declared, 3)
+
+		// repeated declaration doesn't duplicate states
+		b.States("Draft")
+		assert.Len(t, b.declared, 3)
+	})
+
+	t.Run("declared but unused state", func(t *testing.T) {
+		_, err := New().
+			States("Draft", "Done", "Archived").
+			InitialState("Draft").
+			FinalStates("Done").
+			AddTransition("Draft", "Done", "Approve").
+			Build()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "'Archived'")
+	})
+
+	t.Run("strict states", func(t *testing.T) {
+		b := func() *Builder {
+			return New().
+				StrictStates().
+				States("Draft", "Review", "Done").
+				InitialState("Draft").
+				FinalStates("Done").
+				AddTransition("Draft", "Review", "Submit")
+		}
+
+		_, err := b().AddTransition("Review", "Done", "Approve").Build()
+		require.NoError(t, err)
+
+		_, err = b().AddTransition("Review", "Dnoe", "Approve").Build()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(),
+			"state 'Dnoe' of transition from 'Review' to 'Dnoe' "+
+				"on event 'Approve' isn't declared")
+
+		_, err = b().
+			AddTransition("Review", "Done", "Approve").
+			OnEntry("Reveiw", &testAction{}).
+			Build()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "configured state 'Reveiw' isn't declared")
+
+		_, err = b().
+			AddTransition("Review", "Done", "Approve").
+			FinalStates("Closed").
+			Build()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "final state 'Closed' isn't declared")
+	})
+}
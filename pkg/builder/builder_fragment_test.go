@@ -0,0 +1,118 @@
+package builder
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dr-dobermann/gonfa/pkg/gonfa"
+)
+
+func TestFragments(t *testing.T) {
+	entry := &testAction{name: "entry"}
+	review := &testAction{name: "review"}
+	success := &testAction{name: "success"}
+
+	// approval pattern with dangling Review state and no initial state
+	approval, err := New().
+		FinalStates("Approved").
+		OnEntry("Review", review).
+		AddTransition("Review", "Approved", "Approve").
+		AddTransition("Review", "Draft", "Reject").
+		BuildFragment()
+	require.NoError(t, err)
+
+	submission, err := New().
+		InitialState("Draft").
+		OnEntry("Review", entry).
+		AddTransition("Draft", "Review", "Submit").
+		WithSuccessHooks(success).
+		BuildFragment()
+	require.NoError(t, err)
+
+	t.Run("fragment isn't validated", func(t *testing.T) {
+		assert.Empty(t, approval.InitialState())
+		assert.Len(t, approval.Transitions(), 2)
+	})
+
+	t.Run("merged definition", func(t *testing.T) {
+		def, err := FromFragments(approval, submission).Build()
+		require.NoError(t, err)
+
+		assert.Equal(t, gonfa.State("Draft"), def.InitialState())
+		assert.Equal(t, []gonfa.State{"Approved"}, def.FinalStates())
+		assert.Len(t, def.Transitions(), 3)
+		assert.Equal(t, []gonfa.Action{review, entry},
+			def.GetStateConfig("Review").OnEntry)
+		assert.Equal(t, []gonfa.Action{success}, def.Hooks().OnSuccess)
+	})
+
+	t.Run("fragments aren't changed by the builder", func(t *testing.T) {
+		b := FromFragments(approval, submission)
+		b.OnEntry("Review", &testAction{name: "extra"}).
+			WithGuards(&testGuard{result: true})
+
+		assert.Len(t, approval.States()["Review"].OnEntry, 1)
+		for _, tr := range approval.Transitions() {
+			assert.Empty(t, tr.Guards)
+		}
+	})
+
+	t.Run("conflicting initial states", func(t *testing.T) {
+		other, err := New().
+			InitialState("Review").
+			AddTransition("Review", "Draft", "Reject").
+			BuildFragment()
+		require.NoError(t, err)
+
+		_, err = FromFragments(submission, other).Build()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "conflicting with 'Draft'")
+	})
+
+	t.Run("duplicate transitions", func(t *testing.T) {
+		_, err := FromFragments(approval, submission, approval).Build()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "duplicate transition")
+	})
+
+	t.Run("error states", func(t *testing.T) {
+		handling, err := New().
+			FinalStates("Err").
+			OnActionError("Review", "Err").
+			BuildFragment()
+		require.NoError(t, err)
+
+		def, err := FromFragments(approval, submission, handling).Build()
+		require.NoError(t, err)
+		assert.Equal(t, gonfa.State("Err"),
+			def.GetStateConfig("Review").ErrorState)
+
+		// the same error state may be repeated
+		_, err = FromFragments(approval, submission, handling, handling).Build()
+		require.NoError(t, err)
+
+		other, err := New().
+			FinalStates("Failed").
+			OnActionError("Review", "Failed").
+			BuildFragment()
+		require.NoError(t, err)
+
+		_, err = FromFragments(approval, submission, handling, other).Build()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(),
+			"fragment 3 sets error state 'Failed' of state 'Review' "+
+				"conflicting with 'Err'")
+	})
+
+	t.Run("nil fragment", func(t *testing.T) {
+		_, err := FromFragments(submission, nil).Build()
+		assert.Error(t, err)
+	})
+
+	t.Run("configuration error", func(t *testing.T) {
+		_, err := New().Pipeline([]gonfa.State{"A"}, nil).BuildFragment()
+		assert.Error(t, err)
+	})
+}
@@ -75,3 +75,32 @@ func TestWithHooksCombined(t *testing.T) {
 	assert.Contains(t, builder.hooks.OnSuccess, successAction)
 	assert.Contains(t, builder.hooks.OnFailure, failureAction)
 }
+
+func TestWithHooksMerge(t *testing.T) {
+	base := &testAction{name: "base"}
+	local := &testAction{name: "local"}
+	event := &testAction{name: "event"}
+
+	t.Run("merge", func(t *testing.T) {
+		b := New().
+			WithHooksMerge().
+			OnEvent("Submit", event).
+			WithHooks(definition.Hooks{OnSuccess: []gonfa.Action{base}}).
+			WithHooks(definition.Hooks{
+				OnSuccess: []gonfa.Action{local},
+				OnFailure: []gonfa.Action{local},
+			})
+
+		assert.Equal(t, []gonfa.Action{base, local}, b.hooks.OnSuccess)
+		assert.Equal(t, []gonfa.Action{local}, b.hooks.OnFailure)
+		assert.Equal(t, []gonfa.Action{event}, b.hooks.OnEvent["Submit"])
+	})
+
+	t.Run("replace by default", func(t *testing.T) {
+		b := New().
+			WithHooks(definition.Hooks{OnSuccess: []gonfa.Action{base}}).
+			WithHooks(definition.Hooks{OnSuccess: []gonfa.Action{local}})
+
+		assert.Equal(t, []gonfa.Action{local}, b.hooks.OnSuccess)
+	})
+}
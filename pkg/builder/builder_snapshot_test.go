@@ -0,0 +1,74 @@
+package builder
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dr-dobermann/gonfa/pkg/gonfa"
+)
+
+func TestSnapshot(t *testing.T) {
+	guard := &testGuard{result: true}
+	action := &testAction{name: "action"}
+
+	b := New().
+		InitialState("Draft").
+		FinalStates("Done").
+		OnEntry("Review", action).
+		AddTransition("Draft", "Review", "Submit").
+		WithGuards(guard).
+		AddTransition("Review", "Done", "Approve")
+
+	snapshot := b.Snapshot()
+	original, err := b.Build()
+	require.NoError(t, err)
+
+	// edit the builder after the snapshot
+	b.InitialState("Other").
+		FinalStates("Archived").
+		OnEntry("Review", &testAction{name: "extra"}).
+		WithActions(&testAction{name: "approveAction"}).
+		AddTransition("Done", "Archived", "Archive")
+
+	b.RestoreSnapshot(snapshot)
+	restored, err := b.Build()
+	require.NoError(t, err)
+
+	assert.Equal(t, original.String(), restored.String())
+	assert.Equal(t, original.FinalStates(), restored.FinalStates())
+	assert.Len(t, restored.GetStateConfig("Review").OnEntry, 1)
+	assert.Empty(t, restored.GetTransitions("Review", "Approve")[0].Actions)
+
+	t.Run("last transition is restored", func(t *testing.T) {
+		b.RestoreSnapshot(snapshot).WithActions(action)
+		def, err := b.Build()
+		require.NoError(t, err)
+
+		assert.Len(t, def.GetTransitions("Review", "Approve")[0].Actions, 1)
+	})
+
+	t.Run("snapshot isn't affected by restored builder edits", func(t *testing.T) {
+		b.RestoreSnapshot(snapshot)
+		def, err := b.Build()
+		require.NoError(t, err)
+
+		assert.Empty(t, def.GetTransitions("Review", "Approve")[0].Actions)
+		assert.Equal(t, gonfa.State("Draft"), def.InitialState())
+	})
+}
+
+func TestSnapshotErrorState(t *testing.T) {
+	b := New().
+		InitialState("A").
+		FinalStates("B", "Err").
+		AddTransition("A", "B", "go").
+		OnActionError("A", "Err")
+
+	b.RestoreSnapshot(b.Snapshot())
+	def, err := b.Build()
+	require.NoError(t, err)
+
+	assert.Equal(t, gonfa.State("Err"), def.GetStateConfig("A").ErrorState)
+}
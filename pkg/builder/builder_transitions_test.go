@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/dr-dobermann/gonfa/pkg/gonfa"
 )
@@ -56,6 +57,86 @@ func TestWithGuards(t *testing.T) {
 	assert.Contains(t, builder.lastTransition.Guards, guard2)
 }
 
+func TestWithDefaultGuards(t *testing.T) {
+	auth := &testGuard{result: true}
+	specific := &testGuard{result: true}
+
+	builder := New().
+		AddTransition("Draft", "Review", "Submit").
+		WithDefaultGuards(auth).
+		AddTransition("Review", "Approved", "Approve").
+		WithGuards(specific).
+		AddTransition("Review", "Rejected", "Reject").
+		ClearDefaultGuards().
+		AddTransition("Rejected", "Draft", "Rework")
+
+	require.Len(t, builder.transitions, 4)
+	assert.Empty(t, builder.transitions[0].Guards)
+	assert.Equal(t, []gonfa.Guard{auth, specific}, builder.transitions[1].Guards)
+	assert.Equal(t, []gonfa.Guard{auth}, builder.transitions[2].Guards)
+	assert.Empty(t, builder.transitions[3].Guards)
+
+	// transitions don't share the defaults slice
+	builder.transitions[2].Guards[0] = specific
+	assert.Same(t, auth, builder.transitions[1].Guards[0])
+}
+
+func TestWithTags(t *testing.T) {
+	def, err := New().
+		InitialState("Draft").
+		FinalStates("Done").
+		AddTransition("Draft", "Done", "Approve").
+		WithTags("requires-approval").
+		WithTags("audited").
+		Build()
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"requires-approval", "audited"},
+		def.Transitions()[0].Tags)
+}
+
+func TestWithLabel(t *testing.T) {
+	def, err := New().
+		InitialState("Draft").
+		FinalStates("Done").
+		WithLabel("ignored without a transition").
+		AddTransition("Draft", "Done", "Approve").
+		WithLabel("Approved by manager").
+		Build()
+	require.NoError(t, err)
+
+	assert.Equal(t, "Approved by manager", def.Transitions()[0].Label)
+}
+
+func TestWithDisabled(t *testing.T) {
+	def, err := New().
+		InitialState("Draft").
+		FinalStates("Done").
+		AddTransition("Draft", "Done", "Approve").
+		AddTransition("Draft", "Done", "Skip").
+		WithDisabled().
+		Build()
+	require.NoError(t, err)
+
+	transitions := def.Transitions()
+	assert.False(t, transitions[0].Disabled)
+	assert.True(t, transitions[1].Disabled)
+}
+
+func TestOnActionError(t *testing.T) {
+	def, err := New().
+		InitialState("Pending").
+		FinalStates("Paid", "Failed").
+		OnActionError("Pending", "Failed").
+		AddTransition("Pending", "Paid", "Pay").
+		Build()
+	require.NoError(t, err)
+
+	assert.Equal(t, gonfa.State("Failed"),
+		def.GetStateConfig("Pending").ErrorState)
+	assert.Contains(t, def.States(), gonfa.State("Failed"))
+}
+
 func TestWithGuardsNoTransition(t *testing.T) {
 	builder := New()
 	guard := &testGuard{result: true}
@@ -108,3 +189,117 @@ func TestWithGuardsAndActions(t *testing.T) {
 	assert.Contains(t, transition.Guards, guard)
 	assert.Contains(t, transition.Actions, action)
 }
+
+func TestPipeline(t *testing.T) {
+	t.Run("five stage pipeline", func(t *testing.T) {
+		action := &testAction{name: "publish"}
+
+		def, err := New().
+			Pipeline(
+				[]gonfa.State{"Draft", "Review", "Approved", "Signed", "Published"},
+				[]gonfa.Event{"Submit", "Approve", "Sign", "Publish"}).
+			WithActions(action).
+			Build()
+		require.NoError(t, err)
+
+		assert.Equal(t, gonfa.State("Draft"), def.InitialState())
+		assert.Equal(t, []gonfa.State{"Published"}, def.FinalStates())
+
+		transitions := def.Transitions()
+		require.Len(t, transitions, 4)
+		assert.Equal(t, gonfa.State("Draft"), transitions[0].From)
+		assert.Equal(t, gonfa.State("Review"), transitions[0].To)
+		assert.Equal(t, gonfa.Event("Approve"), transitions[1].On)
+		assert.Equal(t, gonfa.State("Signed"), transitions[3].From)
+		assert.Equal(t, gonfa.State("Published"), transitions[3].To)
+		assert.Len(t, transitions[3].Actions, 1)
+	})
+
+	t.Run("events count mismatch", func(t *testing.T) {
+		_, err := New().
+			Pipeline([]gonfa.State{"A", "B", "C"}, []gonfa.Event{"e1"}).
+			Build()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(),
+			"pipeline of 3 states requires 2 events, got 1")
+	})
+
+	t.Run("too few states", func(t *testing.T) {
+		_, err := New().
+			Pipeline([]gonfa.State{"A"}, nil).
+			Build()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "at least two states")
+	})
+}
+
+func TestAddSwitchTransition(t *testing.T) {
+	keyFn := func(p gonfa.Payload) string {
+		s, _ := p.(string)
+		return s
+	}
+
+	t.Run("transition per target", func(t *testing.T) {
+		def, err := New().
+			InitialState("Triage").
+			FinalStates("Billing", "Support").
+			AddSwitchTransition("Triage", "Route", map[string]gonfa.State{
+				"invoice": "Billing",
+				"refund":  "Billing",
+				"bug":     "Support",
+			}, keyFn).
+			Build()
+		require.NoError(t, err)
+
+		transitions := def.GetTransitions("Triage", "Route")
+		require.Len(t, transitions, 2)
+		assert.Equal(t, gonfa.State("Billing"), transitions[0].To)
+		assert.Equal(t, gonfa.State("Support"), transitions[1].To)
+	})
+
+	t.Run("modifiers are ignored", func(t *testing.T) {
+		b := New().
+			AddSwitchTransition("Triage", "Route",
+				map[string]gonfa.State{"bug": "Support"}, keyFn).
+			WithActions(&testAction{})
+
+		require.Len(t, b.transitions, 1)
+		assert.Empty(t, b.transitions[0].Actions)
+	})
+
+	tests := []struct {
+		name   string
+		routes map[string]gonfa.State
+		keyFn  func(gonfa.Payload) string
+		errMsg string
+	}{
+		{
+			name:   "no routes",
+			keyFn:  keyFn,
+			errMsg: "switch transition from 'Triage' on event 'Route' has no routes",
+		},
+		{
+			name:   "no key function",
+			routes: map[string]gonfa.State{"bug": "Support"},
+			errMsg: "switch transition from 'Triage' on event 'Route' " +
+				"has no key function",
+		},
+		{
+			name:   "empty target",
+			routes: map[string]gonfa.State{"bug": ""},
+			keyFn:  keyFn,
+			errMsg: "switch transition from 'Triage' on event 'Route' " +
+				"has empty target for key 'bug'",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New().
+				InitialState("Triage").
+				AddSwitchTransition("Triage", "Route", tt.routes, tt.keyFn).
+				Build()
+			assert.EqualError(t, err, tt.errMsg)
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package builder
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/dr-dobermann/gonfa/pkg/definition"
+)
+
+// BuildFragment returns the builder configuration as a reusable
+// definition.Fragment. Unlike Build it doesn't validate the configuration:
+// the fragment may lack an initial state or have dangling states. Only
+// configuration errors (e.g. from Pipeline) are reported.
+func (b *Builder) BuildFragment() (*definition.Fragment, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+
+	return definition.NewFragment(
+		b.initialState,
+		b.finalStates,
+		cloneStates(b.states),
+		cloneTransitions(b.transitions),
+		cloneHooks(b.hooks),
+	), nil
+}
+
+// FromFragments creates a Builder pre-filled with the merged fragments.
+// The result can be configured further and is validated by Build as usual.
+//
+// Fragments are merged in the given order:
+//   - at most one distinct initial state may be declared; fragments
+//     declaring different initial states make Build fail;
+//   - final states are united;
+//   - actions and accept guards of a state declared in several fragments
+//     are concatenated; its error state (see OnActionError) may be set by
+//     several fragments only if it's the same, otherwise Build fails;
+//   - transitions and hooks are concatenated; the same transition (from, to,
+//     event) declared in several fragments makes Build fail as a duplicate.
+//
+// The builder has no "last" transition, so WithGuards/WithActions have no
+// effect until the next AddTransition.
+func FromFragments(frags ...*definition.Fragment) *Builder {
+	b := New()
+
+	for i, f := range frags {
+		if f == nil {
+			b.setErr(fmt.Errorf("fragment %d is nil", i))
+			continue
+		}
+
+		if initial := f.InitialState(); initial != "" {
+			if b.initialState != "" && b.initialState != initial {
+				b.setErr(fmt.Errorf(
+					"fragment %d declares initial state '%s' "+
+						"conflicting with '%s'",
+					i, initial, b.initialState))
+			} else {
+				b.InitialState(initial)
+			}
+		}
+
+		for _, s := range f.FinalStates() {
+			if !slices.Contains(b.finalStates, s) {
+				b.FinalStates(s)
+			}
+		}
+
+		states := f.States()
+		for _, s := range slices.Sorted(maps.Keys(states)) {
+			cfg := states[s]
+			config := b.states[s]
+			config.OnEntry = append(config.OnEntry, cfg.OnEntry...)
+			config.OnExit = append(config.OnExit, cfg.OnExit...)
+			config.AcceptIf = append(config.AcceptIf, cfg.AcceptIf...)
+
+			if cfg.ErrorState != "" {
+				if config.ErrorState != "" &&
+					config.ErrorState != cfg.ErrorState {
+					b.setErr(fmt.Errorf(
+						"fragment %d sets error state '%s' of state '%s' "+
+							"conflicting with '%s'",
+						i, cfg.ErrorState, s, config.ErrorState))
+				} else {
+					config.ErrorState = cfg.ErrorState
+				}
+			}
+
+			b.states[s] = config
+		}
+
+		b.transitions = append(b.transitions,
+			cloneTransitions(f.Transitions())...)
+
+		hooks := f.Hooks()
+		b.WithSuccessHooks(hooks.OnSuccess...)
+		b.WithFailureHooks(hooks.OnFailure...)
+		for e, aa := range hooks.OnEvent {
+			b.OnEvent(e, aa...)
+		}
+	}
+
+	return b
+}
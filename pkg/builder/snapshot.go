@@ -0,0 +1,131 @@
+package builder
+
+import (
+	"slices"
+
+	"github.com/dr-dobermann/gonfa/pkg/definition"
+	"github.com/dr-dobermann/gonfa/pkg/gonfa"
+)
+
+// BuilderState is an opaque deep copy of the builder's in-progress
+// configuration made by Builder.Snapshot.
+type BuilderState struct {
+	initialState gonfa.State
+	finalStates  []gonfa.State
+	states       map[gonfa.State]definition.StateConfig
+	transitions  []definition.Transition
+	hooks        definition.Hooks
+	mergeHooks   bool
+	lastIndex    int // index of the last transition or -1
+	defaults     []gonfa.Guard
+	declared     []gonfa.State
+	strict       bool
+	options      []definition.Option
+	err          error
+}
+
+// Snapshot returns a deep copy of the current builder configuration.
+// Later changes of the builder don't affect the snapshot, so it can be used
+// to implement undo/redo in workflow editors.
+func (b *Builder) Snapshot() BuilderState {
+	lastIndex := -1
+	if b.lastTransition != nil {
+		lastIndex = len(b.transitions) - 1
+	}
+
+	return BuilderState{
+		initialState: b.initialState,
+		finalStates:  slices.Clone(b.finalStates),
+		states:       cloneStates(b.states),
+		transitions:  cloneTransitions(b.transitions),
+		hooks:        cloneHooks(b.hooks),
+		mergeHooks:   b.mergeHooks,
+		lastIndex:    lastIndex,
+		defaults:     slices.Clone(b.defaultGuards),
+		declared:     slices.Clone(b.declared),
+		strict:       b.strict,
+		options:      slices.Clone(b.options),
+		err:          b.err,
+	}
+}
+
+// RestoreSnapshot replaces the builder configuration with the snapshot.
+// The snapshot is copied, so it can be restored again later.
+func (b *Builder) RestoreSnapshot(s BuilderState) *Builder {
+	b.initialState = s.initialState
+	b.finalStates = slices.Clone(s.finalStates)
+	b.states = cloneStates(s.states)
+	if b.states == nil {
+		b.states = make(map[gonfa.State]definition.StateConfig)
+	}
+	b.transitions = cloneTransitions(s.transitions)
+	b.hooks = cloneHooks(s.hooks)
+	b.mergeHooks = s.mergeHooks
+	b.defaultGuards = slices.Clone(s.defaults)
+	b.declared = slices.Clone(s.declared)
+	b.strict = s.strict
+	b.options = slices.Clone(s.options)
+	b.err = s.err
+
+	b.lastTransition = nil
+	if s.lastIndex >= 0 {
+		b.lastTransition = &b.transitions[s.lastIndex]
+	}
+
+	return b
+}
+
+// cloneStates deep copies the states configuration.
+func cloneStates(
+	states map[gonfa.State]definition.StateConfig,
+) map[gonfa.State]definition.StateConfig {
+	if states == nil {
+		return nil
+	}
+
+	c := make(map[gonfa.State]definition.StateConfig, len(states))
+	for s, cfg := range states {
+		// Copy all the fields first, so new ones aren't lost
+		cfg.OnEntry = slices.Clone(cfg.OnEntry)
+		cfg.OnExit = slices.Clone(cfg.OnExit)
+		cfg.AcceptIf = slices.Clone(cfg.AcceptIf)
+		c[s] = cfg
+	}
+
+	return c
+}
+
+// cloneTransitions deep copies the transitions.
+func cloneTransitions(
+	transitions []definition.Transition,
+) []definition.Transition {
+	c := slices.Clone(transitions)
+	for i := range c {
+		c[i].Guards = slices.Clone(c[i].Guards)
+		c[i].Actions = slices.Clone(c[i].Actions)
+		c[i].Preconditions = slices.Clone(c[i].Preconditions)
+		c[i].AfterActions = slices.Clone(c[i].AfterActions)
+		c[i].Compensations = slices.Clone(c[i].Compensations)
+		c[i].AsyncActions = slices.Clone(c[i].AsyncActions)
+		c[i].Tags = slices.Clone(c[i].Tags)
+	}
+
+	return c
+}
+
+// cloneHooks deep copies the hooks.
+func cloneHooks(h definition.Hooks) definition.Hooks {
+	c := definition.Hooks{
+		OnSuccess: slices.Clone(h.OnSuccess),
+		OnFailure: slices.Clone(h.OnFailure),
+	}
+
+	if h.OnEvent != nil {
+		c.OnEvent = make(map[gonfa.Event][]gonfa.Action, len(h.OnEvent))
+		for e, aa := range h.OnEvent {
+			c.OnEvent[e] = slices.Clone(aa)
+		}
+	}
+
+	return c
+}
@@ -0,0 +1,101 @@
+package builder
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/dr-dobermann/gonfa/pkg/definition"
+	"github.com/dr-dobermann/gonfa/pkg/gonfa"
+)
+
+// DefaultRoute is the routes key of AddSwitchTransition taken when the
+// payload key matches no other route.
+const DefaultRoute = ""
+
+// AddSwitchTransition adds a content-based router: on the event the machine
+// moves from the state to the target routes[keyFn(payload)]. If the key
+// matches no route, the DefaultRoute target is taken; without a default
+// route the event is rejected like by a failed guard (Fire returns false
+// and runs the failure hooks).
+//
+// It adds a transition per distinct target guarded by a route guard, which
+// calls keyFn once per candidate transition, so keyFn should be cheap and
+// deterministic. Build returns an error if routes is empty, keyFn is nil or
+// a target is empty; in strict mode (see StrictStates) the targets must be
+// declared like any other state.
+//
+// The route transitions don't become the "last" transition, so subsequent
+// WithGuards/WithActions calls are ignored until the next AddTransition.
+func (b *Builder) AddSwitchTransition(
+	from gonfa.State,
+	on gonfa.Event,
+	routes map[string]gonfa.State,
+	keyFn func(gonfa.Payload) string,
+) *Builder {
+	b.lastTransition = nil
+
+	if len(routes) == 0 {
+		b.setErr(fmt.Errorf(
+			"switch transition from '%s' on event '%s' has no routes",
+			from, on))
+		return b
+	}
+
+	if keyFn == nil {
+		b.setErr(fmt.Errorf(
+			"switch transition from '%s' on event '%s' has no key function",
+			from, on))
+		return b
+	}
+
+	var targets []gonfa.State
+	for key, to := range routes {
+		if to == "" {
+			b.setErr(fmt.Errorf(
+				"switch transition from '%s' on event '%s' "+
+					"has empty target for key '%s'", from, on, key))
+			return b
+		}
+
+		if !slices.Contains(targets, to) {
+			targets = append(targets, to)
+		}
+	}
+	slices.Sort(targets)
+
+	routes = maps.Clone(routes)
+	for _, to := range targets {
+		b.transitions = append(b.transitions, definition.Transition{
+			From: from,
+			To:   to,
+			On:   on,
+			Guards: append(slices.Clone(b.defaultGuards),
+				&routeGuard{routes: routes, keyFn: keyFn, to: to}),
+		})
+	}
+
+	return b
+}
+
+// routeGuard passes if the payload key routes to the transition's target.
+type routeGuard struct {
+	routes map[string]gonfa.State
+	keyFn  func(gonfa.Payload) string
+	to     gonfa.State
+}
+
+// Check implements gonfa.Guard.
+func (g *routeGuard) Check(
+	_ context.Context,
+	_ gonfa.MachineState,
+	payload gonfa.Payload,
+) bool {
+	to, ok := g.routes[g.keyFn(payload)]
+	if !ok {
+		to, ok = g.routes[DefaultRoute]
+	}
+
+	return ok && to == g.to
+}
@@ -0,0 +1,163 @@
+package definition
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/dr-dobermann/gonfa/pkg/gonfa"
+)
+
+// acceptingSetChecks selects the checks run by ValidateAcceptingSet.
+type acceptingSetChecks struct {
+	nonEmpty     bool
+	initialFinal bool
+	acceptingRun bool
+}
+
+// AcceptingSetOption turns off one of the ValidateAcceptingSet checks.
+type AcceptingSetOption func(*acceptingSetChecks)
+
+// AllowEmptyAcceptingSet turns off the check that the definition has at
+// least one final state.
+func AllowEmptyAcceptingSet() AcceptingSetOption {
+	return func(c *acceptingSetChecks) {
+		c.nonEmpty = false
+	}
+}
+
+// AllowInitialFinal turns off the check that the initial state isn't a
+// final state.
+func AllowInitialFinal() AcceptingSetOption {
+	return func(c *acceptingSetChecks) {
+		c.initialFinal = false
+	}
+}
+
+// SkipAcceptingRunCheck turns off the check that a final state is reachable
+// from the initial state.
+func SkipAcceptingRunCheck() AcceptingSetOption {
+	return func(c *acceptingSetChecks) {
+		c.acceptingRun = false
+	}
+}
+
+// ValidateAcceptingSet checks the definition is a sensible acceptor:
+//   - the final states set is non-empty;
+//   - the initial state isn't a final state;
+//   - at least one accepting run exists, i.e. a final state is reachable
+//     from the initial state (guards are ignored).
+//
+// All checks are on by default and can be turned off with options.
+// Errors of all failed checks are joined together.
+func (d *Definition) ValidateAcceptingSet(opts ...AcceptingSetOption) error {
+	checks := acceptingSetChecks{
+		nonEmpty:     true,
+		initialFinal: true,
+		acceptingRun: true,
+	}
+	for _, opt := range opts {
+		opt(&checks)
+	}
+
+	var errs []error
+
+	if checks.nonEmpty && len(d.finalStates) == 0 {
+		errs = append(errs, fmt.Errorf("definition has no final states"))
+	}
+
+	if checks.initialFinal && d.IsFinalState(d.initialState) {
+		errs = append(errs, fmt.Errorf(
+			"initial state '%s' is also a final state", d.initialState))
+	}
+
+	if checks.acceptingRun && len(d.finalStates) > 0 {
+		graph, _ := newTransitionGraph(d.transitions)
+		reachable := findReachableStates(d.initialState, graph)
+
+		found := false
+		for _, s := range d.finalStates {
+			if reachable.contains(s) {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			errs = append(errs, fmt.Errorf(
+				"no final state is reachable from initial state '%s'",
+				d.initialState))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// ShortestAcceptingRun returns the shortest sequence of events leading from
+// the initial state to any final state, ignoring guards. If there are
+// several shortest runs, the lexicographically smallest event sequence is
+// returned. Returns false if no final state is reachable. The run of a
+// definition whose initial state is final is empty.
+func (d *Definition) ShortestAcceptingRun() ([]gonfa.Event, bool) {
+	// runGroup holds the states first reached by the run.
+	type runGroup struct {
+		run    []gonfa.Event
+		states []gonfa.State
+	}
+
+	outgoing := make(map[gonfa.State][]Transition)
+	for _, t := range d.transitions {
+		outgoing[t.From] = append(outgoing[t.From], t)
+	}
+
+	// Breadth-first search over groups of states sharing the same run.
+	// Groups of every level are kept in lexicographic order of their runs,
+	// so the first group with a final state has the smallest run.
+	visited := newStateSet([]gonfa.State{d.initialState})
+	level := []runGroup{{states: []gonfa.State{d.initialState}}}
+
+	for len(level) > 0 {
+		for _, g := range level {
+			if slices.ContainsFunc(g.states, d.IsFinalState) {
+				return g.run, true
+			}
+		}
+
+		var next []runGroup
+		for _, g := range level {
+			targets := make(map[gonfa.Event][]gonfa.State)
+			for _, s := range g.states {
+				for _, t := range outgoing[s] {
+					targets[t.On] = append(targets[t.On], t.To)
+				}
+			}
+
+			events := make([]gonfa.Event, 0, len(targets))
+			for e := range targets {
+				events = append(events, e)
+			}
+			slices.Sort(events)
+
+			for _, e := range events {
+				var states []gonfa.State
+				for _, s := range targets[e] {
+					if !visited.contains(s) {
+						visited[s] = struct{}{}
+						states = append(states, s)
+					}
+				}
+
+				if len(states) > 0 {
+					next = append(next, runGroup{
+						run:    append(slices.Clone(g.run), e),
+						states: states,
+					})
+				}
+			}
+		}
+
+		level = next
+	}
+
+	return nil, false
+}
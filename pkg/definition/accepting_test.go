@@ -0,0 +1,140 @@
+package definition
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dr-dobermann/gonfa/pkg/gonfa"
+)
+
+func TestValidateAcceptingSet(t *testing.T) {
+	states := map[gonfa.State]StateConfig{"Start": {}, "Loop": {}, "End": {}}
+
+	t.Run("valid acceptor", func(t *testing.T) {
+		def, err := New("Start", []gonfa.State{"End"}, states, []Transition{
+			{From: "Start", To: "Loop", On: "next"},
+			{From: "Loop", To: "End", On: "next"},
+		}, Hooks{})
+		require.NoError(t, err)
+
+		assert.NoError(t, def.ValidateAcceptingSet())
+	})
+
+	t.Run("empty accepting set", func(t *testing.T) {
+		def, err := New("Start", nil, states, []Transition{
+			{From: "Start", To: "Loop", On: "next"},
+			{From: "Loop", To: "End", On: "next"},
+			{From: "End", To: "Start", On: "next"},
+		}, Hooks{})
+		require.NoError(t, err)
+
+		err = def.ValidateAcceptingSet()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "definition has no final states")
+
+		assert.NoError(t, def.ValidateAcceptingSet(AllowEmptyAcceptingSet()))
+	})
+
+	t.Run("initial state is final", func(t *testing.T) {
+		// a structurally valid definition can't have a final initial state,
+		// so the check is exercised on a hand-made definition
+		def := &Definition{
+			initialState: "Start",
+			finalStates:  []gonfa.State{"Start"},
+		}
+
+		err := def.ValidateAcceptingSet()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "initial state 'Start' is also a final state")
+
+		assert.NoError(t, def.ValidateAcceptingSet(AllowInitialFinal()))
+	})
+
+	t.Run("no accepting run", func(t *testing.T) {
+		def := &Definition{
+			initialState: "Start",
+			finalStates:  []gonfa.State{"End"},
+			transitions: []Transition{
+				{From: "Start", To: "Loop", On: "next"},
+				{From: "Loop", To: "Start", On: "next"},
+			},
+		}
+
+		err := def.ValidateAcceptingSet()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "no final state is reachable")
+
+		assert.NoError(t, def.ValidateAcceptingSet(SkipAcceptingRunCheck()))
+	})
+}
+
+func TestShortestAcceptingRun(t *testing.T) {
+	states := map[gonfa.State]StateConfig{
+		"Start": {}, "A": {}, "B": {}, "P": {}, "Q": {}, "End": {},
+	}
+
+	t.Run("shortest run", func(t *testing.T) {
+		def, err := New("Start", []gonfa.State{"End"}, states, []Transition{
+			{From: "Start", To: "A", On: "a"},
+			{From: "A", To: "B", On: "a"},
+			{From: "B", To: "End", On: "a"},
+			{From: "Start", To: "P", On: "b"},
+			{From: "P", To: "End", On: "b"},
+			{From: "Start", To: "Q", On: "c"},
+			{From: "Q", To: "End", On: "a"},
+		}, Hooks{})
+		require.NoError(t, err)
+
+		run, ok := def.ShortestAcceptingRun()
+		require.True(t, ok)
+		assert.Equal(t, []gonfa.Event{"b", "b"}, run)
+	})
+
+	t.Run("lexicographically smallest run with shared prefix", func(t *testing.T) {
+		def, err := New("Start", []gonfa.State{"End"}, states, []Transition{
+			{From: "Start", To: "P", On: "a"},
+			{From: "Start", To: "Q", On: "a"},
+			{From: "P", To: "End", On: "y"},
+			{From: "Q", To: "End", On: "x"},
+			{From: "Start", To: "A", On: "b"},
+			{From: "A", To: "B", On: "b"},
+			{From: "B", To: "End", On: "b"},
+		}, Hooks{})
+		require.NoError(t, err)
+
+		run, ok := def.ShortestAcceptingRun()
+		require.True(t, ok)
+		assert.Equal(t, []gonfa.Event{"a", "x"}, run)
+	})
+
+	t.Run("initial state is final", func(t *testing.T) {
+		def := &Definition{
+			initialState: "Start",
+			finalStates:  []gonfa.State{"Start", "End"},
+			transitions: []Transition{
+				{From: "Start", To: "End", On: "a"},
+			},
+		}
+
+		run, ok := def.ShortestAcceptingRun()
+		assert.True(t, ok)
+		assert.Empty(t, run)
+	})
+
+	t.Run("no accepting run", func(t *testing.T) {
+		def := &Definition{
+			initialState: "Start",
+			finalStates:  []gonfa.State{"End"},
+			transitions: []Transition{
+				{From: "Start", To: "A", On: "a"},
+				{From: "A", To: "Start", On: "a"},
+			},
+		}
+
+		run, ok := def.ShortestAcceptingRun()
+		assert.False(t, ok)
+		assert.Nil(t, run)
+	})
+}
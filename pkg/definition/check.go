@@ -2,6 +2,8 @@ package definition
 
 import (
 	"fmt"
+	"maps"
+	"slices"
 
 	"github.com/dr-dobermann/gonfa/pkg/gonfa"
 )
@@ -24,6 +26,51 @@ func (s stateSet) contains(state gonfa.State) bool {
 	return exists
 }
 
+// sorted returns the states of the set in ascending order, so errors don't
+// depend on the map iteration order
+func (s stateSet) sorted() []gonfa.State {
+	return slices.Sorted(maps.Keys(s))
+}
+
+// stateError is a graph check error about a single state, so loaders could
+// point at the state's declaration
+type stateError struct {
+	state gonfa.State
+	msg   string
+}
+
+// newStateError returns a stateError about the state with the formatted
+// message
+func newStateError(state gonfa.State, format string, args ...any) error {
+	return &stateError{state: state, msg: fmt.Sprintf(format, args...)}
+}
+
+// Error returns the error message
+func (e *stateError) Error() string {
+	return e.msg
+}
+
+// transitionError is a check error about a single transition, so loaders
+// could point at the transition's declaration
+type transitionError struct {
+	key transitionKey
+	msg string
+}
+
+// newTransitionError returns a transitionError about t with the formatted
+// message
+func newTransitionError(t Transition, format string, args ...any) error {
+	return &transitionError{
+		key: transitionKey{from: t.From, to: t.To, on: t.On},
+		msg: fmt.Sprintf(format, args...),
+	}
+}
+
+// Error returns the error message
+func (e *transitionError) Error() string {
+	return e.msg
+}
+
 // transitionGraph represents state transition graph
 type transitionGraph map[gonfa.State]stateSet
 
@@ -61,18 +108,46 @@ func newTransitionGraph(transitions []Transition) (transitionGraph, error) {
 	return graph, nil
 }
 
+// addErrorStates checks that error states exist and adds the catching edges
+// to the graph.
+func addErrorStates(
+	graph transitionGraph,
+	errorStates map[gonfa.State]gonfa.State,
+	states stateSet,
+) error {
+	for _, from := range slices.Sorted(maps.Keys(errorStates)) {
+		to := errorStates[from]
+		if !states.contains(to) {
+			return fmt.Errorf(
+				"error state '%s' of state '%s' doesn't exist in states",
+				to, from)
+		}
+
+		if graph[from] == nil {
+			graph[from] = make(stateSet)
+		}
+		graph[from][to] = struct{}{}
+	}
+
+	return nil
+}
+
 // stateCounter tracks incoming and outgoing transition counts
 type stateCounter struct {
 	incoming int
 	outgoing int
 }
 
-// checkStates performs optimized integrity check
+// checkStates performs optimized integrity check.
+// errorStates maps states to their error states (see
+// StateConfig.ErrorState); the catching edges count as transitions in the
+// connectivity analysis.
 func checkStates(
 	initialState gonfa.State,
 	states []gonfa.State,
 	transitions []Transition,
 	finalStates []gonfa.State,
+	errorStates map[gonfa.State]gonfa.State,
 ) error {
 	stateSet := newStateSet(states)
 	finalSet := newStateSet(finalStates)
@@ -90,10 +165,26 @@ func checkStates(
 		return err
 	}
 
+	if err := addErrorStates(graph, errorStates, stateSet); err != nil {
+		return err
+	}
+
 	if err := validateTransitionStates(graph, stateSet); err != nil {
 		return err
 	}
 
+	if err := validateWeights(transitions); err != nil {
+		return err
+	}
+
+	if err := validateAsyncActions(transitions); err != nil {
+		return err
+	}
+
+	if err := validateTransitionNames(transitions); err != nil {
+		return err
+	}
+
 	return analyzeGraphStructure(initialState, finalSet, stateSet, graph)
 }
 
@@ -112,7 +203,7 @@ func validateInitialState(
 
 // validateFinalStates checks if all final states exist
 func validateFinalStates(finalSet, stateSet stateSet) error {
-	for state := range finalSet {
+	for _, state := range finalSet.sorted() {
 		if !stateSet.contains(state) {
 			return fmt.Errorf(
 				"final state '%s' doesn't exist in states",
@@ -127,14 +218,14 @@ func validateTransitionStates(
 	graph transitionGraph,
 	stateSet stateSet,
 ) error {
-	for fromState, toStates := range graph {
+	for _, fromState := range slices.Sorted(maps.Keys(graph)) {
 		if !stateSet.contains(fromState) {
 			return fmt.Errorf(
 				"state '%s' doesn't exist as transition source",
 				fromState)
 		}
 
-		for toState := range toStates {
+		for _, toState := range graph[fromState].sorted() {
 			if !stateSet.contains(toState) {
 				return fmt.Errorf(
 					"state '%s' doesn't exist as transition target",
@@ -145,6 +236,115 @@ func validateTransitionStates(
 	return nil
 }
 
+// validateWeights checks that no transition has a negative weight and that
+// competing transitions (same From and On) are either all unweighted or all
+// have positive weights, since weighted selection never attempts a
+// zero-weight transition competing with weighted ones
+func validateWeights(transitions []Transition) error {
+	if errs := weightErrors(transitions); len(errs) > 0 {
+		return errs[0]
+	}
+	return nil
+}
+
+// weightErrors returns all problems validateWeights checks for, in the
+// order of transitions.
+func weightErrors(transitions []Transition) []error {
+	type groupKey struct {
+		from gonfa.State
+		on   gonfa.Event
+	}
+
+	weighted := make(map[groupKey]bool)
+	for _, t := range transitions {
+		if t.Weight > 0 {
+			weighted[groupKey{t.From, t.On}] = true
+		}
+	}
+
+	var errs []error
+	for _, t := range transitions {
+		switch {
+		case t.Weight < 0:
+			errs = append(errs, newTransitionError(t,
+				"transition from '%s' to '%s' on event '%s' has negative weight %v",
+				t.From, t.To, t.On, t.Weight))
+
+		case t.Weight == 0 && weighted[groupKey{t.From, t.On}]:
+			errs = append(errs, newTransitionError(t,
+				"transition from '%s' to '%s' on event '%s' has no positive "+
+					"weight but competing transitions are weighted",
+				t.From, t.To, t.On))
+		}
+	}
+
+	return errs
+}
+
+// validateTransitionNames checks that the guard and action names, if set,
+// match the transition's guards and actions one to one
+func validateTransitionNames(transitions []Transition) error {
+	for _, t := range transitions {
+		if len(t.GuardNames) > 0 && len(t.GuardNames) != len(t.Guards) {
+			return fmt.Errorf(
+				"transition from '%s' to '%s' on event '%s' has %d guard names for %d guards",
+				t.From, t.To, t.On, len(t.GuardNames), len(t.Guards))
+		}
+
+		if len(t.ActionNames) > 0 && len(t.ActionNames) != len(t.Actions) {
+			return fmt.Errorf(
+				"transition from '%s' to '%s' on event '%s' has %d action names for %d actions",
+				t.From, t.To, t.On, len(t.ActionNames), len(t.Actions))
+		}
+	}
+	return nil
+}
+
+// validateAsyncActions checks that every async action is set and its
+// completion events are handled by the pending state, i.e. the target of
+// the transition.
+func validateAsyncActions(transitions []Transition) error {
+	if errs := asyncActionErrors(transitions); len(errs) > 0 {
+		return errs[0]
+	}
+	return nil
+}
+
+// asyncActionErrors returns all problems validateAsyncActions checks for
+// in the order of transitions.
+func asyncActionErrors(transitions []Transition) []error {
+	handled := make(map[gonfa.State]map[gonfa.Event]struct{})
+	for _, t := range transitions {
+		if handled[t.From] == nil {
+			handled[t.From] = make(map[gonfa.Event]struct{})
+		}
+		handled[t.From][t.On] = struct{}{}
+	}
+
+	var errs []error
+	for _, t := range transitions {
+		for _, a := range t.AsyncActions {
+			if a.Action == nil {
+				errs = append(errs, fmt.Errorf(
+					"transition from '%s' to '%s' on event '%s' has nil async action",
+					t.From, t.To, t.On))
+				continue
+			}
+
+			for _, e := range []gonfa.Event{a.OnDone, a.OnFail} {
+				if _, ok := handled[t.To][e]; !ok {
+					errs = append(errs, fmt.Errorf(
+						"pending state '%s' has no transition on "+
+							"async action completion event '%s'",
+						t.To, e))
+				}
+			}
+		}
+	}
+
+	return errs
+}
+
 // analyzeGraphStructure performs graph connectivity and reachability checks
 func analyzeGraphStructure(
 	initialState gonfa.State,
@@ -167,7 +367,11 @@ func analyzeGraphStructure(
 		return err
 	}
 
-	return validateFinalStateReachability(finalSet, reachable)
+	if err := validateFinalStateReachability(finalSet, reachable); err != nil {
+		return err
+	}
+
+	return validateStateReachability(stateSet, reachable)
 }
 
 // buildStateCounters creates transition counters for all states
@@ -241,10 +445,10 @@ func validateStateConnectivity(
 	initialState gonfa.State,
 	finalSet stateSet,
 ) error {
-	for state, counter := range counters {
+	for _, state := range slices.Sorted(maps.Keys(counters)) {
 		if err := validateSingleState(
 			state,
-			counter,
+			counters[state],
 			initialState,
 			finalSet,
 		); err != nil {
@@ -261,28 +465,47 @@ func validateSingleState(
 	initialState gonfa.State,
 	finalSet stateSet,
 ) error {
+	if errs := connectivityErrors(
+		state,
+		counter,
+		initialState,
+		finalSet,
+	); len(errs) > 0 {
+		return errs[0]
+	}
+	return nil
+}
+
+// connectivityErrors returns all connectivity problems of a single state:
+// hanging, dead-end and final with outgoing transitions.
+func connectivityErrors(
+	state gonfa.State,
+	counter *stateCounter,
+	initialState gonfa.State,
+	finalSet stateSet,
+) []error {
+	var errs []error
+
 	isFinal := finalSet.contains(state)
 
-	// Check for hanging states
 	if counter.incoming == 0 && state != initialState {
-		return fmt.Errorf(
+		errs = append(errs, newStateError(state,
 			"state '%s' isn't an initial state but has no incoming transitions",
-			state)
+			state))
 	}
 
-	// Check for dead-end non-final states
 	if counter.outgoing == 0 && !isFinal {
-		return fmt.Errorf("state '%s' is a dead-end state", state)
+		errs = append(errs, newStateError(state,
+			"state '%s' is a dead-end state", state))
 	}
 
-	// Check for final states with outgoing transitions
 	if isFinal && counter.outgoing > 0 {
-		return fmt.Errorf(
+		errs = append(errs, newStateError(state,
 			"final state '%s' has outgoing transition(s)",
-			state)
+			state))
 	}
 
-	return nil
+	return errs
 }
 
 // validateFinalStateReachability checks if all final states are reachable
@@ -290,12 +513,28 @@ func validateFinalStateReachability(
 	finalSet stateSet,
 	reachable stateSet,
 ) error {
-	for state := range finalSet {
+	for _, state := range finalSet.sorted() {
 		if !reachable.contains(state) {
-			return fmt.Errorf(
+			return newStateError(state,
 				"final state '%s' is not reachable from initial state",
 				state)
 		}
 	}
 	return nil
 }
+
+// validateStateReachability checks if all states are reachable, so states
+// forming a loop disconnected from the initial state are caught
+func validateStateReachability(
+	stateSet stateSet,
+	reachable stateSet,
+) error {
+	for _, state := range stateSet.sorted() {
+		if !reachable.contains(state) {
+			return newStateError(state,
+				"state '%s' is not reachable from initial state",
+				state)
+		}
+	}
+	return nil
+}
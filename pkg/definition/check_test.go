@@ -98,7 +98,7 @@ func TestCheckStatesOptimized(t *testing.T) {
 			{From: "Start", To: "End", On: "finish"},
 		}
 
-		err := checkStates(initialState, states, transitions, finalStates)
+		err := checkStates(initialState, states, transitions, finalStates, nil)
 		assert.NoError(t, err)
 	})
 
@@ -110,7 +110,7 @@ func TestCheckStatesOptimized(t *testing.T) {
 			{From: "Start", To: "End", On: "finish"},
 		}
 
-		err := checkStates(initialState, states, transitions, finalStates)
+		err := checkStates(initialState, states, transitions, finalStates, nil)
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "initial state 'NonExistent' doesn't exist in states")
 	})
@@ -123,7 +123,7 @@ func TestCheckStatesOptimized(t *testing.T) {
 			{From: "Start", To: "Middle", On: "move"},
 		}
 
-		err := checkStates(initialState, states, transitions, finalStates)
+		err := checkStates(initialState, states, transitions, finalStates, nil)
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "final state 'End' doesn't exist in states")
 	})
@@ -136,7 +136,7 @@ func TestCheckStatesOptimized(t *testing.T) {
 			{From: "NonExistent", To: "End", On: "finish"},
 		}
 
-		err := checkStates(initialState, states, transitions, finalStates)
+		err := checkStates(initialState, states, transitions, finalStates, nil)
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "state 'NonExistent' doesn't exist as transition source")
 	})
@@ -149,7 +149,7 @@ func TestCheckStatesOptimized(t *testing.T) {
 			{From: "Start", To: "NonExistent", On: "move"},
 		}
 
-		err := checkStates(initialState, states, transitions, finalStates)
+		err := checkStates(initialState, states, transitions, finalStates, nil)
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "state 'NonExistent' doesn't exist as transition target")
 	})
@@ -163,7 +163,7 @@ func TestCheckStatesOptimized(t *testing.T) {
 			{From: "Start", To: "End", On: "finish"}, // Exact duplicate
 		}
 
-		err := checkStates(initialState, states, transitions, finalStates)
+		err := checkStates(initialState, states, transitions, finalStates, nil)
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "duplicate transition from 'Start' to 'End' on event 'finish'")
 	})
@@ -415,7 +415,7 @@ func TestCheckStatesIntegration(t *testing.T) {
 			{From: "InReview", To: "Rejected", On: "Reject"},
 		}
 
-		err := checkStates(initialState, states, transitions, finalStates)
+		err := checkStates(initialState, states, transitions, finalStates, nil)
 		assert.NoError(t, err)
 	})
 
@@ -430,7 +430,7 @@ func TestCheckStatesIntegration(t *testing.T) {
 			{From: "PathB", To: "End", On: "Finish"},
 		}
 
-		err := checkStates(initialState, states, transitions, finalStates)
+		err := checkStates(initialState, states, transitions, finalStates, nil)
 		assert.NoError(t, err)
 	})
 
@@ -444,7 +444,7 @@ func TestCheckStatesIntegration(t *testing.T) {
 			{From: "Loop", To: "End", On: "Finish"},
 		}
 
-		err := checkStates(initialState, states, transitions, finalStates)
+		err := checkStates(initialState, states, transitions, finalStates, nil)
 		assert.NoError(t, err)
 	})
 
@@ -458,7 +458,7 @@ func TestCheckStatesIntegration(t *testing.T) {
 			// No path to Unreachable
 		}
 
-		err := checkStates(initialState, states, transitions, finalStates)
+		err := checkStates(initialState, states, transitions, finalStates, nil)
 		assert.Error(t, err)
 		// The error can be either about hanging state or unreachable final state
 		// Both are valid detection points for this invalid configuration
@@ -468,13 +468,50 @@ func TestCheckStatesIntegration(t *testing.T) {
 			"Expected hanging state or unreachable final state error, got: %s", err.Error())
 	})
 
+	t.Run("invalid: disjoint cycle", func(t *testing.T) {
+		initialState := gonfa.State("Start")
+		states := []gonfa.State{"Start", "Loop", "End", "Ping", "Pong"}
+		finalStates := []gonfa.State{"End"}
+		transitions := []Transition{
+			{From: "Start", To: "Loop", On: "Begin"},
+			{From: "Loop", To: "Start", On: "Back"},
+			{From: "Loop", To: "End", On: "Finish"},
+			// Ping and Pong only reach each other
+			{From: "Ping", To: "Pong", On: "Hit"},
+			{From: "Pong", To: "Ping", On: "Hit"},
+		}
+
+		err := checkStates(initialState, states, transitions, finalStates, nil)
+		assert.EqualError(t, err,
+			"state 'Ping' is not reachable from initial state")
+	})
+
+	t.Run("invalid: zero weight in weighted group", func(t *testing.T) {
+		states := []gonfa.State{"Start", "A", "B"}
+		transitions := []Transition{
+			{From: "Start", To: "A", On: "Go", Weight: 2},
+			{From: "Start", To: "B", On: "Go"},
+		}
+
+		err := checkStates("Start", states, transitions,
+			[]gonfa.State{"A", "B"}, nil)
+		assert.EqualError(t, err,
+			"transition from 'Start' to 'B' on event 'Go' has no positive "+
+				"weight but competing transitions are weighted")
+
+		// unweighted groups are valid
+		transitions[0].Weight = 0
+		assert.NoError(t, checkStates("Start", states, transitions,
+			[]gonfa.State{"A", "B"}, nil))
+	})
+
 	t.Run("initial state as final state", func(t *testing.T) {
 		initialState := gonfa.State("SingleState")
 		states := []gonfa.State{"SingleState"}
 		finalStates := []gonfa.State{"SingleState"}
 		transitions := []Transition{}
 
-		err := checkStates(initialState, states, transitions, finalStates)
+		err := checkStates(initialState, states, transitions, finalStates, nil)
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "no transitions start from initial state")
 	})
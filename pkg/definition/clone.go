@@ -0,0 +1,66 @@
+package definition
+
+import (
+	"maps"
+	"slices"
+
+	"github.com/dr-dobermann/gonfa/pkg/gonfa"
+)
+
+// Clone returns a deep copy of the definition: its states, transitions,
+// final states, hooks and options don't share slices or maps with d, so a
+// variant can be derived from the clone without aliasing d. Guards and
+// actions themselves are shared: the clone becomes one more owner of them
+// (see gonfa.Initializable), so both definitions should be closed.
+func (d *Definition) Clone() *Definition {
+	c := &Definition{
+		initialState:     d.initialState,
+		finalStates:      slices.Clone(d.finalStates),
+		states:           make(map[gonfa.State]StateConfig, len(d.states)),
+		transitions:      make([]Transition, len(d.transitions)),
+		hooks:            d.hooks.clone(),
+		events:           slices.Clone(d.events),
+		declaredEvents:   slices.Clone(d.declaredEvents),
+		eventsDeclared:   d.eventsDeclared,
+		requireAllEvents: d.requireAllEvents,
+		guardNames:       slices.Clone(d.guardNames),
+		actionNames:      slices.Clone(d.actionNames),
+		contextValues:    maps.Clone(d.contextValues),
+		owned:            retainComponents(d.owned),
+	}
+
+	for s, cfg := range d.states {
+		cfg.OnEntry = slices.Clone(cfg.OnEntry)
+		cfg.OnExit = slices.Clone(cfg.OnExit)
+		cfg.AcceptIf = slices.Clone(cfg.AcceptIf)
+		c.states[s] = cfg
+	}
+
+	for i, t := range d.transitions {
+		c.transitions[i] = t.clone()
+	}
+
+	c.hooks.OnSuccess = slices.Clone(c.hooks.OnSuccess)
+	c.hooks.OnFailure = slices.Clone(c.hooks.OnFailure)
+	for e, aa := range c.hooks.OnEvent {
+		c.hooks.OnEvent[e] = slices.Clone(aa)
+	}
+
+	return c
+}
+
+// clone returns a copy of the transition which doesn't share slices
+// with t.
+func (t Transition) clone() Transition {
+	t.Guards = slices.Clone(t.Guards)
+	t.Actions = slices.Clone(t.Actions)
+	t.Preconditions = slices.Clone(t.Preconditions)
+	t.AfterActions = slices.Clone(t.AfterActions)
+	t.Compensations = slices.Clone(t.Compensations)
+	t.AsyncActions = slices.Clone(t.AsyncActions)
+	t.Tags = slices.Clone(t.Tags)
+	t.GuardNames = slices.Clone(t.GuardNames)
+	t.ActionNames = slices.Clone(t.ActionNames)
+
+	return t
+}
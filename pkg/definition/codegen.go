@@ -0,0 +1,439 @@
+package definition
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"io"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/dr-dobermann/gonfa/pkg/gonfa"
+	"github.com/dr-dobermann/gonfa/pkg/registry"
+)
+
+// generateConfig holds the GenerateGo settings.
+type generateConfig struct {
+	names *registry.Registry
+}
+
+// GenerateOption configures GenerateGo.
+type GenerateOption func(*generateConfig)
+
+// NamesFrom makes GenerateGo name the guards and actions after the names
+// they are registered under in reg.
+func NamesFrom(reg *registry.Registry) GenerateOption {
+	return func(c *generateConfig) {
+		c.names = reg
+	}
+}
+
+// GenerateGo writes gofmt-clean Go source of package pkg reconstructing the
+// definition with the builder API. It helps to move a workflow prototyped
+// in YAML into type-checked Go code.
+//
+// Guards and actions can't be generated, so the source declares Guards and
+// Actions structs with a field per distinct object and a NewDefinition
+// function which takes them:
+//
+//	func NewDefinition(g Guards, a Actions) (*definition.Definition, error)
+//
+// Fields are named after the registry names when NamesFrom is given,
+// otherwise (or for unregistered objects) they are numbered and commented
+// with the object type as a TODO.
+func GenerateGo(
+	d *Definition,
+	pkg string,
+	w io.Writer,
+	opts ...GenerateOption,
+) error {
+	if d == nil {
+		return fmt.Errorf("definition cannot be nil")
+	}
+
+	if !isIdentifier(pkg) {
+		return fmt.Errorf("invalid package name '%s'", pkg)
+	}
+
+	if d.contextValues != nil {
+		return fmt.Errorf("context values can't be generated")
+	}
+
+	var cfg generateConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	g := &goGenerator{
+		guards:  newGoFields("Guard"),
+		actions: newGoFields("Action"),
+		names:   cfg.names,
+	}
+
+	src, err := format.Source(g.generate(d, pkg))
+	if err != nil {
+		return fmt.Errorf("failed to format generated source: %w", err)
+	}
+
+	if _, err := w.Write(src); err != nil {
+		return fmt.Errorf("failed to write generated source: %w", err)
+	}
+
+	return nil
+}
+
+// goGenerator accumulates the NewDefinition body and the fields of the
+// Guards and Actions structs.
+type goGenerator struct {
+	guards  *goFields
+	actions *goFields
+	names   *registry.Registry
+	body    bytes.Buffer
+}
+
+// generate returns the unformatted source.
+func (g *goGenerator) generate(d *Definition, pkg string) []byte {
+	g.call("InitialState", strconv.Quote(string(d.initialState)))
+
+	var finals []string
+	for _, s := range d.finalStates {
+		if len(d.states[s].AcceptIf) == 0 {
+			finals = append(finals, strconv.Quote(string(s)))
+		}
+	}
+	if len(finals) > 0 {
+		g.call("FinalStates", finals...)
+	}
+
+	states := make([]gonfa.State, 0, len(d.states))
+	for s := range d.states {
+		states = append(states, s)
+	}
+	slices.Sort(states)
+
+	for _, s := range states {
+		cfg := d.states[s]
+		state := strconv.Quote(string(s))
+
+		if len(cfg.AcceptIf) > 0 {
+			g.call("FinalStateIf", append([]string{state},
+				g.guardRefs(cfg.AcceptIf)...)...)
+		}
+
+		g.stateActions("OnEntry", state, cfg.OnEntry)
+		g.stateActions("OnExit", state, cfg.OnExit)
+
+		if cfg.ErrorState != "" {
+			g.call("OnActionError", state,
+				strconv.Quote(string(cfg.ErrorState)))
+		}
+	}
+
+	if d.eventsDeclared {
+		events := make([]string, len(d.declaredEvents))
+		for i, e := range d.declaredEvents {
+			events[i] = strconv.Quote(string(e))
+		}
+		g.call("DeclareEvents", events...)
+	}
+	if d.requireAllEvents {
+		g.call("RequireAllEventsReachable")
+	}
+
+	for _, t := range d.transitions {
+		g.transition(t)
+	}
+
+	g.hooks(d.hooks)
+
+	var src bytes.Buffer
+
+	fmt.Fprintf(&src, "package %s\n\n", pkg)
+	src.WriteString("import (\n")
+	src.WriteString("\"github.com/dr-dobermann/gonfa/pkg/builder\"\n")
+	src.WriteString("\"github.com/dr-dobermann/gonfa/pkg/definition\"\n")
+	if len(g.guards.fields)+len(g.actions.fields) > 0 {
+		src.WriteString("\"github.com/dr-dobermann/gonfa/pkg/gonfa\"\n")
+	}
+	src.WriteString(")\n\n")
+
+	g.guards.writeStruct(&src, "Guards", "gonfa.Guard",
+		"Guards lists the guards used by the definition.")
+	g.actions.writeStruct(&src, "Actions", "gonfa.Action",
+		"Actions lists the actions used by the definition.")
+
+	src.WriteString("// NewDefinition builds the state machine definition.\n")
+	src.WriteString("func NewDefinition(g Guards, a Actions) " +
+		"(*definition.Definition, error) {\n")
+	src.WriteString("return builder.New()")
+	src.Write(g.body.Bytes())
+	src.WriteString(".\nBuild()\n}\n")
+
+	return src.Bytes()
+}
+
+// call appends a chained builder call.
+func (g *goGenerator) call(method string, args ...string) {
+	fmt.Fprintf(&g.body, ".\n%s(%s)", method, strings.Join(args, ", "))
+}
+
+// stateActions appends the calls adding state actions keeping their order.
+// Plain actions are grouped, while every GuardedAction gets its own call.
+func (g *goGenerator) stateActions(
+	method string,
+	state string,
+	actions []gonfa.Action,
+) {
+	var plain []gonfa.Action
+
+	flush := func() {
+		if len(plain) > 0 {
+			g.call(method, append([]string{state}, g.actionRefs(plain)...)...)
+			plain = nil
+		}
+	}
+
+	for _, a := range actions {
+		ga, ok := a.(*GuardedAction)
+		if !ok {
+			plain = append(plain, a)
+			continue
+		}
+
+		flush()
+		g.call(method+"If", state, g.guards.ref("g", ga.Guard, g.guardName),
+			g.actions.ref("a", ga.Action, g.actionName))
+	}
+
+	flush()
+}
+
+// transition appends the calls adding the transition.
+func (g *goGenerator) transition(t Transition) {
+	g.call("AddTransition", strconv.Quote(string(t.From)),
+		strconv.Quote(string(t.To)), strconv.Quote(string(t.On)))
+
+	if len(t.Preconditions) > 0 {
+		g.call("WithPreconditions", g.guardRefs(t.Preconditions)...)
+	}
+	if len(t.Guards) > 0 {
+		g.call("WithGuards", g.guardRefs(t.Guards)...)
+	}
+	if len(t.Actions) > 0 {
+		g.call("WithActions", g.actionRefs(t.Actions)...)
+	}
+	if len(t.AfterActions) > 0 {
+		g.call("WithAfterActions", g.actionRefs(t.AfterActions)...)
+	}
+	if len(t.Compensations) > 0 {
+		g.call("WithCompensation", g.actionRefs(t.Compensations)...)
+	}
+	for _, a := range t.AsyncActions {
+		g.call("WithAsyncAction", g.actions.ref("a", a.Action, g.actionName),
+			strconv.Quote(string(a.OnDone)), strconv.Quote(string(a.OnFail)))
+	}
+	if t.Weight != 0 {
+		g.call("WithWeight", strconv.FormatFloat(t.Weight, 'g', -1, 64))
+	}
+	if t.Priority != 0 {
+		g.call("WithPriority", strconv.Itoa(t.Priority))
+	}
+	if len(t.Tags) > 0 {
+		tags := make([]string, len(t.Tags))
+		for i, tag := range t.Tags {
+			tags[i] = strconv.Quote(tag)
+		}
+		g.call("WithTags", tags...)
+	}
+	if t.Label != "" {
+		g.call("WithLabel", strconv.Quote(t.Label))
+	}
+	if t.Internal {
+		g.call("WithInternal")
+	}
+	if t.Disabled {
+		g.call("WithDisabled")
+	}
+}
+
+// hooks appends the calls adding the global hooks.
+func (g *goGenerator) hooks(h Hooks) {
+	if len(h.OnSuccess) > 0 {
+		g.call("WithSuccessHooks", g.actionRefs(h.OnSuccess)...)
+	}
+	if len(h.OnFailure) > 0 {
+		g.call("WithFailureHooks", g.actionRefs(h.OnFailure)...)
+	}
+
+	events := make([]gonfa.Event, 0, len(h.OnEvent))
+	for e := range h.OnEvent {
+		events = append(events, e)
+	}
+	slices.Sort(events)
+
+	for _, e := range events {
+		if aa := h.OnEvent[e]; len(aa) > 0 {
+			g.call("OnEvent", append([]string{strconv.Quote(string(e))},
+				g.actionRefs(aa)...)...)
+		}
+	}
+}
+
+// guardRefs returns the field references of the guards.
+func (g *goGenerator) guardRefs(guards []gonfa.Guard) []string {
+	refs := make([]string, len(guards))
+	for i, guard := range guards {
+		refs[i] = g.guards.ref("g", guard, g.guardName)
+	}
+
+	return refs
+}
+
+// actionRefs returns the field references of the actions.
+func (g *goGenerator) actionRefs(actions []gonfa.Action) []string {
+	refs := make([]string, len(actions))
+	for i, action := range actions {
+		refs[i] = g.actions.ref("a", action, g.actionName)
+	}
+
+	return refs
+}
+
+// guardName returns the registry name of the guard.
+func (g *goGenerator) guardName(obj any) (string, bool) {
+	if g.names == nil {
+		return "", false
+	}
+
+	guard, _ := obj.(gonfa.Guard)
+	return g.names.NameOf(guard)
+}
+
+// actionName returns the registry name of the action.
+func (g *goGenerator) actionName(obj any) (string, bool) {
+	if g.names == nil {
+		return "", false
+	}
+
+	action, _ := obj.(gonfa.Action)
+	return g.names.NameOfAction(action)
+}
+
+// goField is a field of a generated struct.
+type goField struct {
+	name    string
+	comment string
+}
+
+// goFields assigns unique field names to objects.
+type goFields struct {
+	prefix string
+	fields []goField
+	byObj  map[any]string
+	used   map[string]struct{}
+}
+
+// newGoFields creates goFields numbering unnamed objects with prefix.
+func newGoFields(prefix string) *goFields {
+	return &goFields{
+		prefix: prefix,
+		byObj:  make(map[any]string),
+		used:   make(map[string]struct{}),
+	}
+}
+
+// ref returns the reference to the object's field through the variable v.
+// A new field is added for an object seen for the first time.
+func (f *goFields) ref(
+	v string,
+	obj any,
+	nameOf func(any) (string, bool),
+) string {
+	comparable := obj != nil && reflect.TypeOf(obj).Comparable()
+	if comparable {
+		if name, ok := f.byObj[obj]; ok {
+			return v + "." + name
+		}
+	}
+
+	regName, named := nameOf(obj)
+
+	field := goField{name: exportedName(regName)}
+	if !named || field.name == "" {
+		field.name = fmt.Sprintf("%s%d", f.prefix, len(f.fields)+1)
+		field.comment = fmt.Sprintf("TODO: %T", obj)
+	} else {
+		field.comment = strconv.Quote(regName)
+	}
+
+	base := field.name
+	for i := 2; ; i++ {
+		if _, ok := f.used[field.name]; !ok {
+			break
+		}
+		field.name = fmt.Sprintf("%s%d", base, i)
+	}
+
+	f.used[field.name] = struct{}{}
+	f.fields = append(f.fields, field)
+	if comparable {
+		f.byObj[obj] = field.name
+	}
+
+	return v + "." + field.name
+}
+
+// writeStruct writes the struct declaration with all fields of type typ.
+func (f *goFields) writeStruct(w io.Writer, name, typ, doc string) {
+	fmt.Fprintf(w, "// %s\ntype %s struct {\n", doc, name)
+	for _, field := range f.fields {
+		fmt.Fprintf(w, "%s %s // %s\n", field.name, typ, field.comment)
+	}
+	fmt.Fprint(w, "}\n\n")
+}
+
+// exportedName converts a registry name like "billing:chargeCard" into an
+// exported Go identifier like "BillingChargeCard". Returns an empty string
+// if the name has no letters or digits.
+func exportedName(name string) string {
+	var sb strings.Builder
+
+	upper := true
+	for _, r := range name {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			upper = true
+			continue
+		}
+
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		sb.WriteRune(r)
+	}
+
+	id := sb.String()
+	if id != "" && !unicode.IsUpper([]rune(id)[0]) {
+		id = "X" + id
+	}
+
+	return id
+}
+
+// isIdentifier checks if s is a valid Go identifier.
+func isIdentifier(s string) bool {
+	if s == "" {
+		return false
+	}
+
+	for i, r := range s {
+		if !unicode.IsLetter(r) && r != '_' && (i == 0 || !unicode.IsDigit(r)) {
+			return false
+		}
+	}
+
+	return true
+}
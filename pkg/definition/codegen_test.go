@@ -0,0 +1,128 @@
+package definition
+
+import (
+	"bytes"
+	"go/format"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dr-dobermann/gonfa/pkg/gonfa"
+	"github.com/dr-dobermann/gonfa/pkg/registry"
+)
+
+func TestGenerateGo(t *testing.T) {
+	isManager := &testGuard{result: true}
+	unnamed := &testGuard{result: false}
+	notify := &testAction{name: "notify"}
+	charge := &testAction{name: "charge"}
+
+	reg := registry.New()
+	require.NoError(t, reg.RegisterGuard("isManager", isManager))
+	require.NoError(t, reg.RegisterAction("notify", notify))
+	require.NoError(t, reg.RegisterAction("billing:chargeCard", charge))
+
+	states := map[gonfa.State]StateConfig{
+		"Draft": {},
+		"Review": {OnEntry: []gonfa.Action{
+			notify,
+			&GuardedAction{Guard: isManager, Action: charge},
+		}, ErrorState: "Rejected"},
+		"Approved": {AcceptIf: []gonfa.Guard{unnamed}},
+		"Rejected": {},
+	}
+	transitions := []Transition{
+		{From: "Draft", To: "Review", On: "Submit", Tags: []string{"audited"}},
+		{From: "Review", To: "Approved", On: "Approve",
+			Guards: []gonfa.Guard{isManager}, Actions: []gonfa.Action{charge},
+			Weight: 0.5, Label: "Approved by manager"},
+		{From: "Review", To: "Rejected", On: "Reject",
+			Guards: []gonfa.Guard{unnamed}, Disabled: true},
+	}
+	hooks := Hooks{
+		OnSuccess: []gonfa.Action{notify},
+		OnEvent:   map[gonfa.Event][]gonfa.Action{"Reject": {notify}},
+	}
+
+	def, err := New("Draft", []gonfa.State{"Approved", "Rejected"},
+		states, transitions, hooks,
+		DeclareEvents("Submit", "Approve", "Reject"),
+		RequireAllEventsReachable())
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, GenerateGo(def, "workflow", &buf, NamesFrom(reg)))
+
+	expected := `package workflow
+
+import (
+	"github.com/dr-dobermann/gonfa/pkg/builder"
+	"github.com/dr-dobermann/gonfa/pkg/definition"
+	"github.com/dr-dobermann/gonfa/pkg/gonfa"
+)
+
+// Guards lists the guards used by the definition.
+type Guards struct {
+	Guard1    gonfa.Guard // TODO: *definition.testGuard
+	IsManager gonfa.Guard // "isManager"
+}
+
+// Actions lists the actions used by the definition.
+type Actions struct {
+	Notify            gonfa.Action // "notify"
+	BillingChargeCard gonfa.Action // "billing:chargeCard"
+}
+
+// NewDefinition builds the state machine definition.
+func NewDefinition(g Guards, a Actions) (*definition.Definition, error) {
+	return builder.New().
+		InitialState("Draft").
+		FinalStates("Rejected").
+		FinalStateIf("Approved", g.Guard1).
+		OnEntry("Review", a.Notify).
+		OnEntryIf("Review", g.IsManager, a.BillingChargeCard).
+		OnActionError("Review", "Rejected").
+		DeclareEvents("Approve", "Reject", "Submit").
+		RequireAllEventsReachable().
+		AddTransition("Draft", "Review", "Submit").
+		WithTags("audited").
+		AddTransition("Review", "Approved", "Approve").
+		WithGuards(g.IsManager).
+		WithActions(a.BillingChargeCard).
+		WithWeight(0.5).
+		WithLabel("Approved by manager").
+		AddTransition("Review", "Rejected", "Reject").
+		WithGuards(g.Guard1).
+		WithDisabled().
+		WithSuccessHooks(a.Notify).
+		OnEvent("Reject", a.Notify).
+		Build()
+}
+`
+	assert.Equal(t, expected, buf.String())
+
+	formatted, err := format.Source(buf.Bytes())
+	require.NoError(t, err)
+	assert.Equal(t, buf.String(), string(formatted))
+
+	t.Run("without registry", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, GenerateGo(def, "workflow", &buf))
+		assert.Contains(t, buf.String(), "Guard2 gonfa.Guard // TODO")
+		assert.Contains(t, buf.String(), "WithActions(a.Action2)")
+	})
+
+	t.Run("invalid package", func(t *testing.T) {
+		assert.Error(t, GenerateGo(def, "my-pkg", &bytes.Buffer{}))
+		assert.Error(t, GenerateGo(nil, "workflow", &bytes.Buffer{}))
+	})
+}
+
+func TestExportedName(t *testing.T) {
+	assert.Equal(t, "IsManager", exportedName("isManager"))
+	assert.Equal(t, "BillingChargeCard", exportedName("billing:chargeCard"))
+	assert.Equal(t, "SendEMail", exportedName("send-e_mail"))
+	assert.Equal(t, "X1st", exportedName("1st"))
+	assert.Empty(t, exportedName("::"))
+}
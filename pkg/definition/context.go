@@ -0,0 +1,27 @@
+package definition
+
+import "maps"
+
+// WithContextValues sets machine-wide default values (e.g. a tenant ID or
+// feature flags) which machines add to the context passed to every guard,
+// action and hook. A value set in the caller's context under the same key
+// wins over the default. Several calls add up, later values replace
+// earlier ones under the same key.
+func WithContextValues(values map[any]any) Option {
+	return func(d *Definition) {
+		if len(values) == 0 {
+			return
+		}
+
+		if d.contextValues == nil {
+			d.contextValues = make(map[any]any, len(values))
+		}
+		maps.Copy(d.contextValues, values)
+	}
+}
+
+// ContextValues returns a copy of the default context values set with
+// WithContextValues or nil if there are none.
+func (d *Definition) ContextValues() map[any]any {
+	return maps.Clone(d.contextValues)
+}
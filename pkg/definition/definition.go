@@ -13,8 +13,11 @@
 package definition
 
 import (
+	"cmp"
+	"context"
 	"fmt"
 	"slices"
+	"sync"
 
 	"github.com/dr-dobermann/gonfa/pkg/gonfa"
 )
@@ -26,18 +29,185 @@ type Transition struct {
 	On      gonfa.Event    // Triggering event
 	Guards  []gonfa.Guard  // Chain of guards that must all pass
 	Actions []gonfa.Action // Chain of actions to execute during transition
+	Weight  float64        // Relative weight used by weighted selection
+
+	// Priority orders competing transitions (same From and On): the ones
+	// with higher priority are attempted first, equal priorities keep the
+	// declaration order.
+	Priority int
+
+	// Preconditions are structural invariants checked before Guards.
+	// Unlike a failed guard, a failed precondition is an error.
+	Preconditions []gonfa.Guard
+
+	// AfterActions are executed after the transition is committed.
+	// Their errors are reported but never roll the transition back.
+	AfterActions []gonfa.Action
+
+	// Compensations undo the side effects of the committed transition when
+	// the machine saga it belongs to is aborted (see Machine.BeginSaga).
+	Compensations []gonfa.Action
+
+	// AsyncActions are started in the background once the transition is
+	// committed. The target state is a pending state waiting for their
+	// completion events.
+	AsyncActions []AsyncAction
+
+	// Tags are arbitrary labels (e.g. "requires-approval") used by external
+	// tools to query transitions. They don't affect runtime behavior.
+	Tags []string
+
+	// Name identifies the transition in diagnostics. LoadDefinition sets it
+	// to "From->To on On"; definitions built in code may leave it empty.
+	Name string
+
+	// GuardNames and ActionNames are the names Guards and Actions were
+	// resolved from, index by index. They are set by LoadDefinition and
+	// used in diagnostics; definitions built in code may leave them empty.
+	GuardNames  []string
+	ActionNames []string
+
+	// Label is a human-readable description of the transition (e.g.
+	// "Approved by manager") recorded in the machine history along with
+	// the raw event. It doesn't affect runtime behavior.
+	Label string
+
+	// Internal self-transitions (From == To) run their actions without
+	// leaving the state, so its OnExit and OnEntry actions are skipped.
+	// The transition is still recorded in the history. It has no effect on
+	// transitions between different states.
+	Internal bool
+
+	// Disabled transitions are skipped by machines as if they weren't
+	// declared, until enabled with Machine.SetTransitionEnabled. The zero
+	// value keeps the transition enabled.
+	Disabled bool
+
+	// trivial is set by New for transitions with nothing to check or
+	// execute besides the state change.
+	trivial bool
+
+	// index is set by New to the transition's position among the
+	// transitions with the same source state and event, as returned by
+	// GetTransitions.
+	index int
+}
+
+// AsyncAction is a long-running action of a transition (e.g. a call of an
+// external service) executed in its own goroutine. When it completes, the
+// machine fires OnDone if it succeeded or OnFail if it failed, so the
+// target state of the transition must have transitions on both events.
+type AsyncAction struct {
+	Action gonfa.Action
+	OnDone gonfa.Event
+	OnFail gonfa.Event
+}
+
+// Trivial checks if the transition has no preconditions, guards or
+// actions, and it's internal (see IsInternal) or its source state has no
+// OnExit and its target state has no OnEntry actions, so firing it is just
+// a state change. It's computed by New, so it's always false for
+// transitions which don't come from a Definition.
+func (t Transition) Trivial() bool {
+	return t.trivial
+}
+
+// IsInternal checks if the transition is an internal self-transition, i.e.
+// it's marked Internal and its source and target states are the same.
+func (t Transition) IsInternal() bool {
+	return t.Internal && t.From == t.To
+}
+
+// Index returns the position of the transition in the list returned by
+// Definition.GetTransitions for its source state and event. It's computed
+// by New, so it's always 0 for transitions which don't come from a
+// Definition.
+func (t Transition) Index() int {
+	return t.index
 }
 
 // StateConfig describes actions associated with a specific state.
 type StateConfig struct {
 	OnEntry []gonfa.Action // Actions to execute upon entering the state
 	OnExit  []gonfa.Action // Actions to execute upon exiting the state
+
+	// AcceptIf makes a final state conditional: the machine is in an
+	// accepting state only if all these guards pass. Ignored for non-final
+	// states.
+	AcceptIf []gonfa.Guard
+
+	// ErrorState catches failures of the actions of transitions leaving the
+	// state: if an OnExit or transition action fails, the machine moves to
+	// ErrorState instead of staying in the state. Empty means no catching.
+	ErrorState gonfa.State
+}
+
+// GuardedAction is an action which runs only if its guard passes.
+// It's used in StateConfig for conditional OnEntry/OnExit actions: the guard
+// is evaluated right before the action each time the state is entered or
+// exited, and if it fails the action is silently skipped.
+// The guard should be free of side effects since it's evaluated in the
+// middle of a transition which can still fail.
+type GuardedAction struct {
+	Guard  gonfa.Guard
+	Action gonfa.Action
+}
+
+// Execute runs the action if the guard passes.
+func (ga *GuardedAction) Execute(
+	ctx context.Context,
+	state gonfa.MachineState,
+	payload gonfa.Payload,
+) error {
+	if ga.Guard != nil && !ga.Guard.Check(ctx, state, payload) {
+		return nil
+	}
+
+	return ga.Action.Execute(ctx, state, payload)
 }
 
 // Hooks describes a set of global hooks for the state machine.
 type Hooks struct {
 	OnSuccess []gonfa.Action // Called after successful transitions
 	OnFailure []gonfa.Action // Called after failed transitions
+
+	// OnEvent actions are called after any transition triggered by the
+	// event is committed, regardless of its source and target states.
+	OnEvent map[gonfa.Event][]gonfa.Action
+}
+
+// clone returns a copy of the hooks with its own OnEvent map.
+func (h Hooks) clone() Hooks {
+	if h.OnEvent != nil {
+		onEvent := make(map[gonfa.Event][]gonfa.Action, len(h.OnEvent))
+		for e, aa := range h.OnEvent {
+			onEvent[e] = aa
+		}
+		h.OnEvent = onEvent
+	}
+
+	return h
+}
+
+// MergeHooks combines several sets of hooks (e.g. a base instrumentation
+// layer and definition-specific hooks) into one. The actions of every kind
+// are concatenated in the order of hs, and the event actions are
+// concatenated per event. The result doesn't share slices or maps with hs.
+func MergeHooks(hs ...Hooks) Hooks {
+	var merged Hooks
+	for _, h := range hs {
+		merged.OnSuccess = append(merged.OnSuccess, h.OnSuccess...)
+		merged.OnFailure = append(merged.OnFailure, h.OnFailure...)
+
+		for e, aa := range h.OnEvent {
+			if merged.OnEvent == nil {
+				merged.OnEvent = make(map[gonfa.Event][]gonfa.Action)
+			}
+			merged.OnEvent[e] = append(merged.OnEvent[e], aa...)
+		}
+	}
+
+	return merged
 }
 
 // Definition is an immutable description of the state machine graph.
@@ -48,32 +218,65 @@ type Definition struct {
 	states       map[gonfa.State]StateConfig
 	transitions  []Transition
 	hooks        Hooks
+	events       []gonfa.Event
+
+	// declared event vocabulary, see DeclareEvents
+	declaredEvents   []gonfa.Event
+	eventsDeclared   bool
+	requireAllEvents bool
+
+	// registry names the definition was loaded with, see RequiredNames
+	guardNames  []string
+	actionNames []string
+
+	// default context values, see WithContextValues
+	contextValues map[any]any
+
+	// guards and actions the definition owns, see initComponents
+	owned     []any
+	closeOnce sync.Once
+	closeErr  error
 }
 
 // New creates a new Definition with the given parameters.
+//
+// After the definition passes validation, Init is called on every guard and
+// action implementing gonfa.Initializable (see Close for the order) which
+// isn't already owned by another open definition. If any Init fails, the
+// objects acquired so far are released in reverse order and New returns
+// the error.
+//
+// Options enable optional checks like RequireAllEventsReachable.
 func New(
 	initialState gonfa.State,
 	finalStates []gonfa.State,
 	states map[gonfa.State]StateConfig,
 	transitions []Transition,
 	hooks Hooks,
+	opts ...Option,
 ) (*Definition, error) {
 	if initialState == "" {
 		return nil, fmt.Errorf("initial state cannot be empty")
 	}
 
 	ss := make([]gonfa.State, len(states))
+	errorStates := make(map[gonfa.State]gonfa.State)
 	i := 0
-	for s := range states {
+	for s, cfg := range states {
 		ss[i] = s
 		i = i + 1
+
+		if cfg.ErrorState != "" {
+			errorStates[s] = cfg.ErrorState
+		}
 	}
 
 	if err := checkStates(
 		initialState,
 		ss,
 		transitions,
-		finalStates); err != nil {
+		finalStates,
+		errorStates); err != nil {
 		return nil, fmt.Errorf("states check failed: %w", err)
 	}
 
@@ -92,14 +295,65 @@ func New(
 	// Copy transitions slice
 	transitionsCopy := make([]Transition, len(transitions))
 	copy(transitionsCopy, transitions)
+	competing := make(map[transitionKey][]int)
+	for i, t := range transitionsCopy {
+		key := transitionKey{from: t.From, on: t.On}
+		competing[key] = append(competing[key], i)
+	}
+	for _, group := range competing {
+		slices.SortStableFunc(group, func(a, b int) int {
+			return byPriority(transitionsCopy[a], transitionsCopy[b])
+		})
+		for pos, i := range group {
+			transitionsCopy[i].index = pos
+		}
+	}
+
+	for i := range transitionsCopy {
+		t := &transitionsCopy[i]
+		t.Tags = slices.Clone(t.Tags)
+		t.GuardNames = slices.Clone(t.GuardNames)
+		t.ActionNames = slices.Clone(t.ActionNames)
+		t.trivial = len(t.Preconditions) == 0 && len(t.Guards) == 0 &&
+			len(t.Actions) == 0 &&
+			(t.IsInternal() || len(statesCopy[t.From].OnExit) == 0 &&
+				len(statesCopy[t.To].OnEntry) == 0)
+	}
+
+	// Collect the sorted events vocabulary
+	var events []gonfa.Event
+	for _, t := range transitionsCopy {
+		if !slices.Contains(events, t.On) {
+			events = append(events, t.On)
+		}
+	}
+	slices.Sort(events)
 
-	return &Definition{
+	d := &Definition{
 		initialState: initialState,
 		finalStates:  finalStatesCopy,
 		states:       statesCopy,
 		transitions:  transitionsCopy,
-		hooks:        hooks,
-	}, nil
+		hooks:        hooks.clone(),
+		events:       events,
+	}
+
+	for _, opt := range opts {
+		if opt != nil {
+			opt(d)
+		}
+	}
+	slices.Sort(d.declaredEvents)
+
+	if err := d.checkDeclaredEvents(); err != nil {
+		return nil, err
+	}
+
+	if err := d.initComponents(); err != nil {
+		return nil, fmt.Errorf("components initialization failed: %w", err)
+	}
+
+	return d, nil
 }
 
 // InitialState returns the initial state of the machine.
@@ -139,16 +393,79 @@ func (d *Definition) Transitions() []Transition {
 		panic("failed to copy transitions list")
 	}
 
+	for i := range transitions {
+		transitions[i].Tags = slices.Clone(transitions[i].Tags)
+		transitions[i].GuardNames = slices.Clone(transitions[i].GuardNames)
+		transitions[i].ActionNames = slices.Clone(transitions[i].ActionNames)
+	}
+
 	return transitions
 }
 
+// SortedTransitions returns a copy of all transitions sorted by
+// (From, On, To). Since a definition can't have duplicate transitions, the
+// order is canonical and suits diffing and hashing. Use Transitions when
+// the declaration order matters (e.g. for first-match selection).
+func (d *Definition) SortedTransitions() []Transition {
+	return sortTransitions(d.Transitions())
+}
+
+// TransitionsWithTag returns copies of all transitions labeled with the tag
+// in declaration order.
+func (d *Definition) TransitionsWithTag(tag string) []Transition {
+	var result []Transition
+	for _, t := range d.transitions {
+		if slices.Contains(t.Tags, tag) {
+			t.Tags = slices.Clone(t.Tags)
+			result = append(result, t)
+		}
+	}
+
+	return result
+}
+
 // Hooks returns the global hooks configuration.
 func (d *Definition) Hooks() Hooks {
-	return d.hooks
+	return d.hooks.clone()
+}
+
+// EventActions returns the actions to be run after any transition
+// triggered by the event.
+func (d *Definition) EventActions(event gonfa.Event) []gonfa.Action {
+	return d.hooks.OnEvent[event]
+}
+
+// AllEvents returns the sorted set of all events used in transitions,
+// i.e. the event vocabulary of the machine.
+func (d *Definition) AllEvents() []gonfa.Event {
+	return slices.Clone(d.events)
+}
+
+// NextEvents returns the sorted set of events of the transitions leaving the
+// state, i.e. the events available once a machine is in it (e.g. for
+// workflow previews). It's structural only: guards, preconditions and the
+// Disabled flags aren't taken into account.
+func (d *Definition) NextEvents(after gonfa.State) []gonfa.Event {
+	var events []gonfa.Event
+	for _, t := range d.transitions {
+		if t.From == after && !slices.Contains(events, t.On) {
+			events = append(events, t.On)
+		}
+	}
+	slices.Sort(events)
+
+	return events
+}
+
+// HasEvent checks if the event is used in any transition.
+func (d *Definition) HasEvent(event gonfa.Event) bool {
+	_, found := slices.BinarySearch(d.events, event)
+	return found
 }
 
 // GetTransitions returns all transitions that can be triggered from the given
-// state with the given event.
+// state with the given event, ordered by descending Priority. Transitions
+// with equal priorities keep the declaration order.
 func (d *Definition) GetTransitions(
 	from gonfa.State,
 	event gonfa.Event,
@@ -159,10 +476,16 @@ func (d *Definition) GetTransitions(
 			result = append(result, t)
 		}
 	}
+	slices.SortStableFunc(result, byPriority)
 
 	return result
 }
 
+// byPriority compares transitions by descending priority.
+func byPriority(a, b Transition) int {
+	return cmp.Compare(b.Priority, a.Priority)
+}
+
 // GetStateConfig returns the configuration for the given state.
 // Returns an empty StateConfig if the state is not configured.
 func (d *Definition) GetStateConfig(state gonfa.State) StateConfig {
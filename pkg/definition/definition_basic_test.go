@@ -1,6 +1,7 @@
 package definition
 
 import (
+	"fmt"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -114,6 +115,66 @@ func TestNewDefinition(t *testing.T) {
 		assert.Contains(t, err.Error(), "states check failed")
 		assert.Contains(t, err.Error(), "state 'NonExistent' doesn't exist as transition target")
 	})
+
+	t.Run("async actions", func(t *testing.T) {
+		states := map[gonfa.State]StateConfig{
+			"Start": {}, "Pending": {}, "End": {},
+		}
+		transitions := func(a AsyncAction) []Transition {
+			return []Transition{
+				{From: "Start", To: "Pending", On: "Go",
+					AsyncActions: []AsyncAction{a}},
+				{From: "Pending", To: "End", On: "Done"},
+				{From: "Pending", To: "End", On: "Fail"},
+			}
+		}
+
+		action := &testAction{name: "call"}
+		def, err := New("Start", []gonfa.State{"End"}, states,
+			transitions(AsyncAction{Action: action, OnDone: "Done", OnFail: "Fail"}),
+			Hooks{})
+		require.NoError(t, err)
+		assert.Contains(t, def.components(), any(action))
+
+		_, err = New("Start", []gonfa.State{"End"}, states,
+			transitions(AsyncAction{OnDone: "Done", OnFail: "Fail"}), Hooks{})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "nil async action")
+
+		_, err = New("Start", []gonfa.State{"End"}, states,
+			transitions(AsyncAction{Action: action, OnDone: "Done", OnFail: "Oops"}),
+			Hooks{})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(),
+			"pending state 'Pending' has no transition on "+
+				"async action completion event 'Oops'")
+	})
+
+	t.Run("error state", func(t *testing.T) {
+		transitions := []Transition{
+			{From: "Start", To: "End", On: "Event1"},
+		}
+
+		// Failed is reachable only through the error state of Start
+		def, err := New("Start", []gonfa.State{"End", "Failed"},
+			map[gonfa.State]StateConfig{
+				"Start":  {ErrorState: "Failed"},
+				"End":    {},
+				"Failed": {},
+			}, transitions, Hooks{})
+		require.NoError(t, err)
+		assert.Equal(t, gonfa.State("Failed"),
+			def.GetStateConfig("Start").ErrorState)
+
+		_, err = New("Start", []gonfa.State{"End"},
+			map[gonfa.State]StateConfig{
+				"Start": {ErrorState: "Missing"},
+				"End":   {},
+			}, transitions, Hooks{})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(),
+			"error state 'Missing' of state 'Start' doesn't exist in states")
+	})
 }
 
 func TestDefinitionGetters(t *testing.T) {
@@ -208,6 +269,92 @@ func TestGetTransitions(t *testing.T) {
 	})
 }
 
+func TestDeclareEvents(t *testing.T) {
+	states := map[gonfa.State]StateConfig{"Start": {}, "End": {}}
+	transitions := []Transition{{From: "Start", To: "End", On: "Finish"}}
+
+	t.Run("declared events", func(t *testing.T) {
+		def, err := New("Start", []gonfa.State{"End"}, states, transitions,
+			Hooks{}, DeclareEvents("Finish", "Cancel"), DeclareEvents("Finish"))
+		require.NoError(t, err)
+
+		events, ok := def.DeclaredEvents()
+		assert.True(t, ok)
+		assert.Equal(t, []gonfa.Event{"Cancel", "Finish"}, events)
+		assert.Equal(t, []gonfa.Event{"Finish"}, def.AllEvents())
+	})
+
+	t.Run("not declared", func(t *testing.T) {
+		def, err := New("Start", []gonfa.State{"End"}, states, transitions,
+			Hooks{}, RequireAllEventsReachable())
+		require.NoError(t, err)
+
+		events, ok := def.DeclaredEvents()
+		assert.False(t, ok)
+		assert.Empty(t, events)
+	})
+
+	t.Run("unused declared event", func(t *testing.T) {
+		_, err := New("Start", []gonfa.State{"End"}, states, transitions,
+			Hooks{}, DeclareEvents("Finish", "Cancel"),
+			RequireAllEventsReachable())
+		require.Error(t, err)
+		assert.Contains(t, err.Error(),
+			"declared event 'Cancel' isn't used in any transition")
+	})
+
+	t.Run("all declared events are used", func(t *testing.T) {
+		_, err := New("Start", []gonfa.State{"End"}, states, transitions,
+			Hooks{}, DeclareEvents("Finish"), RequireAllEventsReachable())
+		require.NoError(t, err)
+	})
+}
+
+func TestTransitionTrivial(t *testing.T) {
+	action := &testAction{name: "action"}
+	guard := &testGuard{result: true}
+
+	states := map[gonfa.State]StateConfig{
+		"Start":  {},
+		"Middle": {OnEntry: []gonfa.Action{action}},
+		"Side":   {OnExit: []gonfa.Action{action}},
+		"End":    {},
+	}
+	transitions := []Transition{
+		{From: "Start", To: "End", On: "Plain"},
+		{From: "Start", To: "End", On: "Guarded", Guards: []gonfa.Guard{guard}},
+		{From: "Start", To: "End", On: "Checked",
+			Preconditions: []gonfa.Guard{guard}},
+		{From: "Start", To: "End", On: "Acting", Actions: []gonfa.Action{action}},
+		{From: "Start", To: "Middle", On: "Enter"},
+		{From: "Start", To: "Side", On: "Side"},
+		{From: "Side", To: "End", On: "Exit"},
+		{From: "Middle", To: "End", On: "Plain",
+			AfterActions: []gonfa.Action{action}},
+	}
+
+	def, err := New("Start", []gonfa.State{"End"}, states, transitions, Hooks{})
+	require.NoError(t, err)
+
+	trivial := make(map[string]bool)
+	for _, tr := range def.Transitions() {
+		trivial[fmt.Sprintf("%s-%s", tr.From, tr.On)] = tr.Trivial()
+	}
+
+	assert.Equal(t, map[string]bool{
+		"Start-Plain":   true,
+		"Start-Guarded": false,
+		"Start-Checked": false,
+		"Start-Acting":  false,
+		"Start-Enter":   false,
+		"Start-Side":    true,
+		"Side-Exit":     false,
+		"Middle-Plain":  true,
+	}, trivial)
+
+	assert.False(t, transitions[0].Trivial(), "source transitions are changed")
+}
+
 func TestGetStateConfig(t *testing.T) {
 	states := map[gonfa.State]StateConfig{
 		"Start": {
@@ -236,3 +383,200 @@ func TestGetStateConfig(t *testing.T) {
 		assert.Empty(t, config.OnExit)
 	})
 }
+
+func TestAllEvents(t *testing.T) {
+	states := map[gonfa.State]StateConfig{
+		"Start": {}, "Middle": {}, "End": {},
+	}
+	transitions := []Transition{
+		{From: "Start", To: "Middle", On: "Submit"},
+		{From: "Middle", To: "Start", On: "Reject"},
+		{From: "Middle", To: "End", On: "Approve"},
+		{From: "Start", To: "End", On: "Approve"},
+	}
+
+	def, err := New("Start", []gonfa.State{"End"}, states, transitions, Hooks{})
+	require.NoError(t, err)
+
+	events := def.AllEvents()
+	assert.Equal(t, []gonfa.Event{"Approve", "Reject", "Submit"}, events)
+
+	// returned slice is a copy
+	events[0] = "Changed"
+	assert.Equal(t, gonfa.Event("Approve"), def.AllEvents()[0])
+	assert.True(t, def.HasEvent("Reject"))
+	assert.False(t, def.HasEvent("Changed"))
+}
+
+func TestNextEvents(t *testing.T) {
+	states := map[gonfa.State]StateConfig{
+		"Review": {}, "Approved": {}, "Archived": {}, "Draft": {},
+	}
+	transitions := []Transition{
+		{From: "Draft", To: "Review", On: "Submit"},
+		{From: "Review", To: "Approved", On: "Approve"},
+		{From: "Review", To: "Draft", On: "Revise"},
+		{From: "Review", To: "Archived", On: "Approve",
+			Guards: []gonfa.Guard{&testGuard{result: false}}},
+		{From: "Approved", To: "Archived", On: "Archive"},
+		{From: "Approved", To: "Draft", On: "Revise", Disabled: true},
+	}
+
+	def, err := New("Draft", []gonfa.State{"Archived"}, states, transitions,
+		Hooks{})
+	require.NoError(t, err)
+
+	assert.Equal(t, []gonfa.Event{"Approve", "Revise"}, def.NextEvents("Review"))
+	assert.Equal(t, []gonfa.Event{"Archive", "Revise"},
+		def.NextEvents("Approved"))
+	assert.Empty(t, def.NextEvents("Archived"))
+	assert.Empty(t, def.NextEvents("Unknown"))
+}
+
+func TestTransitionsWithTag(t *testing.T) {
+	states := map[gonfa.State]StateConfig{
+		"Start": {}, "Middle": {}, "End": {},
+	}
+	tags := []string{"automated"}
+	transitions := []Transition{
+		{From: "Start", To: "Middle", On: "Submit", Tags: tags},
+		{From: "Middle", To: "End", On: "Approve",
+			Tags: []string{"requires-approval", "automated"}},
+		{From: "Middle", To: "Start", On: "Reject"},
+	}
+
+	def, err := New("Start", []gonfa.State{"End"}, states, transitions, Hooks{})
+	require.NoError(t, err)
+
+	automated := def.TransitionsWithTag("automated")
+	require.Len(t, automated, 2)
+	assert.Equal(t, gonfa.Event("Submit"), automated[0].On)
+	assert.Equal(t, gonfa.Event("Approve"), automated[1].On)
+
+	approval := def.TransitionsWithTag("requires-approval")
+	require.Len(t, approval, 1)
+	assert.Equal(t, gonfa.State("End"), approval[0].To)
+
+	assert.Empty(t, def.TransitionsWithTag("unknown"))
+
+	// tags are deep copied
+	tags[0] = "changed"
+	automated[1].Tags[0] = "changed"
+	def.Transitions()[0].Tags[0] = "changed"
+	assert.Len(t, def.TransitionsWithTag("automated"), 2)
+	assert.Empty(t, def.TransitionsWithTag("changed"))
+}
+
+func TestSortedTransitions(t *testing.T) {
+	states := map[gonfa.State]StateConfig{
+		"Draft": {}, "Review": {}, "Approved": {}, "Rejected": {},
+	}
+	transitions := []Transition{
+		{From: "Review", To: "Rejected", On: "Reject"},
+		{From: "Review", To: "Draft", On: "Approve"},
+		{From: "Review", To: "Approved", On: "Approve"},
+		{From: "Draft", To: "Review", On: "Submit"},
+	}
+
+	def, err := New("Draft", []gonfa.State{"Approved", "Rejected"},
+		states, transitions, Hooks{})
+	require.NoError(t, err)
+
+	var got []string
+	for _, tr := range def.SortedTransitions() {
+		got = append(got, fmt.Sprintf("%s-%s-%s", tr.From, tr.On, tr.To))
+	}
+	assert.Equal(t, []string{
+		"Draft-Submit-Review",
+		"Review-Approve-Approved",
+		"Review-Approve-Draft",
+		"Review-Reject-Rejected",
+	}, got)
+
+	// declaration order is kept by Transitions
+	assert.Equal(t, gonfa.State("Rejected"), def.Transitions()[0].To)
+}
+
+func TestMergeHooks(t *testing.T) {
+	base := &testAction{name: "base"}
+	audit := &testAction{name: "audit"}
+	notify := &testAction{name: "notify"}
+	alert := &testAction{name: "alert"}
+
+	global := Hooks{
+		OnSuccess: []gonfa.Action{base},
+		OnFailure: []gonfa.Action{base},
+		OnEvent:   map[gonfa.Event][]gonfa.Action{"Submit": {audit}},
+	}
+	local := Hooks{
+		OnSuccess: []gonfa.Action{notify},
+		OnEvent: map[gonfa.Event][]gonfa.Action{
+			"Submit":  {notify},
+			"Approve": {alert},
+		},
+	}
+
+	merged := MergeHooks(global, local, Hooks{OnFailure: []gonfa.Action{alert}})
+
+	assert.Equal(t, []gonfa.Action{base, notify}, merged.OnSuccess)
+	assert.Equal(t, []gonfa.Action{base, alert}, merged.OnFailure)
+	assert.Equal(t, map[gonfa.Event][]gonfa.Action{
+		"Submit":  {audit, notify},
+		"Approve": {alert},
+	}, merged.OnEvent)
+
+	// The sources aren't modified
+	assert.Equal(t, []gonfa.Action{base}, global.OnSuccess)
+	assert.Equal(t, []gonfa.Action{audit}, global.OnEvent["Submit"])
+
+	assert.Equal(t, Hooks{}, MergeHooks())
+}
+
+func TestClone(t *testing.T) {
+	guard := &testGuard{result: true}
+	action := &testAction{name: "action"}
+	other := &testAction{name: "other"}
+
+	def, err := New("A", []gonfa.State{"B"},
+		map[gonfa.State]StateConfig{
+			"A": {OnExit: []gonfa.Action{action}},
+			"B": {},
+		},
+		[]Transition{{From: "A", To: "B", On: "Go",
+			Guards: []gonfa.Guard{guard}, Actions: []gonfa.Action{action},
+			Tags: []string{"audited"}}},
+		Hooks{
+			OnSuccess: []gonfa.Action{action},
+			OnEvent:   map[gonfa.Event][]gonfa.Action{"Go": {action}},
+		},
+		DeclareEvents("Go"),
+		WithContextValues(map[any]any{"tenant": "acme"}))
+	require.NoError(t, err)
+
+	clone := def.Clone()
+	require.NotSame(t, def, clone)
+
+	assert.Equal(t, def.InitialState(), clone.InitialState())
+	assert.Equal(t, def.FinalStates(), clone.FinalStates())
+	assert.Equal(t, def.States(), clone.States())
+	assert.Equal(t, def.Transitions(), clone.Transitions())
+	assert.Equal(t, def.Hooks(), clone.Hooks())
+	assert.Equal(t, def.AllEvents(), clone.AllEvents())
+	assert.Equal(t, def.ContextValues(), clone.ContextValues())
+	events, declared := clone.DeclaredEvents()
+	assert.True(t, declared)
+	assert.Equal(t, []gonfa.Event{"Go"}, events)
+
+	// getters of the clone share its internal slices, but not the original's
+	clone.Transitions()[0].Guards[0] = &testGuard{result: false}
+	clone.Transitions()[0].Actions[0] = other
+	clone.States()["A"].OnExit[0] = other
+	clone.Hooks().OnSuccess[0] = other
+	clone.Hooks().OnEvent["Go"][0] = other
+
+	assert.Same(t, guard, def.Transitions()[0].Guards[0])
+	assert.Same(t, action, def.Transitions()[0].Actions[0])
+	assert.Same(t, action, def.States()["A"].OnExit[0])
+	assert.Same(t, action, def.Hooks().OnSuccess[0])
+	assert.Same(t, action, def.EventActions("Go")[0])
+}
@@ -0,0 +1,314 @@
+package definition
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dr-dobermann/gonfa/pkg/gonfa"
+	"github.com/dr-dobermann/gonfa/pkg/registry"
+)
+
+// lifecycleAction records Init/Close calls into a shared journal.
+type lifecycleAction struct {
+	name    string
+	initErr error
+	journal *[]string
+}
+
+func (a *lifecycleAction) Execute(
+	ctx context.Context,
+	state gonfa.MachineState,
+	payload gonfa.Payload,
+) error {
+	return nil
+}
+
+func (a *lifecycleAction) Init() error {
+	*a.journal = append(*a.journal, "init "+a.name)
+	return a.initErr
+}
+
+func (a *lifecycleAction) Close() error {
+	*a.journal = append(*a.journal, "close "+a.name)
+	return nil
+}
+
+// closeOnlyAction is Closable but not Initializable.
+type closeOnlyAction struct {
+	name    string
+	journal *[]string
+}
+
+func (a *closeOnlyAction) Execute(
+	ctx context.Context,
+	state gonfa.MachineState,
+	payload gonfa.Payload,
+) error {
+	return nil
+}
+
+func (a *closeOnlyAction) Close() error {
+	*a.journal = append(*a.journal, "close "+a.name)
+	return nil
+}
+
+// compositeAction is built of other actions.
+type compositeAction struct {
+	lifecycleAction
+	parts []gonfa.Action
+}
+
+func (a *compositeAction) Parts() []any {
+	parts := make([]any, len(a.parts))
+	for i, p := range a.parts {
+		parts[i] = p
+	}
+
+	return parts
+}
+
+func TestDefinitionLifecycle(t *testing.T) {
+	t.Run("init and close order", func(t *testing.T) {
+		var journal []string
+		trAction := &lifecycleAction{name: "transition", journal: &journal}
+		entry := &lifecycleAction{name: "entry", journal: &journal}
+		hook := &lifecycleAction{name: "hook", journal: &journal}
+
+		def, err := New("Start",
+			[]gonfa.State{"End"},
+			map[gonfa.State]StateConfig{
+				"Start": {},
+				"End":   {OnEntry: []gonfa.Action{entry, trAction}},
+			},
+			[]Transition{{
+				From:    "Start",
+				To:      "End",
+				On:      "Finish",
+				Actions: []gonfa.Action{trAction},
+			}},
+			Hooks{OnSuccess: []gonfa.Action{hook, &testAction{}}})
+		require.NoError(t, err)
+		assert.Equal(t,
+			[]string{"init transition", "init entry", "init hook"},
+			journal)
+
+		journal = nil
+		require.NoError(t, def.Close())
+		assert.Equal(t,
+			[]string{"close hook", "close entry", "close transition"},
+			journal)
+
+		// second Close is a no-op
+		journal = nil
+		require.NoError(t, def.Close())
+		assert.Empty(t, journal)
+	})
+
+	t.Run("init failure closes initialized components", func(t *testing.T) {
+		var journal []string
+		first := &lifecycleAction{name: "first", journal: &journal}
+		failed := &lifecycleAction{
+			name:    "failed",
+			journal: &journal,
+			initErr: errors.New("no connection"),
+		}
+
+		def, err := New("Start",
+			[]gonfa.State{"End"},
+			map[gonfa.State]StateConfig{"Start": {}, "End": {}},
+			[]Transition{{
+				From:    "Start",
+				To:      "End",
+				On:      "Finish",
+				Actions: []gonfa.Action{first, failed},
+			}},
+			Hooks{})
+		require.Error(t, err)
+		assert.Nil(t, def)
+		assert.Contains(t, err.Error(), "no connection")
+		assert.Equal(t,
+			[]string{"init first", "init failed", "close first"},
+			journal)
+	})
+
+	t.Run("init failure closes only initialized components", func(t *testing.T) {
+		var journal []string
+		first := &lifecycleAction{name: "first", journal: &journal}
+		closeOnly := &closeOnlyAction{name: "close only", journal: &journal}
+		failed := &lifecycleAction{
+			name:    "failed",
+			journal: &journal,
+			initErr: errors.New("no connection"),
+		}
+		last := &lifecycleAction{name: "last", journal: &journal}
+
+		_, err := New("Start",
+			[]gonfa.State{"End"},
+			map[gonfa.State]StateConfig{"Start": {}, "End": {}},
+			[]Transition{{
+				From:    "Start",
+				To:      "End",
+				On:      "Finish",
+				Actions: []gonfa.Action{first, closeOnly, failed, last},
+			}},
+			Hooks{})
+		require.Error(t, err)
+		assert.Equal(t,
+			[]string{"init first", "init failed", "close first"},
+			journal)
+	})
+
+	t.Run("composite parts", func(t *testing.T) {
+		var journal []string
+		part := &lifecycleAction{name: "part", journal: &journal}
+		composite := &compositeAction{
+			lifecycleAction: lifecycleAction{name: "composite", journal: &journal},
+			parts:           []gonfa.Action{part},
+		}
+
+		def, err := New("Start",
+			[]gonfa.State{"End"},
+			map[gonfa.State]StateConfig{"Start": {}, "End": {}},
+			[]Transition{{
+				From:    "Start",
+				To:      "End",
+				On:      "Finish",
+				Actions: []gonfa.Action{composite},
+			}},
+			Hooks{})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"init part", "init composite"}, journal)
+
+		journal = nil
+		require.NoError(t, def.Close())
+		assert.Equal(t, []string{"close composite", "close part"}, journal)
+	})
+
+	t.Run("shared components", func(t *testing.T) {
+		var journal []string
+		shared := &lifecycleAction{name: "shared", journal: &journal}
+		closeOnly := &closeOnlyAction{name: "close only", journal: &journal}
+
+		newDef := func() *Definition {
+			def, err := New("Start",
+				[]gonfa.State{"End"},
+				map[gonfa.State]StateConfig{"Start": {}, "End": {}},
+				[]Transition{{
+					From:    "Start",
+					To:      "End",
+					On:      "Finish",
+					Actions: []gonfa.Action{shared, closeOnly},
+				}},
+				Hooks{})
+			require.NoError(t, err)
+
+			return def
+		}
+
+		first, second := newDef(), newDef()
+		clone := second.Clone()
+		assert.Equal(t, []string{"init shared"}, journal)
+
+		journal = nil
+		require.NoError(t, first.Close())
+		require.NoError(t, second.Close())
+		assert.Empty(t, journal, "the clone still owns the components")
+
+		require.NoError(t, clone.Close())
+		assert.Equal(t, []string{"close close only", "close shared"}, journal)
+
+		// a new owner initializes them again
+		journal = nil
+		require.NoError(t, newDef().Close())
+		assert.Equal(t,
+			[]string{"init shared", "close close only", "close shared"},
+			journal)
+	})
+
+	t.Run("init failure keeps shared components", func(t *testing.T) {
+		var journal []string
+		shared := &lifecycleAction{name: "shared", journal: &journal}
+		failed := &lifecycleAction{
+			name:    "failed",
+			journal: &journal,
+			initErr: errors.New("no connection"),
+		}
+
+		newDef := func(actions ...gonfa.Action) (*Definition, error) {
+			return New("Start",
+				[]gonfa.State{"End"},
+				map[gonfa.State]StateConfig{"Start": {}, "End": {}},
+				[]Transition{{
+					From:    "Start",
+					To:      "End",
+					On:      "Finish",
+					Actions: actions,
+				}},
+				Hooks{})
+		}
+
+		def, err := newDef(shared)
+		require.NoError(t, err)
+
+		_, err = newDef(shared, failed)
+		require.Error(t, err)
+		assert.Equal(t, []string{"init shared", "init failed"}, journal)
+
+		journal = nil
+		require.NoError(t, def.Close())
+		assert.Equal(t, []string{"close shared"}, journal)
+	})
+
+	t.Run("init failure during LoadDefinition", func(t *testing.T) {
+		var journal []string
+		reg := registry.New()
+		require.NoError(t, reg.RegisterAction("broken", &lifecycleAction{
+			name:    "broken",
+			journal: &journal,
+			initErr: errors.New("init failed"),
+		}))
+
+		yamlData := `
+initialState: Start
+finalStates: [End]
+states:
+  Start: {}
+  End: {}
+transitions:
+  - from: Start
+    to: End
+    on: Finish
+    actions: [broken]
+`
+		def, err := LoadDefinition(strings.NewReader(yamlData), reg)
+		require.Error(t, err)
+		assert.Nil(t, def)
+		assert.Contains(t, err.Error(), "components initialization failed")
+	})
+}
+
+func TestGuardedActionLifecycle(t *testing.T) {
+	var journal []string
+	inner := &lifecycleAction{name: "inner", journal: &journal}
+
+	def, err := New("Start",
+		[]gonfa.State{"End"},
+		map[gonfa.State]StateConfig{
+			"Start": {},
+			"End": {OnEntry: []gonfa.Action{
+				&GuardedAction{Guard: &testGuard{result: true}, Action: inner},
+			}},
+		},
+		[]Transition{{From: "Start", To: "End", On: "Finish"}},
+		Hooks{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"init inner"}, journal)
+
+	require.NoError(t, def.Close())
+	assert.Equal(t, []string{"init inner", "close inner"}, journal)
+}
@@ -0,0 +1,98 @@
+package definition
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/dr-dobermann/gonfa/pkg/gonfa"
+)
+
+// ToDOT renders the definition as a Graphviz digraph, e.g. for
+// `dot -Tpng`. Every state is a node: the initial state is a box, final
+// states are double circles and the rest are circles. Every transition is
+// an edge labeled with its event, followed by its guards in brackets and
+// its actions after a slash; they are named after the names the definition
+// was loaded with, or counted if the names are unknown. Disabled
+// transitions are dashed. The output is sorted, so it's stable.
+func ToDOT(d *Definition) string {
+	var sb strings.Builder
+
+	sb.WriteString("digraph gonfa {\n")
+	sb.WriteString("  rankdir=LR;\n")
+	sb.WriteString("  node [shape=circle];\n")
+
+	states := make([]gonfa.State, 0, len(d.states))
+	for s := range d.states {
+		states = append(states, s)
+	}
+	slices.Sort(states)
+
+	for _, s := range states {
+		var attrs []string
+		switch {
+		case d.IsFinalState(s):
+			attrs = append(attrs, "shape=doublecircle")
+			if s == d.initialState {
+				attrs = append(attrs, "style=bold")
+			}
+		case s == d.initialState:
+			attrs = append(attrs, "shape=box")
+		}
+
+		fmt.Fprintf(&sb, "  %s", dotQuote(string(s)))
+		if len(attrs) > 0 {
+			fmt.Fprintf(&sb, " [%s]", strings.Join(attrs, ", "))
+		}
+		sb.WriteString(";\n")
+	}
+
+	for _, t := range sortTransitions(d.transitions) {
+		fmt.Fprintf(&sb, "  %s -> %s [label=%s",
+			dotQuote(string(t.From)), dotQuote(string(t.To)),
+			dotQuote(edgeLabel(t)))
+		if t.Disabled {
+			sb.WriteString(", style=dashed")
+		}
+		sb.WriteString("];\n")
+	}
+
+	sb.WriteString("}\n")
+
+	return sb.String()
+}
+
+// edgeLabel describes the transition as `event [guards] / actions`.
+func edgeLabel(t Transition) string {
+	label := string(t.On)
+
+	if len(t.Guards) > 0 {
+		label += " [" + objectList(t.GuardNames, len(t.Guards), "guard") + "]"
+	}
+	if len(t.Actions) > 0 {
+		label += " / " + objectList(t.ActionNames, len(t.Actions), "action")
+	}
+
+	return label
+}
+
+// objectList returns the names joined with commas or, if they are
+// unknown, the number of n objects of the kind.
+func objectList(names []string, n int, kind string) string {
+	if len(names) == n {
+		return strings.Join(names, ", ")
+	}
+
+	if n == 1 {
+		return "1 " + kind
+	}
+
+	return fmt.Sprintf("%d %ss", n, kind)
+}
+
+// dotQuote returns s as a DOT double-quoted string.
+func dotQuote(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+	return `"` + r.Replace(s) + `"`
+}
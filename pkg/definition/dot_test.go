@@ -0,0 +1,50 @@
+package definition
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dr-dobermann/gonfa/pkg/gonfa"
+)
+
+func TestToDOT(t *testing.T) {
+	guard := &testGuard{result: true}
+	action := &testAction{name: "notify"}
+
+	def, err := New("Draft", []gonfa.State{"Approved", "Rejected"},
+		map[gonfa.State]StateConfig{
+			"Draft": {}, `In "Review"`: {}, "Approved": {}, "Rejected": {},
+		},
+		[]Transition{
+			{From: "Draft", To: `In "Review"`, On: "Submit",
+				Actions: []gonfa.Action{action}},
+			{From: `In "Review"`, To: "Approved", On: "Approve",
+				Guards:     []gonfa.Guard{guard},
+				GuardNames: []string{"isManager"},
+				Actions:    []gonfa.Action{action, action}},
+			{From: `In "Review"`, To: "Rejected", On: "Reject",
+				Guards: []gonfa.Guard{guard, guard}, Disabled: true},
+			{From: `In "Review"`, To: `In "Review"`, On: "Comment"},
+			{From: `In "Review"`, To: "Rejected", On: "Timeout"},
+		},
+		Hooks{})
+	require.NoError(t, err)
+
+	expected := `digraph gonfa {
+  rankdir=LR;
+  node [shape=circle];
+  "Approved" [shape=doublecircle];
+  "Draft" [shape=box];
+  "In \"Review\"";
+  "Rejected" [shape=doublecircle];
+  "Draft" -> "In \"Review\"" [label="Submit / 1 action"];
+  "In \"Review\"" -> "Approved" [label="Approve [isManager] / 2 actions"];
+  "In \"Review\"" -> "In \"Review\"" [label="Comment"];
+  "In \"Review\"" -> "Rejected" [label="Reject [2 guards]", style=dashed];
+  "In \"Review\"" -> "Rejected" [label="Timeout"];
+}
+`
+	assert.Equal(t, expected, ToDOT(def))
+}
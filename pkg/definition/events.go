@@ -0,0 +1,77 @@
+package definition
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/dr-dobermann/gonfa/pkg/gonfa"
+)
+
+// Option configures optional checks of a Definition created by New.
+type Option func(*Definition)
+
+// DeclareEvents declares the event vocabulary of the definition for teams
+// maintaining it separately from the transition table. Transitions on
+// undeclared events are reported by Lint, and RequireAllEventsReachable
+// rejects declared events which aren't used. Several calls add up.
+func DeclareEvents(events ...gonfa.Event) Option {
+	return func(d *Definition) {
+		d.eventsDeclared = true
+		for _, e := range events {
+			if !slices.Contains(d.declaredEvents, e) {
+				d.declaredEvents = append(d.declaredEvents, e)
+			}
+		}
+	}
+}
+
+// RequireAllEventsReachable makes New fail if any event declared with
+// DeclareEvents isn't used in a transition, i.e. it was declared but never
+// wired up. Without declared events it has no effect.
+func RequireAllEventsReachable() Option {
+	return func(d *Definition) {
+		d.requireAllEvents = true
+	}
+}
+
+// DeclaredEvents returns the sorted event vocabulary declared with
+// DeclareEvents and false if it isn't declared. Unlike AllEvents, it may
+// contain events which aren't used in transitions.
+func (d *Definition) DeclaredEvents() ([]gonfa.Event, bool) {
+	return slices.Clone(d.declaredEvents), d.eventsDeclared
+}
+
+// checkDeclaredEvents checks that every declared event is used if the
+// definition requires it.
+func (d *Definition) checkDeclaredEvents() error {
+	if !d.requireAllEvents {
+		return nil
+	}
+
+	for _, e := range d.declaredEvents {
+		if !d.HasEvent(e) {
+			return fmt.Errorf(
+				"declared event '%s' isn't used in any transition", e)
+		}
+	}
+
+	return nil
+}
+
+// options returns the options reproducing the declared events and context
+// values settings of the definition.
+func (d *Definition) options() []Option {
+	var opts []Option
+
+	if d.eventsDeclared {
+		opts = append(opts, DeclareEvents(d.declaredEvents...))
+	}
+	if d.requireAllEvents {
+		opts = append(opts, RequireAllEventsReachable())
+	}
+	if d.contextValues != nil {
+		opts = append(opts, WithContextValues(d.contextValues))
+	}
+
+	return opts
+}
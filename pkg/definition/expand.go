@@ -0,0 +1,91 @@
+package definition
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/dr-dobermann/gonfa/pkg/registry"
+)
+
+// varToken matches ${VAR} tokens substituted by LoadDefinitionExpanded.
+var varToken = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// expandConfig holds the LoadDefinitionExpanded settings.
+type expandConfig struct {
+	keepUnresolved bool
+}
+
+// ExpandOption configures LoadDefinitionExpanded.
+type ExpandOption func(*expandConfig)
+
+// KeepUnresolved makes LoadDefinitionExpanded leave tokens of unknown
+// variables as they are instead of failing.
+func KeepUnresolved() ExpandOption {
+	return func(c *expandConfig) {
+		c.keepUnresolved = true
+	}
+}
+
+// LoadDefinitionExpanded works like LoadDefinition but first substitutes
+// ${VAR} tokens in the raw YAML with the values from vars, so one workflow
+// template could serve several environments:
+//
+//	transitions:
+//	  - from: Review
+//	    to: Approved
+//	    on: ${APPROVE_EVENT}
+//
+// If vars is nil, the variables are looked up in the process environment.
+// Substitution happens before the YAML is parsed, so values are inserted
+// verbatim and must be valid at their position. By default an unknown
+// variable is an error listing all unknown names; see KeepUnresolved.
+func LoadDefinitionExpanded(
+	r io.Reader,
+	registry registry.Resolver,
+	vars map[string]string,
+	opts ...ExpandOption,
+) (*Definition, error) {
+	var cfg expandConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read YAML data: %w", err)
+	}
+
+	lookup := os.LookupEnv
+	if vars != nil {
+		lookup = func(name string) (string, bool) {
+			v, ok := vars[name]
+			return v, ok
+		}
+	}
+
+	var unresolved []string
+	data = varToken.ReplaceAllFunc(data, func(token []byte) []byte {
+		name := string(token[2 : len(token)-1])
+		if v, ok := lookup(name); ok {
+			return []byte(v)
+		}
+
+		if !slices.Contains(unresolved, name) {
+			unresolved = append(unresolved, name)
+		}
+		return token
+	})
+
+	if len(unresolved) > 0 && !cfg.keepUnresolved {
+		slices.Sort(unresolved)
+		return nil, fmt.Errorf("unresolved variables: %s",
+			strings.Join(unresolved, ", "))
+	}
+
+	return LoadDefinition(bytes.NewReader(data), registry)
+}
@@ -0,0 +1,64 @@
+package definition
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dr-dobermann/gonfa/pkg/gonfa"
+)
+
+const expandYAML = `
+initialState: ${START}
+finalStates: [End]
+states:
+  ${START}: {}
+  End:
+    onEntry: [action1]
+transitions:
+  - from: ${START}
+    to: End
+    on: ${EVENT}
+`
+
+func TestLoadDefinitionExpanded(t *testing.T) {
+	t.Run("substitutes variables", func(t *testing.T) {
+		def, err := LoadDefinitionExpanded(strings.NewReader(expandYAML),
+			getTestRegistry(),
+			map[string]string{"START": "Draft", "EVENT": "Finish"})
+		require.NoError(t, err)
+
+		assert.Equal(t, gonfa.State("Draft"), def.InitialState())
+		assert.Equal(t, []gonfa.Event{"Finish"}, def.AllEvents())
+	})
+
+	t.Run("environment", func(t *testing.T) {
+		t.Setenv("START", "Open")
+		t.Setenv("EVENT", "Close")
+
+		def, err := LoadDefinitionExpanded(strings.NewReader(expandYAML),
+			getTestRegistry(), nil)
+		require.NoError(t, err)
+
+		assert.Equal(t, gonfa.State("Open"), def.InitialState())
+		assert.True(t, def.HasEvent("Close"))
+	})
+
+	t.Run("unresolved variables", func(t *testing.T) {
+		_, err := LoadDefinitionExpanded(strings.NewReader(expandYAML),
+			getTestRegistry(), map[string]string{})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "unresolved variables: EVENT, START")
+	})
+
+	t.Run("keep unresolved", func(t *testing.T) {
+		def, err := LoadDefinitionExpanded(strings.NewReader(expandYAML),
+			getTestRegistry(), map[string]string{"EVENT": "Finish"},
+			KeepUnresolved())
+		require.NoError(t, err)
+
+		assert.Equal(t, gonfa.State("${START}"), def.InitialState())
+	})
+}
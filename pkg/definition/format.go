@@ -0,0 +1,57 @@
+package definition
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/dr-dobermann/gonfa/pkg/gonfa"
+)
+
+// String returns a readable tabular summary of the definition: the initial
+// state, sorted final states and every transition sorted by (From, On, To)
+// in the form `From --On--> To [guards=n actions=m]`.
+func (d *Definition) String() string {
+	var sb strings.Builder
+
+	fmt.Fprintf(&sb, "Initial: %s\n", d.initialState)
+
+	finals := stateNames(d.finalStates)
+	slices.Sort(finals)
+	fmt.Fprintf(&sb, "Final: %s\n", strings.Join(finals, ", "))
+
+	sb.WriteString("Transitions:\n")
+	for _, t := range sortTransitions(d.transitions) {
+		fmt.Fprintf(&sb, "  %s --%s--> %s [guards=%d actions=%d]\n",
+			t.From, t.On, t.To, len(t.Guards), len(t.Actions))
+	}
+
+	return sb.String()
+}
+
+// sortTransitions returns a copy of transitions stably sorted by
+// (From, On, To).
+func sortTransitions(transitions []Transition) []Transition {
+	sorted := slices.Clone(transitions)
+	slices.SortStableFunc(sorted, func(a, b Transition) int {
+		return cmp.Or(
+			cmp.Compare(a.From, b.From),
+			cmp.Compare(a.On, b.On),
+			cmp.Compare(a.To, b.To))
+	})
+
+	return sorted
+}
+
+var _ fmt.Stringer = (*Definition)(nil)
+
+// stateNames converts states to strings.
+func stateNames(states []gonfa.State) []string {
+	names := make([]string, len(states))
+	for i, s := range states {
+		names[i] = string(s)
+	}
+
+	return names
+}
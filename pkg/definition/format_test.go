@@ -0,0 +1,36 @@
+package definition
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dr-dobermann/gonfa/pkg/gonfa"
+)
+
+func TestDefinitionString(t *testing.T) {
+	states := map[gonfa.State]StateConfig{
+		"Draft": {}, "Review": {}, "Approved": {}, "Rejected": {},
+	}
+	transitions := []Transition{
+		{From: "Review", To: "Rejected", On: "Reject"},
+		{From: "Review", To: "Approved", On: "Approve",
+			Guards:  []gonfa.Guard{&testGuard{result: true}},
+			Actions: []gonfa.Action{&testAction{}, &testAction{}}},
+		{From: "Draft", To: "Review", On: "Submit"},
+	}
+
+	def, err := New("Draft", []gonfa.State{"Rejected", "Approved"},
+		states, transitions, Hooks{})
+	require.NoError(t, err)
+
+	expected := `Initial: Draft
+Final: Approved, Rejected
+Transitions:
+  Draft --Submit--> Review [guards=0 actions=0]
+  Review --Approve--> Approved [guards=1 actions=2]
+  Review --Reject--> Rejected [guards=0 actions=0]
+`
+	assert.Equal(t, expected, def.String())
+}
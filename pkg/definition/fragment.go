@@ -0,0 +1,76 @@
+package definition
+
+import (
+	"slices"
+
+	"github.com/dr-dobermann/gonfa/pkg/gonfa"
+)
+
+// Fragment is a reusable, unvalidated part of a state machine definition.
+// Unlike a Definition it may lack an initial state, have dangling states or
+// unreachable final states. Fragments are merged into a complete
+// Definition by builder.FromFragments; only the assembled Definition is
+// validated.
+//
+// A Fragment is immutable, so it can be shared between builders.
+type Fragment struct {
+	initialState gonfa.State
+	finalStates  []gonfa.State
+	states       map[gonfa.State]StateConfig
+	transitions  []Transition
+	hooks        Hooks
+}
+
+// NewFragment creates a new Fragment with the given parameters.
+// initialState may be empty. No checks besides copying are made.
+func NewFragment(
+	initialState gonfa.State,
+	finalStates []gonfa.State,
+	states map[gonfa.State]StateConfig,
+	transitions []Transition,
+	hooks Hooks,
+) *Fragment {
+	statesCopy := make(map[gonfa.State]StateConfig, len(states))
+	for k, v := range states {
+		statesCopy[k] = v
+	}
+
+	return &Fragment{
+		initialState: initialState,
+		finalStates:  slices.Clone(finalStates),
+		states:       statesCopy,
+		transitions:  slices.Clone(transitions),
+		hooks:        hooks.clone(),
+	}
+}
+
+// InitialState returns the initial state of the fragment or an empty state
+// if the fragment doesn't declare it.
+func (f *Fragment) InitialState() gonfa.State {
+	return f.initialState
+}
+
+// FinalStates returns a copy of the fragment's final states.
+func (f *Fragment) FinalStates() []gonfa.State {
+	return slices.Clone(f.finalStates)
+}
+
+// States returns a copy of the fragment's states configuration.
+func (f *Fragment) States() map[gonfa.State]StateConfig {
+	states := make(map[gonfa.State]StateConfig, len(f.states))
+	for k, v := range f.states {
+		states[k] = v
+	}
+
+	return states
+}
+
+// Transitions returns a copy of the fragment's transitions.
+func (f *Fragment) Transitions() []Transition {
+	return slices.Clone(f.transitions)
+}
+
+// Hooks returns the fragment's global hooks.
+func (f *Fragment) Hooks() Hooks {
+	return f.hooks.clone()
+}
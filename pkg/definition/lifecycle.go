@@ -0,0 +1,276 @@
+package definition
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"sync"
+
+	"github.com/dr-dobermann/gonfa/pkg/gonfa"
+)
+
+// components returns all unique guards and actions bound into the
+// definition in a deterministic order:
+//  1. transitions in declaration order (preconditions, guards, actions,
+//     after actions, compensations, async actions);
+//  2. states sorted by name (OnEntry, OnExit, then AcceptIf);
+//  3. global hooks (OnSuccess, OnFailure, then OnEvent sorted by event).
+//
+// An object referenced several times is returned only once. GuardedActions
+// are replaced by their guards and actions, and the parts of a
+// gonfa.Composite precede it.
+func (d *Definition) components() []any {
+	var (
+		result []any
+		seen   = make(map[any]struct{})
+	)
+
+	var add func(c any)
+	add = func(c any) {
+		if c == nil {
+			return
+		}
+
+		if ga, ok := c.(*GuardedAction); ok {
+			add(ga.Guard)
+			add(ga.Action)
+			return
+		}
+
+		// Non-comparable values can't be used as map keys, so they are
+		// never deduplicated.
+		if reflect.TypeOf(c).Comparable() {
+			if _, ok := seen[c]; ok {
+				return
+			}
+			seen[c] = struct{}{}
+		}
+
+		if cc, ok := c.(gonfa.Composite); ok {
+			for _, p := range cc.Parts() {
+				add(p)
+			}
+		}
+
+		result = append(result, c)
+	}
+
+	for _, t := range d.transitions {
+		for _, g := range t.Preconditions {
+			add(g)
+		}
+		for _, g := range t.Guards {
+			add(g)
+		}
+		for _, a := range t.Actions {
+			add(a)
+		}
+		for _, a := range t.AfterActions {
+			add(a)
+		}
+		for _, a := range t.Compensations {
+			add(a)
+		}
+		for _, a := range t.AsyncActions {
+			add(a.Action)
+		}
+	}
+
+	states := make([]gonfa.State, 0, len(d.states))
+	for s := range d.states {
+		states = append(states, s)
+	}
+	slices.Sort(states)
+
+	for _, s := range states {
+		for _, a := range d.states[s].OnEntry {
+			add(a)
+		}
+		for _, a := range d.states[s].OnExit {
+			add(a)
+		}
+		for _, g := range d.states[s].AcceptIf {
+			add(g)
+		}
+	}
+
+	for _, a := range d.hooks.OnSuccess {
+		add(a)
+	}
+	for _, a := range d.hooks.OnFailure {
+		add(a)
+	}
+
+	events := make([]gonfa.Event, 0, len(d.hooks.OnEvent))
+	for e := range d.hooks.OnEvent {
+		events = append(events, e)
+	}
+	slices.Sort(events)
+
+	for _, e := range events {
+		for _, a := range d.hooks.OnEvent[e] {
+			add(a)
+		}
+	}
+
+	return result
+}
+
+// owners counts the open definitions every guard and action implementing
+// gonfa.Initializable or gonfa.Closable is bound into, so an object shared
+// by several definitions is initialized by the first of them and closed by
+// the last one. Init and Close are called under the lock.
+var owners = struct {
+	sync.Mutex
+	count map[any]int
+}{count: make(map[any]int)}
+
+// managed reports whether the component has a lifecycle to track.
+func managed(c any) bool {
+	_, in := c.(gonfa.Initializable)
+	_, cl := c.(gonfa.Closable)
+
+	return in || cl
+}
+
+// acquire adds an owner to the component and reports whether it's the
+// first one. Non-comparable components can't be tracked, so every
+// definition owns them on its own.
+// Must be called with owners locked.
+func acquire(c any) bool {
+	if !reflect.TypeOf(c).Comparable() {
+		return true
+	}
+
+	owners.count[c]++
+
+	return owners.count[c] == 1
+}
+
+// release removes an owner from the component and reports whether it was
+// the last one.
+// Must be called with owners locked.
+func release(c any) bool {
+	if !reflect.TypeOf(c).Comparable() {
+		return true
+	}
+
+	owners.count[c]--
+	if owners.count[c] > 0 {
+		return false
+	}
+
+	delete(owners.count, c)
+
+	return true
+}
+
+// initComponents makes the definition an owner of its gonfa.Initializable
+// and gonfa.Closable components, calling Init on the ones it's the first
+// owner of. If any Init fails, the definition releases the components
+// acquired so far and the Init error is returned; only the initialized ones
+// are closed.
+func (d *Definition) initComponents() error {
+	owners.Lock()
+	defer owners.Unlock()
+
+	var owned []any
+
+	for _, c := range d.components() {
+		if !managed(c) {
+			continue
+		}
+
+		in, ok := c.(gonfa.Initializable)
+		if !acquire(c) || !ok {
+			owned = append(owned, c)
+			continue
+		}
+
+		if err := in.Init(); err != nil {
+			release(c)
+
+			initErr := fmt.Errorf("failed to init %T: %w", c, err)
+			if closeErr := releaseComponents(owned, true); closeErr != nil {
+				return errors.Join(initErr, closeErr)
+			}
+
+			return initErr
+		}
+
+		owned = append(owned, c)
+	}
+
+	d.owned = owned
+
+	return nil
+}
+
+// releaseComponents removes an owner from every component in reverse
+// order and calls Close on the gonfa.Closable ones which have no owners
+// left. If initializedOnly is set, components which aren't
+// gonfa.Initializable aren't closed. All components are released even if
+// some of them fail to close; the errors are joined together.
+// Must be called with owners locked.
+func releaseComponents(cc []any, initializedOnly bool) error {
+	var errs []error
+
+	for i := len(cc) - 1; i >= 0; i-- {
+		if !release(cc[i]) {
+			continue
+		}
+
+		cl, ok := cc[i].(gonfa.Closable)
+		if !ok {
+			continue
+		}
+
+		if _, ok := cc[i].(gonfa.Initializable); initializedOnly && !ok {
+			continue
+		}
+
+		if err := cl.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("failed to close %T: %w", cc[i], err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// retainComponents adds an owner to the trackable components owned by
+// another definition and returns them. They are already initialized, so
+// Init isn't called.
+func retainComponents(cc []any) []any {
+	owners.Lock()
+	defer owners.Unlock()
+
+	var owned []any
+
+	for _, c := range cc {
+		if reflect.TypeOf(c).Comparable() {
+			acquire(c)
+			owned = append(owned, c)
+		}
+	}
+
+	return owned
+}
+
+// Close releases the definition's guards and actions: each one
+// implementing gonfa.Closable is closed, in the reverse order of their
+// initialization, unless another open definition shares it. Only the first
+// call has an effect; subsequent calls return the result of the first one.
+//
+// Machines must not use the definition after it's closed.
+func (d *Definition) Close() error {
+	d.closeOnce.Do(func() {
+		owners.Lock()
+		defer owners.Unlock()
+
+		d.closeErr = releaseComponents(d.owned, false)
+		d.owned = nil
+	})
+
+	return d.closeErr
+}
@@ -0,0 +1,209 @@
+package definition
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/dr-dobermann/gonfa/pkg/gonfa"
+)
+
+// Warning describes a suspicious but valid part of a Definition.
+// Unlike validation errors, warnings don't prevent the Definition from
+// being created.
+type Warning struct {
+	Check   string // Name of the lint check which produced the warning
+	Message string // Human-readable description of the problem
+}
+
+// String returns the warning in the "check: message" form.
+func (w Warning) String() string {
+	return w.Check + ": " + w.Message
+}
+
+// Lint check names.
+const (
+	LintDisabledState        = "disabled-state"
+	LintAmbiguousTransitions = "ambiguous-transitions"
+	LintSimilarStateNames    = "similar-state-names"
+	LintUndeclaredEvent      = "undeclared-event"
+)
+
+// Lint runs all lint checks on the definition and returns the found
+// warnings in a deterministic order.
+func Lint(d *Definition) []Warning {
+	var warnings []Warning
+
+	warnings = append(warnings, lintDisabledStates(d)...)
+	warnings = append(warnings, lintAmbiguousTransitions(d)...)
+	warnings = append(warnings, lintSimilarStateNames(d)...)
+	warnings = append(warnings, lintUndeclaredEvents(d)...)
+
+	return warnings
+}
+
+// lintDisabledStates flags states which can't be entered at runtime because
+// every transition into them has a guard that always returns false
+// (see gonfa.ConstantGuard). The initial state is never flagged.
+func lintDisabledStates(d *Definition) []Warning {
+	inbound := make(map[gonfa.State][]Transition)
+	for _, t := range d.transitions {
+		inbound[t.To] = append(inbound[t.To], t)
+	}
+
+	states := make([]gonfa.State, 0, len(inbound))
+	for s := range inbound {
+		states = append(states, s)
+	}
+	slices.Sort(states)
+
+	var warnings []Warning
+	for _, s := range states {
+		if s == d.initialState {
+			continue
+		}
+
+		if !slices.ContainsFunc(inbound[s], isEnabled) {
+			warnings = append(warnings, Warning{
+				Check: LintDisabledState,
+				Message: fmt.Sprintf(
+					"state '%s' is unreachable at runtime: "+
+						"all incoming transitions have a constant-false guard",
+					s),
+			})
+		}
+	}
+
+	return warnings
+}
+
+// isEnabled returns false if the transition has a guard which always
+// rejects it.
+func isEnabled(t Transition) bool {
+	for _, g := range t.Guards {
+		if cg, ok := g.(gonfa.ConstantGuard); ok && !cg.ConstantResult() {
+			return false
+		}
+	}
+
+	return true
+}
+
+// lintAmbiguousTransitions flags pairs of competing transitions (same From
+// and On) which can't be told apart by their guards: both have no guards or
+// both have the same set of guard instances. When such transitions compete,
+// Fire always takes the first one, which is rarely intended.
+func lintAmbiguousTransitions(d *Definition) []Warning {
+	var warnings []Warning
+
+	for i, t := range d.transitions {
+		for _, other := range d.transitions[i+1:] {
+			if t.From != other.From || t.On != other.On {
+				continue
+			}
+
+			if sameGuards(t.Guards, other.Guards) {
+				warnings = append(warnings, Warning{
+					Check: LintAmbiguousTransitions,
+					Message: fmt.Sprintf(
+						"transitions from '%s' on event '%s' to '%s' and '%s' "+
+							"have no distinguishing guards",
+						t.From, t.On, t.To, other.To),
+				})
+			}
+		}
+	}
+
+	return warnings
+}
+
+// sameGuards checks if two guard lists consist of the same guard instances
+// regardless of their order. Non-comparable guards are never considered
+// equal.
+func sameGuards(a, b []gonfa.Guard) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	contains := func(gg []gonfa.Guard, g gonfa.Guard) bool {
+		return slices.ContainsFunc(gg, func(x gonfa.Guard) bool {
+			tx := reflect.TypeOf(x)
+			return tx == reflect.TypeOf(g) && tx.Comparable() && x == g
+		})
+	}
+
+	for _, g := range a {
+		if !contains(b, g) {
+			return false
+		}
+	}
+	for _, g := range b {
+		if !contains(a, g) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// lintSimilarStateNames flags pairs of distinct states whose names are equal
+// after trimming and collapsing whitespace and ignoring case (e.g. "End"
+// and "end "). Such states are likely a typo which silently creates an
+// extra state.
+func lintSimilarStateNames(d *Definition) []Warning {
+	states := make([]gonfa.State, 0, len(d.states))
+	for s := range d.states {
+		states = append(states, s)
+	}
+	slices.Sort(states)
+
+	var warnings []Warning
+	for i, a := range states {
+		for _, b := range states[i+1:] {
+			if normalizeStateName(a) != normalizeStateName(b) {
+				continue
+			}
+
+			warnings = append(warnings, Warning{
+				Check: LintSimilarStateNames,
+				Message: fmt.Sprintf(
+					"states '%s' and '%s' differ only in whitespace or case",
+					a, b),
+			})
+		}
+	}
+
+	return warnings
+}
+
+// normalizeStateName lowercases the name, trims it and collapses inner
+// whitespace runs into single spaces.
+func normalizeStateName(s gonfa.State) string {
+	return strings.Join(strings.Fields(strings.ToLower(string(s))), " ")
+}
+
+// lintUndeclaredEvents flags transitions on events outside the vocabulary
+// declared with DeclareEvents, in declaration order. Nothing is flagged if
+// the vocabulary isn't declared.
+func lintUndeclaredEvents(d *Definition) []Warning {
+	if !d.eventsDeclared {
+		return nil
+	}
+
+	var warnings []Warning
+	for _, t := range d.transitions {
+		if _, found := slices.BinarySearch(d.declaredEvents, t.On); found {
+			continue
+		}
+
+		warnings = append(warnings, Warning{
+			Check: LintUndeclaredEvent,
+			Message: fmt.Sprintf(
+				"transition from '%s' to '%s' uses undeclared event '%s'",
+				t.From, t.To, t.On),
+		})
+	}
+
+	return warnings
+}
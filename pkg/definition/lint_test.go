@@ -0,0 +1,155 @@
+package definition
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dr-dobermann/gonfa/pkg/gonfa"
+)
+
+func TestLintDisabledStates(t *testing.T) {
+	off := &constGuard{result: false}
+	on := &constGuard{result: true}
+
+	states := map[gonfa.State]StateConfig{
+		"Start": {}, "Beta": {}, "Stable": {}, "End": {},
+	}
+
+	t.Run("state behind constant-false guards", func(t *testing.T) {
+		def, err := New("Start", []gonfa.State{"End"}, states,
+			[]Transition{
+				{From: "Start", To: "Beta", On: "TryBeta",
+					Guards: []gonfa.Guard{&testGuard{result: true}, off}},
+				{From: "Start", To: "Stable", On: "Go"},
+				{From: "Beta", To: "End", On: "Finish"},
+				{From: "Stable", To: "End", On: "Finish"},
+			}, Hooks{})
+		require.NoError(t, err)
+
+		warnings := Lint(def)
+		require.Len(t, warnings, 1)
+		assert.Equal(t, LintDisabledState, warnings[0].Check)
+		assert.Contains(t, warnings[0].Message, "state 'Beta'")
+		assert.Contains(t, warnings[0].String(), "disabled-state: ")
+	})
+
+	t.Run("one enabled transition is enough", func(t *testing.T) {
+		def, err := New("Start", []gonfa.State{"End"}, states,
+			[]Transition{
+				{From: "Start", To: "Beta", On: "TryBeta",
+					Guards: []gonfa.Guard{off}},
+				{From: "Start", To: "Stable", On: "Go",
+					Guards: []gonfa.Guard{on}},
+				{From: "Stable", To: "Beta", On: "Go"},
+				{From: "Beta", To: "End", On: "Finish"},
+			}, Hooks{})
+		require.NoError(t, err)
+
+		assert.Empty(t, Lint(def))
+	})
+}
+
+func TestLintAmbiguousTransitions(t *testing.T) {
+	g1 := &testGuard{result: true}
+	g2 := &testGuard{result: true}
+	states := map[gonfa.State]StateConfig{
+		"Start": {}, "A": {}, "B": {}, "C": {},
+	}
+	finals := []gonfa.State{"A", "B", "C"}
+
+	t.Run("unguarded competitors", func(t *testing.T) {
+		def, err := New("Start", finals, states, []Transition{
+			{From: "Start", To: "A", On: "Go"},
+			{From: "Start", To: "B", On: "Go"},
+			{From: "Start", To: "C", On: "Go", Guards: []gonfa.Guard{g1}},
+		}, Hooks{})
+		require.NoError(t, err)
+
+		warnings := Lint(def)
+		require.Len(t, warnings, 1)
+		assert.Equal(t, LintAmbiguousTransitions, warnings[0].Check)
+		assert.Contains(t, warnings[0].Message, "to 'A' and 'B'")
+	})
+
+	t.Run("identical guard sets", func(t *testing.T) {
+		def, err := New("Start", finals, states, []Transition{
+			{From: "Start", To: "A", On: "Go", Guards: []gonfa.Guard{g1, g2}},
+			{From: "Start", To: "B", On: "Go", Guards: []gonfa.Guard{g2, g1}},
+			{From: "Start", To: "C", On: "Other"},
+		}, Hooks{})
+		require.NoError(t, err)
+
+		warnings := Lint(def)
+		require.Len(t, warnings, 1)
+		assert.Contains(t, warnings[0].Message, "to 'A' and 'B'")
+	})
+
+	t.Run("distinct guards", func(t *testing.T) {
+		def, err := New("Start", finals, states, []Transition{
+			{From: "Start", To: "A", On: "Go", Guards: []gonfa.Guard{g1}},
+			{From: "Start", To: "B", On: "Go", Guards: []gonfa.Guard{g2}},
+			{From: "Start", To: "C", On: "Go"},
+		}, Hooks{})
+		require.NoError(t, err)
+
+		assert.Empty(t, Lint(def))
+	})
+}
+
+func TestLintSimilarStateNames(t *testing.T) {
+	states := map[gonfa.State]StateConfig{
+		"Start": {}, "Needs Review": {}, "needs  review ": {}, "End": {},
+	}
+
+	def, err := New("Start", []gonfa.State{"End"}, states,
+		[]Transition{
+			{From: "Start", To: "Needs Review", On: "Submit"},
+			{From: "Start", To: "needs  review ", On: "Resubmit"},
+			{From: "Needs Review", To: "End", On: "Approve"},
+			{From: "needs  review ", To: "End", On: "Approve"},
+		}, Hooks{})
+	require.NoError(t, err)
+
+	warnings := Lint(def)
+	require.Len(t, warnings, 1)
+	assert.Equal(t, LintSimilarStateNames, warnings[0].Check)
+	assert.Contains(t, warnings[0].Message,
+		"states 'Needs Review' and 'needs  review '")
+
+	t.Run("distinct names", func(t *testing.T) {
+		assert.NotEqual(t,
+			normalizeStateName("NeedsReview"), normalizeStateName("Needs Review"))
+		assert.Equal(t, normalizeStateName("End"), normalizeStateName("\tEND "))
+	})
+}
+
+func TestLintUndeclaredEvents(t *testing.T) {
+	states := map[gonfa.State]StateConfig{"Start": {}, "Middle": {}, "End": {}}
+	transitions := []Transition{
+		{From: "Start", To: "Middle", On: "Submit"},
+		{From: "Middle", To: "End", On: "Aprove"},
+	}
+
+	t.Run("undeclared event", func(t *testing.T) {
+		def, err := New("Start", []gonfa.State{"End"}, states, transitions,
+			Hooks{}, DeclareEvents("Submit", "Approve"))
+		require.NoError(t, err)
+
+		warnings := Lint(def)
+		require.Len(t, warnings, 1)
+		assert.Equal(t, LintUndeclaredEvent, warnings[0].Check)
+		assert.Equal(t,
+			"transition from 'Middle' to 'End' uses undeclared event 'Aprove'",
+			warnings[0].Message)
+	})
+
+	t.Run("no vocabulary", func(t *testing.T) {
+		def, err := New("Start", []gonfa.State{"End"}, states, transitions,
+			Hooks{})
+		require.NoError(t, err)
+
+		assert.Empty(t, Lint(def))
+	})
+}
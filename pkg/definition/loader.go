@@ -13,8 +13,13 @@
 package definition
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"io/fs"
+	"strings"
 
 	"gopkg.in/yaml.v3"
 
@@ -24,39 +29,214 @@ import (
 
 // yamlDefinition represents the YAML structure for loading definitions
 type yamlDefinition struct {
-	InitialState string                     `yaml:"initialState"`
-	FinalStates  []string                   `yaml:"finalStates,omitempty"`
-	Hooks        yamlHooks                  `yaml:"hooks,omitempty"`
-	States       map[string]yamlStateConfig `yaml:"states,omitempty"`
-	Transitions  []yamlTransition           `yaml:"transitions"`
+	InitialState string                     `yaml:"initialState" json:"initialState"`
+	FinalStates  []string                   `yaml:"finalStates,omitempty" json:"finalStates,omitempty"`
+	Hooks        yamlHooks                  `yaml:"hooks,omitempty" json:"hooks,omitempty"`
+	States       map[string]yamlStateConfig `yaml:"states,omitempty" json:"states,omitempty"`
+	OnEvent      map[string][]yamlName      `yaml:"onEvent,omitempty" json:"onEvent,omitempty"`
+	Events       []string                   `yaml:"events,omitempty" json:"events,omitempty"`
+	Transitions  []yamlTransition           `yaml:"transitions" json:"transitions"`
+	statePos     map[string]yamlPos
+}
+
+// UnmarshalYAML decodes the definition remembering the positions of the
+// states declared under the `states` key.
+func (d *yamlDefinition) UnmarshalYAML(node *yaml.Node) error {
+	type plain yamlDefinition
+	if err := node.Decode((*plain)(d)); err != nil {
+		return err
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		if key.Value != "states" || value.Kind != yaml.MappingNode {
+			continue
+		}
+
+		d.statePos = make(map[string]yamlPos, len(value.Content)/2)
+		for j := 0; j+1 < len(value.Content); j += 2 {
+			d.statePos[value.Content[j].Value] = positionOf(value.Content[j])
+		}
+	}
+
+	return nil
 }
 
 // yamlHooks represents hooks configuration in YAML format
 type yamlHooks struct {
-	OnSuccess []string `yaml:"onSuccess,omitempty"`
-	OnFailure []string `yaml:"onFailure,omitempty"`
+	OnSuccess []yamlName `yaml:"onSuccess,omitempty" json:"onSuccess,omitempty"`
+	OnFailure []yamlName `yaml:"onFailure,omitempty" json:"onFailure,omitempty"`
 }
 
 // yamlStateConfig represents state configuration in YAML format
 type yamlStateConfig struct {
-	OnEntry []string `yaml:"onEntry,omitempty"`
-	OnExit  []string `yaml:"onExit,omitempty"`
+	OnEntry []yamlName `yaml:"onEntry,omitempty" json:"onEntry,omitempty"`
+	OnExit  []yamlName `yaml:"onExit,omitempty" json:"onExit,omitempty"`
 }
 
 // yamlTransition represents a transition configuration in YAML format
 type yamlTransition struct {
-	From    string   `yaml:"from"`
-	To      string   `yaml:"to"`
-	On      string   `yaml:"on"`
-	Guards  []string `yaml:"guards,omitempty"`
-	Actions []string `yaml:"actions,omitempty"`
+	From     string     `yaml:"from" json:"from"`
+	To       string     `yaml:"to" json:"to"`
+	On       string     `yaml:"on" json:"on"`
+	Guards   []yamlName `yaml:"guards,omitempty" json:"guards,omitempty"`
+	Actions  []yamlName `yaml:"actions,omitempty" json:"actions,omitempty"`
+	Weight   *float64   `yaml:"weight,omitempty" json:"weight,omitempty"`
+	Priority int        `yaml:"priority,omitempty" json:"priority,omitempty"`
+	When     []yamlName `yaml:"when,omitempty" json:"when,omitempty"`
+	Tags     []string   `yaml:"tags,omitempty" json:"tags,omitempty"`
+	Label    string     `yaml:"label,omitempty" json:"label,omitempty"`
+	Internal bool       `yaml:"internal,omitempty" json:"internal,omitempty"`
+	Disabled bool       `yaml:"disabled,omitempty" json:"disabled,omitempty"`
+	pos      yamlPos
+}
+
+// UnmarshalYAML decodes the transition remembering its position.
+func (t *yamlTransition) UnmarshalYAML(node *yaml.Node) error {
+	type plain yamlTransition
+	if err := node.Decode((*plain)(t)); err != nil {
+		return err
+	}
+	t.pos = positionOf(node)
+
+	return nil
+}
+
+// at describes the transition and the position within it for errors.
+func (t yamlTransition) at(pos yamlPos) string {
+	return locate(fmt.Sprintf("transition from '%s' to '%s' on event '%s'",
+		t.From, t.To, t.On), pos)
+}
+
+// yamlPos is a position in the YAML source.
+type yamlPos struct {
+	line   int
+	column int
+}
+
+// positionOf returns the position of the node.
+func positionOf(node *yaml.Node) yamlPos {
+	return yamlPos{line: node.Line, column: node.Column}
+}
+
+// String returns the position as "line L, column C".
+func (p yamlPos) String() string {
+	return fmt.Sprintf("line %d, column %d", p.line, p.column)
+}
+
+// locate returns the error suffix " (what, line L, column C)" omitting the
+// empty description and the unknown position (e.g. of a JSON source).
+func locate(what string, pos yamlPos) string {
+	var parts []string
+	if what != "" {
+		parts = append(parts, what)
+	}
+	if pos.line > 0 {
+		parts = append(parts, pos.String())
+	}
+
+	if len(parts) == 0 {
+		return ""
+	}
+
+	return " (" + strings.Join(parts, ", ") + ")"
+}
+
+// yamlName is a guard or action name (or a guard expression) remembering
+// its position, so errors could point at it.
+type yamlName struct {
+	value string
+	pos   yamlPos
+}
+
+// UnmarshalYAML decodes the name remembering its position.
+func (n *yamlName) UnmarshalYAML(node *yaml.Node) error {
+	n.pos = positionOf(node)
+
+	return node.Decode(&n.value)
+}
+
+// UnmarshalJSON decodes the name from a JSON string.
+func (n *yamlName) UnmarshalJSON(data []byte) error {
+	return json.Unmarshal(data, &n.value)
+}
+
+// MarshalYAML encodes the name as a plain string.
+func (n yamlName) MarshalYAML() (any, error) {
+	return n.value, nil
+}
+
+// Evaluator compiles guard expressions from the `when` key of YAML
+// transitions (e.g. "payload.amount > 1000") into guards.
+// The expression syntax is entirely defined by the implementation, so the
+// library doesn't depend on any expression language.
+type Evaluator interface {
+	Compile(expr string) (gonfa.Guard, error)
 }
 
 // LoadDefinition loads a definition from an io.Reader using a registry.
 // The format is expected to be YAML as described in the specification.
+// Any registry.Resolver can be used in place of a *registry.Registry, e.g.
+// registry.Chain.
+//
+// Guards and actions implementing gonfa.Initializable are initialized only
+// after the whole YAML is parsed, every name is resolved and the graph is
+// validated. If an Init fails, the objects initialized before it are closed
+// in reverse order and no Definition is returned.
 func LoadDefinition(
 	r io.Reader,
-	registry *registry.Registry,
+	registry registry.Resolver,
+) (*Definition, error) {
+	return loadDefinition(r, registryResolver{registry}, nil)
+}
+
+// LoadDefinitionWithEval works like LoadDefinition but also accepts guard
+// expressions listed under the `when` key of transitions:
+//
+//	transitions:
+//	  - from: Review
+//	    to: Escalated
+//	    on: Approve
+//	    when:
+//	      - payload.amount > 1000
+//
+// Every expression is compiled by eval into a guard which is appended after
+// the transition's registered guards.
+func LoadDefinitionWithEval(
+	r io.Reader,
+	registry registry.Resolver,
+	eval Evaluator,
+) (*Definition, error) {
+	if eval == nil {
+		return nil, fmt.Errorf("evaluator cannot be nil")
+	}
+
+	return loadDefinition(r, registryResolver{registry}, eval)
+}
+
+// LoadDefinitionNS works like LoadDefinition but resolves names in several
+// registries keyed by namespace. A qualified name like "billing:chargeCard"
+// is looked up as "chargeCard" in the "billing" registry, while an
+// unqualified name is looked up in the default registry stored under the
+// empty namespace "". Only the first separator splits the name.
+func LoadDefinitionNS(
+	r io.Reader,
+	registries map[string]registry.Resolver,
+) (*Definition, error) {
+	if len(registries) == 0 {
+		return nil, fmt.Errorf("at least one registry is required")
+	}
+
+	return loadDefinition(r, nsResolver(registries), nil)
+}
+
+// loadDefinition loads a definition from YAML resolving names with res.
+// Guard expressions are compiled with eval; if eval is nil, expressions are
+// rejected.
+func loadDefinition(
+	r io.Reader,
+	res nameResolver,
+	eval Evaluator,
 ) (*Definition, error) {
 	data, err := io.ReadAll(r)
 	if err != nil {
@@ -68,6 +248,75 @@ func LoadDefinition(
 		return nil, fmt.Errorf("failed to parse YAML: %w", err)
 	}
 
+	return buildDefinition(&yamlDef, res, eval)
+}
+
+// LoadDefinitionJSON works like LoadDefinition but reads the definition
+// from JSON with the same structure and keys as the YAML format:
+//
+//	{
+//	  "initialState": "Draft",
+//	  "finalStates": ["Approved"],
+//	  "transitions": [
+//	    {"from": "Draft", "to": "Approved", "on": "Approve", "guards": ["isManager"]}
+//	  ]
+//	}
+//
+// Errors are the same as LoadDefinition's, except that JSON sources have no
+// line and column information.
+func LoadDefinitionJSON(
+	r io.Reader,
+	registry registry.Resolver,
+) (*Definition, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read JSON data: %w", err)
+	}
+
+	var jsonDef yamlDefinition
+	if err := json.Unmarshal(data, &jsonDef); err != nil {
+		return nil, fmt.Errorf("failed to parse JSON: %w", err)
+	}
+
+	return buildDefinition(&jsonDef, registryResolver{registry}, nil)
+}
+
+// LoadDefinitionAuto loads a definition detecting its format: a source
+// whose first non-whitespace byte is '{' or '[' is read as JSON (see
+// LoadDefinitionJSON), anything else as YAML (see LoadDefinition). Errors
+// name the format the source was read as.
+func LoadDefinitionAuto(
+	r io.Reader,
+	registry registry.Resolver,
+) (*Definition, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read definition data: %w", err)
+	}
+
+	load, format := LoadDefinition, "YAML"
+	if trimmed := bytes.TrimLeft(data, " \t\r\n"); len(trimmed) > 0 &&
+		(trimmed[0] == '{' || trimmed[0] == '[') {
+		load, format = LoadDefinitionJSON, "JSON"
+	}
+
+	def, err := load(bytes.NewReader(data), registry)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load definition as %s: %w",
+			format, err)
+	}
+
+	return def, nil
+}
+
+// buildDefinition converts the parsed YAML (or JSON) structure into a
+// definition resolving names with res. Guard expressions are compiled with
+// eval; if eval is nil, expressions are rejected.
+func buildDefinition(
+	yamlDef *yamlDefinition,
+	res nameResolver,
+	eval Evaluator,
+) (*Definition, error) {
 	// Validate required fields
 	if yamlDef.InitialState == "" {
 		return nil, fmt.Errorf("initialState is required")
@@ -76,27 +325,34 @@ func LoadDefinition(
 		return nil, fmt.Errorf("at least one transition is required")
 	}
 
+	// Names of the resolved guards and actions, see RequiredNames
+	var guardNames, actionNames []string
+
 	// Convert YAML structure to internal types
 	states := make(map[gonfa.State]StateConfig)
 	for stateName, stateConfig := range yamlDef.States {
 		config := StateConfig{}
 
 		// Convert OnEntry actions
-		for _, actionName := range stateConfig.OnEntry {
-			action, exists := registry.GetAction(actionName)
+		for _, name := range stateConfig.OnEntry {
+			action, where, exists := res.action(name.value)
+			actionNames = append(actionNames, name.value)
 			if !exists {
 				return nil, fmt.Errorf(
-					"action '%s' not found in registry", actionName)
+					"action '%s' not found in %s%s", name.value, where,
+					locate(fmt.Sprintf("state '%s' onEntry", stateName), name.pos))
 			}
 			config.OnEntry = append(config.OnEntry, action)
 		}
 
 		// Convert OnExit actions
-		for _, actionName := range stateConfig.OnExit {
-			action, exists := registry.GetAction(actionName)
+		for _, name := range stateConfig.OnExit {
+			action, where, exists := res.action(name.value)
+			actionNames = append(actionNames, name.value)
 			if !exists {
 				return nil, fmt.Errorf(
-					"action '%s' not found in registry", actionName)
+					"action '%s' not found in %s%s", name.value, where,
+					locate(fmt.Sprintf("state '%s' onExit", stateName), name.pos))
 			}
 			config.OnExit = append(config.OnExit, action)
 		}
@@ -108,29 +364,63 @@ func LoadDefinition(
 	var transitions []Transition
 	for _, yamlTrans := range yamlDef.Transitions {
 		transition := Transition{
-			From: gonfa.State(yamlTrans.From),
-			To:   gonfa.State(yamlTrans.To),
-			On:   gonfa.Event(yamlTrans.On),
+			From:     gonfa.State(yamlTrans.From),
+			To:       gonfa.State(yamlTrans.To),
+			On:       gonfa.Event(yamlTrans.On),
+			Priority: yamlTrans.Priority,
+			Tags:     yamlTrans.Tags,
+			Label:    yamlTrans.Label,
+			Internal: yamlTrans.Internal,
+			Disabled: yamlTrans.Disabled,
+			Name: fmt.Sprintf("%s->%s on %s",
+				yamlTrans.From, yamlTrans.To, yamlTrans.On),
+		}
+
+		if yamlTrans.Weight != nil {
+			transition.Weight = *yamlTrans.Weight
 		}
 
 		// Convert guards
-		for _, guardName := range yamlTrans.Guards {
-			guard, exists := registry.GetGuard(guardName)
+		for _, name := range yamlTrans.Guards {
+			guard, where, exists := res.guard(name.value)
+			guardNames = append(guardNames, name.value)
 			if !exists {
+				return nil, fmt.Errorf("guard '%s' not found in %s%s",
+					name.value, where, yamlTrans.at(name.pos))
+			}
+			transition.Guards = append(transition.Guards, guard)
+			transition.GuardNames = append(transition.GuardNames, name.value)
+		}
+
+		// Compile guard expressions
+		for _, expr := range yamlTrans.When {
+			if eval == nil {
+				return nil, fmt.Errorf(
+					"guard expression '%s'%s requires an evaluator "+
+						"(use LoadDefinitionWithEval)",
+					expr.value, yamlTrans.at(expr.pos))
+			}
+
+			guard, err := eval.Compile(expr.value)
+			if err != nil {
 				return nil, fmt.Errorf(
-					"guard '%s' not found in registry", guardName)
+					"failed to compile guard expression '%s'%s: %w",
+					expr.value, yamlTrans.at(expr.pos), err)
 			}
 			transition.Guards = append(transition.Guards, guard)
+			transition.GuardNames = append(transition.GuardNames, expr.value)
 		}
 
 		// Convert actions
-		for _, actionName := range yamlTrans.Actions {
-			action, exists := registry.GetAction(actionName)
+		for _, name := range yamlTrans.Actions {
+			action, where, exists := res.action(name.value)
+			actionNames = append(actionNames, name.value)
 			if !exists {
-				return nil, fmt.Errorf(
-					"action '%s' not found in registry", actionName)
+				return nil, fmt.Errorf("action '%s' not found in %s%s",
+					name.value, where, yamlTrans.at(name.pos))
 			}
 			transition.Actions = append(transition.Actions, action)
+			transition.ActionNames = append(transition.ActionNames, name.value)
 		}
 
 		transitions = append(transitions, transition)
@@ -138,36 +428,364 @@ func LoadDefinition(
 
 	// Convert hooks
 	hooks := Hooks{}
-	for _, actionName := range yamlDef.Hooks.OnSuccess {
-		action, exists := registry.GetAction(actionName)
+	for _, name := range yamlDef.Hooks.OnSuccess {
+		action, where, exists := res.action(name.value)
+		actionNames = append(actionNames, name.value)
 		if !exists {
 			return nil, fmt.Errorf(
-				"success hook action '%s' not found in registry", actionName)
+				"success hook action '%s' not found in %s%s",
+				name.value, where, locate("", name.pos))
 		}
 		hooks.OnSuccess = append(hooks.OnSuccess, action)
 	}
 
-	for _, actionName := range yamlDef.Hooks.OnFailure {
-		action, exists := registry.GetAction(actionName)
+	for _, name := range yamlDef.Hooks.OnFailure {
+		action, where, exists := res.action(name.value)
+		actionNames = append(actionNames, name.value)
 		if !exists {
 			return nil, fmt.Errorf(
-				"failure hook action '%s' not found in registry", actionName)
+				"failure hook action '%s' not found in %s%s",
+				name.value, where, locate("", name.pos))
 		}
 		hooks.OnFailure = append(hooks.OnFailure, action)
 	}
 
+	for event, names := range yamlDef.OnEvent {
+		for _, name := range names {
+			action, where, exists := res.action(name.value)
+			actionNames = append(actionNames, name.value)
+			if !exists {
+				return nil, fmt.Errorf(
+					"event '%s' action '%s' not found in %s%s",
+					event, name.value, where, locate("", name.pos))
+			}
+
+			if hooks.OnEvent == nil {
+				hooks.OnEvent = make(map[gonfa.Event][]gonfa.Action)
+			}
+			hooks.OnEvent[gonfa.Event(event)] = append(
+				hooks.OnEvent[gonfa.Event(event)], action)
+		}
+	}
+
 	// Convert final states
 	var finalStates []gonfa.State
 	for _, stateName := range yamlDef.FinalStates {
 		finalStates = append(finalStates, gonfa.State(stateName))
 	}
 
+	// Declare the event vocabulary
+	opts := []Option{withNames(guardNames, actionNames)}
+	if yamlDef.Events != nil {
+		events := make([]gonfa.Event, len(yamlDef.Events))
+		for i, e := range yamlDef.Events {
+			events[i] = gonfa.Event(e)
+		}
+		opts = append(opts, DeclareEvents(events...))
+	}
+
+	if err := checkYAMLTransitions(yamlDef); err != nil {
+		return nil, err
+	}
+
 	// Create and return the definition
-	return New(
+	def, err := New(
 		gonfa.State(yamlDef.InitialState),
 		finalStates,
 		states,
 		transitions,
 		hooks,
+		opts...,
 	)
+	if err != nil {
+		// Point at the state or transition the check complains about
+		var (
+			se *stateError
+			te *transitionError
+		)
+		switch {
+		case errors.As(err, &se):
+			return nil, fmt.Errorf("%w%s", err,
+				locate("", yamlDef.statePos[string(se.state)]))
+
+		case errors.As(err, &te):
+			return nil, fmt.Errorf("%w%s", err,
+				locate("", yamlDef.transitionPos(te.key)))
+		}
+
+		return nil, err
+	}
+
+	return def, nil
+}
+
+// transitionPos returns the position of the transition with the key.
+func (d *yamlDefinition) transitionPos(key transitionKey) yamlPos {
+	for _, t := range d.Transitions {
+		if t.From == string(key.from) && t.To == string(key.to) &&
+			t.On == string(key.on) {
+			return t.pos
+		}
+	}
+
+	return yamlPos{}
+}
+
+// checkYAMLTransitions checks that the transitions connect declared states
+// and aren't duplicated, as New does, but reports the transition's
+// position.
+func checkYAMLTransitions(yamlDef *yamlDefinition) error {
+	seen := make(map[transitionKey]struct{})
+
+	for _, t := range yamlDef.Transitions {
+		if _, ok := yamlDef.States[t.From]; !ok {
+			return fmt.Errorf("state '%s' doesn't exist as transition "+
+				"source%s", t.From, t.at(t.pos))
+		}
+
+		if _, ok := yamlDef.States[t.To]; !ok {
+			return fmt.Errorf("state '%s' doesn't exist as transition "+
+				"target%s", t.To, t.at(t.pos))
+		}
+
+		key := transitionKey{
+			from: gonfa.State(t.From),
+			to:   gonfa.State(t.To),
+			on:   gonfa.Event(t.On),
+		}
+		if _, exists := seen[key]; exists {
+			return fmt.Errorf(
+				"duplicate transition from '%s' to '%s' on event '%s'%s",
+				t.From, t.To, t.On, locate("", t.pos))
+		}
+		seen[key] = struct{}{}
+	}
+
+	return nil
+}
+
+// SaveDefinition writes the definition to w as YAML in the format read by
+// LoadDefinition, so loading the output with the same registry produces an
+// equivalent definition. Guards and actions are written under the names
+// they are registered with in reg. An unregistered guard having a name in
+// Transition.GuardNames, like the ones compiled by LoadDefinitionWithEval,
+// is written as a guard expression under the `when` key, so the output has
+// to be loaded with LoadDefinitionWithEval. Since expressions are loaded
+// after the registered guards, a registered guard can't follow them.
+//
+// It fails if a guard or action isn't registered (the error names the
+// transition, state or hook using it) or if the definition uses features
+// the YAML format can't express: preconditions, after actions,
+// compensations, async actions, conditional final states, error states,
+// guarded state actions and RequireAllEventsReachable.
+func SaveDefinition(
+	w io.Writer,
+	def *Definition,
+	reg *registry.Registry,
+) error {
+	if def == nil {
+		return fmt.Errorf("definition cannot be nil")
+	}
+
+	if reg == nil {
+		return fmt.Errorf("registry cannot be nil")
+	}
+
+	yamlDef, err := toYAML(def, reg)
+	if err != nil {
+		return err
+	}
+
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+
+	if err := enc.Encode(yamlDef); err != nil {
+		return fmt.Errorf("failed to write YAML: %w", err)
+	}
+
+	return enc.Close()
+}
+
+// toYAML converts the definition into its YAML structure.
+func toYAML(d *Definition, reg *registry.Registry) (*yamlDefinition, error) {
+	if d.requireAllEvents {
+		return nil, fmt.Errorf(
+			"RequireAllEventsReachable can't be expressed in YAML")
+	}
+
+	if d.contextValues != nil {
+		return nil, fmt.Errorf("context values can't be expressed in YAML")
+	}
+
+	actions := func(aa []gonfa.Action, where string) ([]yamlName, error) {
+		names := make([]yamlName, 0, len(aa))
+		for _, a := range aa {
+			name, ok := reg.NameOfAction(a)
+			if !ok {
+				return nil, fmt.Errorf(
+					"action %T of %s isn't registered", a, where)
+			}
+			names = append(names, yamlName{value: name})
+		}
+
+		return names, nil
+	}
+
+	yamlDef := &yamlDefinition{
+		InitialState: string(d.initialState),
+		FinalStates:  stateNames(d.finalStates),
+	}
+
+	if d.eventsDeclared {
+		yamlDef.Events = make([]string, len(d.declaredEvents))
+		for i, e := range d.declaredEvents {
+			yamlDef.Events[i] = string(e)
+		}
+	}
+
+	for s, cfg := range d.states {
+		switch {
+		case len(cfg.AcceptIf) > 0:
+			return nil, fmt.Errorf(
+				"conditional final state '%s' can't be expressed in YAML", s)
+		case cfg.ErrorState != "":
+			return nil, fmt.Errorf(
+				"error state of state '%s' can't be expressed in YAML", s)
+		}
+
+		var (
+			yamlCfg yamlStateConfig
+			err     error
+		)
+		if yamlCfg.OnEntry, err = actions(cfg.OnEntry,
+			fmt.Sprintf("state '%s' onEntry", s)); err != nil {
+			return nil, err
+		}
+		if yamlCfg.OnExit, err = actions(cfg.OnExit,
+			fmt.Sprintf("state '%s' onExit", s)); err != nil {
+			return nil, err
+		}
+
+		if yamlDef.States == nil {
+			yamlDef.States = make(map[string]yamlStateConfig)
+		}
+		yamlDef.States[string(s)] = yamlCfg
+	}
+
+	for _, t := range d.transitions {
+		where := fmt.Sprintf("transition from '%s' to '%s' on event '%s'",
+			t.From, t.To, t.On)
+
+		switch {
+		case len(t.Preconditions) > 0:
+			return nil, fmt.Errorf(
+				"preconditions of %s can't be expressed in YAML", where)
+		case len(t.AfterActions) > 0:
+			return nil, fmt.Errorf(
+				"after actions of %s can't be expressed in YAML", where)
+		case len(t.Compensations) > 0:
+			return nil, fmt.Errorf(
+				"compensations of %s can't be expressed in YAML", where)
+		case len(t.AsyncActions) > 0:
+			return nil, fmt.Errorf(
+				"async actions of %s can't be expressed in YAML", where)
+		}
+
+		yt := yamlTransition{
+			From:     string(t.From),
+			To:       string(t.To),
+			On:       string(t.On),
+			Priority: t.Priority,
+			Tags:     t.Tags,
+			Label:    t.Label,
+			Internal: t.Internal,
+			Disabled: t.Disabled,
+		}
+
+		if t.Weight != 0 {
+			weight := t.Weight
+			yt.Weight = &weight
+		}
+
+		for i, g := range t.Guards {
+			if name, ok := reg.NameOf(g); ok {
+				if len(yt.When) > 0 {
+					return nil, fmt.Errorf(
+						"guard '%s' of %s follows a guard expression",
+						name, where)
+				}
+				yt.Guards = append(yt.Guards, yamlName{value: name})
+
+				continue
+			}
+
+			// Guards compiled by LoadDefinitionWithEval aren't registered,
+			// but keep their expressions as names.
+			if i < len(t.GuardNames) && t.GuardNames[i] != "" {
+				yt.When = append(yt.When, yamlName{value: t.GuardNames[i]})
+				continue
+			}
+
+			return nil, fmt.Errorf(
+				"guard %T of %s isn't registered", g, where)
+		}
+
+		var err error
+		if yt.Actions, err = actions(t.Actions, where); err != nil {
+			return nil, err
+		}
+
+		yamlDef.Transitions = append(yamlDef.Transitions, yt)
+	}
+
+	var err error
+	if yamlDef.Hooks.OnSuccess, err = actions(d.hooks.OnSuccess,
+		"success hooks"); err != nil {
+		return nil, err
+	}
+	if yamlDef.Hooks.OnFailure, err = actions(d.hooks.OnFailure,
+		"failure hooks"); err != nil {
+		return nil, err
+	}
+
+	for e, aa := range d.hooks.OnEvent {
+		if len(aa) == 0 {
+			continue
+		}
+
+		names, err := actions(aa, fmt.Sprintf("event '%s' actions", e))
+		if err != nil {
+			return nil, err
+		}
+
+		if yamlDef.OnEvent == nil {
+			yamlDef.OnEvent = make(map[string][]yamlName)
+		}
+		yamlDef.OnEvent[string(e)] = names
+	}
+
+	return yamlDef, nil
+}
+
+// LoadFS loads a definition from the YAML file at path within fsys.
+// It's intended for definitions compiled into the binary with embed.FS,
+// but works with any fs.FS implementation.
+func LoadFS(
+	fsys fs.FS,
+	path string,
+	registry registry.Resolver,
+) (*Definition, error) {
+	f, err := fsys.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open definition file: %w", err)
+	}
+	defer f.Close()
+
+	def, err := LoadDefinition(f, registry)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load definition from '%s': %w",
+			path, err)
+	}
+
+	return def, nil
 }
@@ -1,9 +1,15 @@
 package definition
 
 import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
 	"strings"
 	"sync"
 	"testing"
+	"testing/fstest"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -333,3 +339,1039 @@ transitions:
 	assert.Len(t, transitions[1].Guards, 1)
 	assert.Len(t, transitions[1].Actions, 1)
 }
+
+func TestLoadDefinitionWithWeights(t *testing.T) {
+	const header = `
+initialState: Start
+finalStates:
+  - Fast
+  - Slow
+states:
+  Start: {}
+  Fast: {}
+  Slow: {}
+transitions:
+`
+
+	t.Run("weights are loaded", func(t *testing.T) {
+		yamlData := header + `
+  - from: Start
+    to: Fast
+    on: Go
+    weight: 3
+  - from: Start
+    to: Slow
+    on: Go
+    weight: 0.5
+`
+		def, err := LoadDefinition(strings.NewReader(yamlData), getTestRegistry())
+		require.NoError(t, err)
+
+		transitions := def.GetTransitions("Start", "Go")
+		require.Len(t, transitions, 2)
+		assert.Equal(t, 3.0, transitions[0].Weight)
+		assert.Equal(t, 0.5, transitions[1].Weight)
+	})
+
+	t.Run("weight is optional", func(t *testing.T) {
+		yamlData := header + `
+  - from: Start
+    to: Fast
+    on: Go
+    weight: 1
+  - from: Start
+    to: Slow
+    on: Walk
+`
+		def, err := LoadDefinition(strings.NewReader(yamlData), getTestRegistry())
+		require.NoError(t, err)
+		assert.Equal(t, 0.0, def.GetTransitions("Start", "Walk")[0].Weight)
+	})
+
+	t.Run("negative weight", func(t *testing.T) {
+		yamlData := header + `
+  - from: Start
+    to: Fast
+    on: Go
+    weight: -1
+  - from: Start
+    to: Slow
+    on: Go
+    weight: 2
+`
+		_, err := LoadDefinition(strings.NewReader(yamlData), getTestRegistry())
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "negative weight")
+	})
+
+	t.Run("zero weights are unweighted", func(t *testing.T) {
+		yamlData := header + `
+  - from: Start
+    to: Fast
+    on: Go
+    weight: 0
+  - from: Start
+    to: Slow
+    on: Go
+    weight: 0
+`
+		_, err := LoadDefinition(strings.NewReader(yamlData), getTestRegistry())
+		require.NoError(t, err)
+	})
+
+	t.Run("zero weight in weighted group", func(t *testing.T) {
+		yamlData := header + `
+  - from: Start
+    to: Fast
+    on: Go
+    weight: 0
+  - from: Start
+    to: Slow
+    on: Go
+    weight: 2
+`
+		_, err := LoadDefinition(strings.NewReader(yamlData), getTestRegistry())
+		require.Error(t, err)
+		assert.Contains(t, err.Error(),
+			"transition from 'Start' to 'Fast' on event 'Go' has no positive "+
+				"weight but competing transitions are weighted")
+	})
+}
+
+func TestLoadFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"workflows/simple.yaml": &fstest.MapFile{Data: []byte(`
+initialState: Start
+finalStates: [End]
+states:
+  Start: {}
+  End: {}
+transitions:
+  - from: Start
+    to: End
+    on: Finish
+    actions: [action1]
+`)},
+		"workflows/broken.yaml": &fstest.MapFile{Data: []byte(`
+initialState: Start
+transitions: []
+`)},
+	}
+
+	t.Run("successful load", func(t *testing.T) {
+		def, err := LoadFS(fsys, "workflows/simple.yaml", getTestRegistry())
+		require.NoError(t, err)
+		assert.Equal(t, gonfa.State("Start"), def.InitialState())
+		assert.Len(t, def.Transitions(), 1)
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		_, err := LoadFS(fsys, "workflows/missing.yaml", getTestRegistry())
+		require.Error(t, err)
+		assert.ErrorIs(t, err, fs.ErrNotExist)
+	})
+
+	t.Run("invalid definition", func(t *testing.T) {
+		_, err := LoadFS(fsys, "workflows/broken.yaml", getTestRegistry())
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "workflows/broken.yaml")
+		assert.Contains(t, err.Error(), "at least one transition is required")
+	})
+}
+
+// prefixEvaluator compiles "allow" and "deny" expressions.
+type prefixEvaluator struct {
+	compiled []string
+}
+
+func (e *prefixEvaluator) Compile(expr string) (gonfa.Guard, error) {
+	e.compiled = append(e.compiled, expr)
+
+	switch expr {
+	case "allow":
+		return &testGuard{result: true}, nil
+	case "deny":
+		return &testGuard{result: false}, nil
+	}
+
+	return nil, errors.New("unknown expression")
+}
+
+func TestLoadDefinitionWithEval(t *testing.T) {
+	const yamlTemplate = `
+initialState: Start
+finalStates: [End]
+states:
+  Start: {}
+  End: {}
+transitions:
+  - from: Start
+    to: End
+    on: Finish
+    guards: [guard1]
+    when:
+      - %s
+`
+
+	t.Run("expressions become guards", func(t *testing.T) {
+		eval := &prefixEvaluator{}
+		def, err := LoadDefinitionWithEval(
+			strings.NewReader(fmt.Sprintf(yamlTemplate, "deny")),
+			getTestRegistry(), eval)
+		require.NoError(t, err)
+
+		guards := def.Transitions()[0].Guards
+		require.Len(t, guards, 2)
+		assert.False(t, guards[1].Check(context.Background(), nil, nil))
+		assert.Equal(t, []string{"deny"}, eval.compiled)
+	})
+
+	t.Run("compile error", func(t *testing.T) {
+		_, err := LoadDefinitionWithEval(
+			strings.NewReader(fmt.Sprintf(yamlTemplate, "amount > 1")),
+			getTestRegistry(), &prefixEvaluator{})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(),
+			"failed to compile guard expression 'amount > 1'")
+	})
+
+	t.Run("expressions without evaluator", func(t *testing.T) {
+		_, err := LoadDefinition(
+			strings.NewReader(fmt.Sprintf(yamlTemplate, "allow")),
+			getTestRegistry())
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "requires an evaluator")
+	})
+
+	t.Run("nil evaluator", func(t *testing.T) {
+		_, err := LoadDefinitionWithEval(
+			strings.NewReader(""), getTestRegistry(), nil)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "evaluator cannot be nil")
+	})
+}
+
+func TestLoadDefinitionWithEventActions(t *testing.T) {
+	yamlData := `
+initialState: Start
+finalStates: [End]
+states:
+  Start: {}
+  End: {}
+onEvent:
+  Finish:
+    - action1
+    - action2
+transitions:
+  - from: Start
+    to: End
+    on: Finish
+`
+
+	t.Run("event actions are loaded", func(t *testing.T) {
+		def, err := LoadDefinition(strings.NewReader(yamlData), getTestRegistry())
+		require.NoError(t, err)
+
+		assert.Len(t, def.EventActions("Finish"), 2)
+		assert.Empty(t, def.EventActions("Start"))
+		assert.Len(t, def.Hooks().OnEvent, 1)
+	})
+
+	t.Run("unknown action", func(t *testing.T) {
+		_, err := LoadDefinition(
+			strings.NewReader(strings.Replace(yamlData, "action2", "missing", 1)),
+			getTestRegistry())
+		require.Error(t, err)
+		assert.Contains(t, err.Error(),
+			"event 'Finish' action 'missing' not found in registry")
+	})
+}
+
+func TestLoadDefinitionWithTags(t *testing.T) {
+	yamlData := `
+initialState: Start
+finalStates: [End]
+states:
+  Start: {}
+  End: {}
+transitions:
+  - from: Start
+    to: End
+    on: Finish
+    tags: [requires-approval, audited]
+`
+
+	def, err := LoadDefinition(strings.NewReader(yamlData), getTestRegistry())
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"requires-approval", "audited"},
+		def.Transitions()[0].Tags)
+	assert.Len(t, def.TransitionsWithTag("audited"), 1)
+}
+
+func TestLoadDefinitionWithLabel(t *testing.T) {
+	yamlData := `
+initialState: Start
+finalStates: [End]
+states:
+  Start: {}
+  End: {}
+transitions:
+  - from: Start
+    to: End
+    on: Finish
+    label: Finished by user
+`
+
+	def, err := LoadDefinition(strings.NewReader(yamlData), getTestRegistry())
+	require.NoError(t, err)
+
+	assert.Equal(t, "Finished by user", def.Transitions()[0].Label)
+}
+
+func TestLoadDefinitionTransitionNames(t *testing.T) {
+	yamlData := `
+initialState: Start
+finalStates: [End]
+states:
+  Start: {}
+  End: {}
+transitions:
+  - from: Start
+    to: End
+    on: Finish
+    guards: [guard1]
+    when: [allow]
+    actions: [action1, action2]
+`
+
+	def, err := LoadDefinitionWithEval(strings.NewReader(yamlData),
+		getTestRegistry(), &prefixEvaluator{})
+	require.NoError(t, err)
+
+	tr := def.Transitions()[0]
+	assert.Equal(t, "Start->End on Finish", tr.Name)
+	assert.Equal(t, []string{"guard1", "allow"}, tr.GuardNames)
+	assert.Equal(t, []string{"action1", "action2"}, tr.ActionNames)
+
+	t.Run("names must match guards and actions", func(t *testing.T) {
+		states := map[gonfa.State]StateConfig{"A": {}, "B": {}}
+
+		_, err := New("A", []gonfa.State{"B"}, states, []Transition{{
+			From: "A", To: "B", On: "Go",
+			Guards:     []gonfa.Guard{tr.Guards[0]},
+			GuardNames: []string{"guard1", "guard2"},
+		}}, Hooks{})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "has 2 guard names for 1 guards")
+
+		_, err = New("A", []gonfa.State{"B"}, states, []Transition{{
+			From: "A", To: "B", On: "Go",
+			ActionNames: []string{"action1"},
+		}}, Hooks{})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "has 1 action names for 0 actions")
+	})
+}
+
+func TestLoadDefinitionInternalTransition(t *testing.T) {
+	yamlData := `
+initialState: Start
+finalStates: [End]
+states:
+  Start:
+    onEntry: [action1]
+  End: {}
+transitions:
+  - from: Start
+    to: Start
+    on: Touch
+    internal: true
+  - from: Start
+    to: End
+    on: Finish
+    internal: true
+`
+
+	def, err := LoadDefinition(strings.NewReader(yamlData), getTestRegistry())
+	require.NoError(t, err)
+
+	touch := def.GetTransitions("Start", "Touch")[0]
+	assert.True(t, touch.Internal)
+	assert.True(t, touch.IsInternal())
+	assert.True(t, touch.Trivial())
+
+	finish := def.GetTransitions("Start", "Finish")[0]
+	assert.True(t, finish.Internal)
+	assert.False(t, finish.IsInternal())
+}
+
+func TestLoadDefinitionPriority(t *testing.T) {
+	yamlData := `
+initialState: Review
+finalStates: [Approved, Expedited, Escalated]
+states:
+  Review: {}
+  Approved: {}
+  Expedited: {}
+  Escalated: {}
+transitions:
+  - from: Review
+    to: Approved
+    on: Approve
+  - from: Review
+    to: Escalated
+    on: Approve
+    priority: -1
+  - from: Review
+    to: Expedited
+    on: Approve
+    priority: 5
+`
+
+	def, err := LoadDefinition(strings.NewReader(yamlData), getTestRegistry())
+	require.NoError(t, err)
+
+	transitions := def.GetTransitions("Review", "Approve")
+	require.Len(t, transitions, 3)
+
+	for i, to := range []gonfa.State{"Expedited", "Approved", "Escalated"} {
+		assert.Equal(t, to, transitions[i].To)
+		assert.Equal(t, i, transitions[i].Index())
+	}
+	assert.Equal(t, 5, transitions[0].Priority)
+
+	// the declaration order is kept
+	assert.Equal(t, gonfa.State("Approved"), def.Transitions()[0].To)
+}
+
+func TestLoadDefinitionDisabledTransition(t *testing.T) {
+	yamlData := `
+initialState: Start
+finalStates: [End]
+states:
+  Start: {}
+  End: {}
+transitions:
+  - from: Start
+    to: End
+    on: Finish
+  - from: Start
+    to: End
+    on: Skip
+    disabled: true
+`
+
+	def, err := LoadDefinition(strings.NewReader(yamlData), getTestRegistry())
+	require.NoError(t, err)
+
+	transitions := def.Transitions()
+	require.Len(t, transitions, 2)
+	assert.False(t, transitions[0].Disabled)
+	assert.True(t, transitions[1].Disabled)
+}
+
+func TestLoadDefinitionEvents(t *testing.T) {
+	yamlData := `
+initialState: Start
+finalStates: [End]
+events: [Finish, Cancel]
+states:
+  Start: {}
+  End: {}
+transitions:
+  - from: Start
+    to: End
+    on: Finish
+`
+
+	def, err := LoadDefinition(strings.NewReader(yamlData), getTestRegistry())
+	require.NoError(t, err)
+
+	events, ok := def.DeclaredEvents()
+	assert.True(t, ok)
+	assert.Equal(t, []gonfa.Event{"Cancel", "Finish"}, events)
+}
+
+func TestLoadDefinitionNS(t *testing.T) {
+	billing := registry.New()
+	charge := &testAction{name: "chargeCard"}
+	require.NoError(t, billing.RegisterAction("chargeCard", charge))
+	require.NoError(t, billing.RegisterGuard("hasFunds", &testGuard{result: true}))
+
+	registries := map[string]registry.Resolver{
+		"":        getTestRegistry(),
+		"billing": billing,
+	}
+
+	yamlData := `
+initialState: Start
+finalStates: [End]
+states:
+  Start: {}
+  End:
+    onEntry: [action1]
+transitions:
+  - from: Start
+    to: End
+    on: Pay
+    guards: [billing:hasFunds]
+    actions: [billing:chargeCard, action2]
+`
+
+	t.Run("qualified and unqualified names", func(t *testing.T) {
+		def, err := LoadDefinitionNS(strings.NewReader(yamlData), registries)
+		require.NoError(t, err)
+
+		tr := def.Transitions()[0]
+		require.Len(t, tr.Actions, 2)
+		assert.Same(t, charge, tr.Actions[0])
+		assert.Len(t, tr.Guards, 1)
+		assert.Len(t, def.GetStateConfig("End").OnEntry, 1)
+	})
+
+	t.Run("name missing in namespace", func(t *testing.T) {
+		_, err := LoadDefinitionNS(
+			strings.NewReader(strings.Replace(yamlData,
+				"billing:chargeCard", "billing:refund", 1)),
+			registries)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(),
+			"action 'billing:refund' not found in registry namespace 'billing'")
+	})
+
+	t.Run("unknown namespace", func(t *testing.T) {
+		_, err := LoadDefinitionNS(
+			strings.NewReader(strings.Replace(yamlData,
+				"billing:hasFunds", "auth:isAdmin", 1)),
+			registries)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(),
+			"guard 'auth:isAdmin' not found in registry namespace 'auth' "+
+				"(not configured)")
+	})
+
+	t.Run("no default registry", func(t *testing.T) {
+		_, err := LoadDefinitionNS(strings.NewReader(yamlData),
+			map[string]registry.Resolver{"billing": billing})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(),
+			"not found in default registry (not configured)")
+	})
+
+	t.Run("no registries", func(t *testing.T) {
+		_, err := LoadDefinitionNS(strings.NewReader(yamlData), nil)
+		assert.Error(t, err)
+	})
+}
+
+func TestLoadDefinitionWithChain(t *testing.T) {
+	override := registry.New()
+	custom := &testAction{name: "custom"}
+	require.NoError(t, override.RegisterAction("action1", custom))
+
+	yamlData := `
+initialState: Start
+finalStates: [End]
+states:
+  Start: {}
+  End: {}
+transitions:
+  - from: Start
+    to: End
+    on: Finish
+    guards: [guard1]
+    actions: [action1, action2]
+`
+
+	def, err := LoadDefinition(strings.NewReader(yamlData),
+		registry.Chain(override, getTestRegistry()))
+	require.NoError(t, err)
+
+	tr := def.Transitions()[0]
+	require.Len(t, tr.Actions, 2)
+	assert.Same(t, custom, tr.Actions[0])
+	assert.Len(t, tr.Guards, 1)
+}
+
+func TestLoadDefinitionErrorPositions(t *testing.T) {
+	reg := getTestRegistry()
+
+	tests := []struct {
+		name   string
+		yaml   string
+		errMsg string
+	}{
+		{
+			name: "transition guard",
+			yaml: `initialState: Start
+transitions:
+  - from: Start
+    to: End
+    on: Event1
+    guards:
+      - nonExistentGuard
+`,
+			errMsg: "guard 'nonExistentGuard' not found in registry " +
+				"(transition from 'Start' to 'End' on event 'Event1', " +
+				"line 7, column 9)",
+		},
+		{
+			name: "transition action",
+			yaml: `initialState: Start
+transitions:
+  - from: Start
+    to: End
+    on: Event1
+    actions: [nonExistentAction]
+`,
+			errMsg: "action 'nonExistentAction' not found in registry " +
+				"(transition from 'Start' to 'End' on event 'Event1', " +
+				"line 6, column 15)",
+		},
+		{
+			name: "state action",
+			yaml: `initialState: Start
+states:
+  End:
+    onEntry:
+      - nonExistentAction
+transitions:
+  - from: Start
+    to: End
+    on: Event1
+`,
+			errMsg: "action 'nonExistentAction' not found in registry " +
+				"(state 'End' onEntry, line 5, column 9)",
+		},
+		{
+			name: "hook",
+			yaml: `initialState: Start
+hooks:
+  onFailure:
+    - nonExistentAction
+transitions:
+  - from: Start
+    to: End
+    on: Event1
+`,
+			errMsg: "failure hook action 'nonExistentAction' not found in " +
+				"registry (line 4, column 7)",
+		},
+		{
+			name: "unknown transition target",
+			yaml: `initialState: Start
+states:
+  Start: {}
+  End: {}
+transitions:
+  - from: Start
+    to: Nowhere
+    on: Event1
+`,
+			errMsg: "state 'Nowhere' doesn't exist as transition target " +
+				"(transition from 'Start' to 'Nowhere' on event 'Event1', " +
+				"line 6, column 5)",
+		},
+		{
+			name: "duplicate transition",
+			yaml: `initialState: Start
+finalStates: [End]
+states:
+  Start: {}
+  End: {}
+transitions:
+  - from: Start
+    to: End
+    on: Event1
+  - from: Start
+    to: End
+    on: Event1
+`,
+			errMsg: "duplicate transition from 'Start' to 'End' on event " +
+				"'Event1' (line 10, column 5)",
+		},
+		{
+			name: "dead-end state",
+			yaml: `initialState: Start
+finalStates: [End]
+states:
+  Start: {}
+  Stuck: {}
+  End: {}
+transitions:
+  - from: Start
+    to: End
+    on: Event1
+  - from: Start
+    to: Stuck
+    on: Event2
+`,
+			errMsg: "states check failed: state 'Stuck' is a dead-end state " +
+				"(line 5, column 3)",
+		},
+		{
+			name: "negative weight",
+			yaml: `initialState: Start
+finalStates: [End]
+states:
+  Start: {}
+  End: {}
+transitions:
+  - from: Start
+    to: End
+    on: Event1
+    weight: -1
+`,
+			errMsg: "states check failed: transition from 'Start' to 'End' " +
+				"on event 'Event1' has negative weight -1 (line 7, column 5)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadDefinition(strings.NewReader(tt.yaml), reg)
+			assert.EqualError(t, err, tt.errMsg)
+		})
+	}
+}
+
+func TestSaveDefinition(t *testing.T) {
+	reg := getTestRegistry()
+
+	yamlData := `
+initialState: Draft
+finalStates: [Approved]
+events: [Submit, Approve, Reject]
+hooks:
+  onSuccess: [action1]
+  onFailure: [action2]
+onEvent:
+  Approve: [action1, action2]
+states:
+  Draft: {}
+  Review:
+    onEntry: [action1]
+    onExit: [action2]
+  Approved:
+transitions:
+  - from: Draft
+    to: Review
+    on: Submit
+    guards: [guard1]
+    actions: [action1]
+    tags: [ui]
+  - from: Review
+    to: Approved
+    on: Approve
+    guards: [guard1, guard2]
+    weight: 2.5
+    priority: 3
+    label: Approved by manager
+  - from: Review
+    to: Draft
+    on: Reject
+    disabled: true
+  - from: Review
+    to: Review
+    on: Reject
+    internal: true
+`
+
+	def, err := LoadDefinition(strings.NewReader(yamlData), reg)
+	require.NoError(t, err)
+
+	var out strings.Builder
+	require.NoError(t, SaveDefinition(&out, def, reg))
+
+	loaded, err := LoadDefinition(strings.NewReader(out.String()), reg)
+	require.NoError(t, err, out.String())
+
+	assert.Equal(t, def.InitialState(), loaded.InitialState())
+	assert.Equal(t, def.FinalStates(), loaded.FinalStates())
+	assert.Equal(t, def.States(), loaded.States())
+	assert.Equal(t, def.Transitions(), loaded.Transitions())
+	assert.Equal(t, def.Hooks(), loaded.Hooks())
+
+	events, declared := loaded.DeclaredEvents()
+	assert.True(t, declared)
+	assert.Equal(t, []gonfa.Event{"Approve", "Reject", "Submit"}, events)
+
+	// The output is stable
+	var again strings.Builder
+	require.NoError(t, SaveDefinition(&again, loaded, reg))
+	assert.Equal(t, out.String(), again.String())
+}
+
+func TestSaveDefinitionWithEval(t *testing.T) {
+	reg := getTestRegistry()
+
+	yamlData := `
+initialState: Start
+finalStates: [End]
+states:
+  Start: {}
+  End: {}
+transitions:
+  - from: Start
+    to: End
+    on: Finish
+    guards: [guard1]
+    when:
+      - allow
+      - deny
+`
+
+	def, err := LoadDefinitionWithEval(strings.NewReader(yamlData), reg,
+		&prefixEvaluator{})
+	require.NoError(t, err)
+
+	var out strings.Builder
+	require.NoError(t, SaveDefinition(&out, def, reg))
+	assert.Contains(t, out.String(), "when:\n      - allow\n      - deny\n")
+
+	eval := &prefixEvaluator{}
+	loaded, err := LoadDefinitionWithEval(strings.NewReader(out.String()),
+		reg, eval)
+	require.NoError(t, err, out.String())
+	assert.Equal(t, []string{"allow", "deny"}, eval.compiled)
+	assert.Equal(t, def.Transitions(), loaded.Transitions())
+
+	t.Run("registered guard after expression", func(t *testing.T) {
+		guard1, _ := reg.GetGuard("guard1")
+		def, err := New("A", []gonfa.State{"B"},
+			map[gonfa.State]StateConfig{"A": {}, "B": {}},
+			[]Transition{{From: "A", To: "B", On: "Go",
+				Guards:     []gonfa.Guard{&testGuard{result: true}, guard1},
+				GuardNames: []string{"allow", "guard1"}}},
+			Hooks{})
+		require.NoError(t, err)
+
+		err = SaveDefinition(&strings.Builder{}, def, reg)
+		assert.EqualError(t, err, "guard 'guard1' of transition from 'A' "+
+			"to 'B' on event 'Go' follows a guard expression")
+	})
+}
+
+func TestSaveDefinitionErrors(t *testing.T) {
+	reg := getTestRegistry()
+	guard1, _ := reg.GetGuard("guard1")
+	states := map[gonfa.State]StateConfig{"A": {}, "B": {}}
+
+	t.Run("nil arguments", func(t *testing.T) {
+		def, err := New("A", []gonfa.State{"B"}, states,
+			[]Transition{{From: "A", To: "B", On: "Go"}}, Hooks{})
+		require.NoError(t, err)
+
+		assert.EqualError(t, SaveDefinition(&strings.Builder{}, nil, reg),
+			"definition cannot be nil")
+		assert.EqualError(t, SaveDefinition(&strings.Builder{}, def, nil),
+			"registry cannot be nil")
+	})
+
+	tests := []struct {
+		name       string
+		transition Transition
+		errMsg     string
+	}{
+		{
+			name: "unregistered guard",
+			transition: Transition{From: "A", To: "B", On: "Go",
+				Guards: []gonfa.Guard{&testGuard{result: true}}},
+			errMsg: "guard *definition.testGuard of transition from 'A' " +
+				"to 'B' on event 'Go' isn't registered",
+		},
+		{
+			name: "unregistered action",
+			transition: Transition{From: "A", To: "B", On: "Go",
+				Guards:  []gonfa.Guard{guard1},
+				Actions: []gonfa.Action{&testAction{}}},
+			errMsg: "action *definition.testAction of transition from 'A' " +
+				"to 'B' on event 'Go' isn't registered",
+		},
+		{
+			name: "preconditions",
+			transition: Transition{From: "A", To: "B", On: "Go",
+				Preconditions: []gonfa.Guard{guard1}},
+			errMsg: "preconditions of transition from 'A' to 'B' " +
+				"on event 'Go' can't be expressed in YAML",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			def, err := New("A", []gonfa.State{"B"}, states,
+				[]Transition{tt.transition}, Hooks{})
+			require.NoError(t, err)
+
+			err = SaveDefinition(&strings.Builder{}, def, reg)
+			assert.EqualError(t, err, tt.errMsg)
+		})
+	}
+
+	t.Run("context values", func(t *testing.T) {
+		def, err := New("A", []gonfa.State{"B"}, states,
+			[]Transition{{From: "A", To: "B", On: "Go"}}, Hooks{},
+			WithContextValues(map[any]any{"tenant": "acme"}))
+		require.NoError(t, err)
+
+		assert.EqualError(t, SaveDefinition(&strings.Builder{}, def, reg),
+			"context values can't be expressed in YAML")
+		assert.EqualError(t, GenerateGo(def, "workflow", &bytes.Buffer{}),
+			"context values can't be generated")
+	})
+}
+
+func TestRequiredNames(t *testing.T) {
+	yamlData := `
+initialState: Start
+finalStates: [End]
+hooks:
+  onSuccess: [action2]
+states:
+  Start:
+    onExit: [action1]
+  End: {}
+transitions:
+  - from: Start
+    to: End
+    on: Event1
+    guards: [guard2, guard1]
+    actions: [action1]
+`
+
+	reg := getTestRegistry()
+	def, err := LoadDefinition(strings.NewReader(yamlData), reg)
+	require.NoError(t, err)
+
+	guards, actions := def.RequiredNames()
+	assert.Equal(t, []string{"guard1", "guard2"}, guards)
+	assert.Equal(t, []string{"action1", "action2"}, actions)
+
+	assert.Empty(t, reg.MissingFor(def))
+
+	partial := registry.New()
+	require.NoError(t, partial.RegisterGuard("guard1", &testGuard{}))
+	assert.Equal(t, []string{"action1", "action2", "guard2"},
+		partial.MissingFor(def))
+
+	t.Run("built in code", func(t *testing.T) {
+		def, err := New("A", []gonfa.State{"B"},
+			map[gonfa.State]StateConfig{"A": {}, "B": {}},
+			[]Transition{{From: "A", To: "B", On: "Go"}}, Hooks{})
+		require.NoError(t, err)
+
+		guards, actions := def.RequiredNames()
+		assert.Nil(t, guards)
+		assert.Nil(t, actions)
+	})
+}
+
+func TestLoadDefinitionJSON(t *testing.T) {
+	reg := getTestRegistry()
+
+	t.Run("valid", func(t *testing.T) {
+		jsonData := `{
+  "initialState": "Start",
+  "finalStates": ["End"],
+  "hooks": {"onSuccess": ["action1"]},
+  "states": {"Start": {"onExit": ["action2"]}, "End": {}},
+  "transitions": [
+    {"from": "Start", "to": "End", "on": "Finish",
+     "guards": ["guard1"], "actions": ["action1"], "weight": 1.5}
+  ]
+}`
+
+		def, err := LoadDefinitionJSON(strings.NewReader(jsonData), reg)
+		require.NoError(t, err)
+
+		// JSON and YAML produce the same definition
+		var yamlData strings.Builder
+		require.NoError(t, SaveDefinition(&yamlData, def, reg))
+
+		fromYAML, err := LoadDefinition(strings.NewReader(yamlData.String()), reg)
+		require.NoError(t, err)
+		assert.Equal(t, fromYAML.States(), def.States())
+		assert.Equal(t, fromYAML.Transitions(), def.Transitions())
+		assert.Equal(t, fromYAML.Hooks(), def.Hooks())
+	})
+
+	tests := []struct {
+		name   string
+		json   string
+		errMsg string
+	}{
+		{
+			name:   "missing initial state",
+			json:   `{"transitions": [{"from": "A", "to": "B", "on": "Go"}]}`,
+			errMsg: "initialState is required",
+		},
+		{
+			name: "missing guard",
+			json: `{"initialState": "A", "transitions": [
+				{"from": "A", "to": "B", "on": "Go", "guards": ["nonExistentGuard"]}]}`,
+			errMsg: "guard 'nonExistentGuard' not found in registry " +
+				"(transition from 'A' to 'B' on event 'Go')",
+		},
+		{
+			name: "missing hook action",
+			json: `{"initialState": "A", "hooks": {"onFailure": ["nonExistentAction"]},
+				"transitions": [{"from": "A", "to": "B", "on": "Go"}]}`,
+			errMsg: "failure hook action 'nonExistentAction' not found in registry",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadDefinitionJSON(strings.NewReader(tt.json), reg)
+			assert.EqualError(t, err, tt.errMsg)
+		})
+	}
+
+	t.Run("invalid JSON", func(t *testing.T) {
+		_, err := LoadDefinitionJSON(strings.NewReader(`{"initialState": `), reg)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to parse JSON")
+	})
+}
+
+func TestLoadDefinitionAuto(t *testing.T) {
+	reg := getTestRegistry()
+
+	t.Run("JSON", func(t *testing.T) {
+		def, err := LoadDefinitionAuto(strings.NewReader(`
+  {"initialState": "A", "finalStates": ["B"],
+   "states": {"A": {}, "B": {}},
+   "transitions": [{"from": "A", "to": "B", "on": "Go"}]}`), reg)
+		require.NoError(t, err)
+		assert.Equal(t, gonfa.State("A"), def.InitialState())
+	})
+
+	t.Run("YAML", func(t *testing.T) {
+		def, err := LoadDefinitionAuto(strings.NewReader(`
+initialState: A
+finalStates: [B]
+states: {A: {}, B: {}}
+transitions:
+  - {from: A, to: B, on: Go}
+`), reg)
+		require.NoError(t, err)
+		assert.Equal(t, gonfa.State("A"), def.InitialState())
+	})
+
+	t.Run("errors name the format", func(t *testing.T) {
+		_, err := LoadDefinitionAuto(strings.NewReader(`{"initialState": `), reg)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(),
+			"failed to load definition as JSON: failed to parse JSON")
+
+		_, err = LoadDefinitionAuto(strings.NewReader("transitions: ["), reg)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(),
+			"failed to load definition as YAML: failed to parse YAML")
+
+		_, err = LoadDefinitionAuto(strings.NewReader(""), reg)
+		assert.EqualError(t, err,
+			"failed to load definition as YAML: initialState is required")
+	})
+}
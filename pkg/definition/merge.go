@@ -0,0 +1,152 @@
+package definition
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+
+	"github.com/dr-dobermann/gonfa/pkg/gonfa"
+)
+
+// Merge composes several definitions (e.g. reusable sub-processes) into
+// one. The result has the initial state of the first definition and the
+// union of the states, transitions, final states and hooks of all of them;
+// hooks are concatenated in the order of defs (see MergeHooks). A final
+// state of one definition which has outgoing transitions in another one is
+// where the first hands over to the second, so it isn't final in the
+// result. Declared
+// events and required names are united, and the first definition setting a
+// default context value wins.
+//
+// A state may be configured (have actions, AcceptIf guards or an error
+// state) in several definitions only if the configurations are the same,
+// and a transition with the same source, target and event may be declared
+// only once. The merged definition is validated by New, so the fragments
+// must form a connected graph, and Init is called on its components again.
+func Merge(defs ...*Definition) (*Definition, error) {
+	if len(defs) == 0 {
+		return nil, fmt.Errorf("no definitions to merge")
+	}
+
+	var (
+		finals      []gonfa.State
+		states      = make(map[gonfa.State]StateConfig)
+		configured  = make(map[gonfa.State]int)
+		transitions []Transition
+		seen        = make(map[transitionKey]int)
+		hooks       []Hooks
+		opts        []Option
+		values      map[any]any
+		guardNames  []string
+		actionNames []string
+	)
+
+	for i, d := range defs {
+		if d == nil {
+			return nil, fmt.Errorf("definition %d is nil", i)
+		}
+
+		for _, s := range d.finalStates {
+			if !slices.Contains(finals, s) {
+				finals = append(finals, s)
+			}
+		}
+
+		for s, cfg := range d.states {
+			if isEmptyConfig(cfg) {
+				if _, ok := states[s]; !ok {
+					states[s] = cfg
+				}
+				continue
+			}
+
+			if j, ok := configured[s]; ok {
+				if !sameConfig(states[s], cfg) {
+					return nil, fmt.Errorf(
+						"state '%s' is configured differently "+
+							"in definitions %d and %d", s, j, i)
+				}
+				continue
+			}
+
+			states[s] = cfg
+			configured[s] = i
+		}
+
+		for _, t := range d.transitions {
+			key := transitionKey{from: t.From, to: t.To, on: t.On}
+			if j, ok := seen[key]; ok {
+				return nil, fmt.Errorf(
+					"transition from '%s' to '%s' on event '%s' is declared "+
+						"in definitions %d and %d", t.From, t.To, t.On, j, i)
+			}
+
+			seen[key] = i
+			transitions = append(transitions, t.clone())
+		}
+
+		hooks = append(hooks, d.hooks)
+
+		if d.eventsDeclared {
+			opts = append(opts, DeclareEvents(d.declaredEvents...))
+		}
+		if d.requireAllEvents {
+			opts = append(opts, RequireAllEventsReachable())
+		}
+
+		for k, v := range d.contextValues {
+			if _, ok := values[k]; !ok {
+				if values == nil {
+					values = make(map[any]any)
+				}
+				values[k] = v
+			}
+		}
+
+		guardNames = append(guardNames, d.guardNames...)
+		actionNames = append(actionNames, d.actionNames...)
+	}
+
+	// Hand-over states aren't final anymore
+	finals = slices.DeleteFunc(finals, func(s gonfa.State) bool {
+		return slices.ContainsFunc(transitions, func(t Transition) bool {
+			return t.From == s
+		})
+	})
+
+	opts = append(opts, WithContextValues(values))
+	if len(guardNames)+len(actionNames) > 0 {
+		opts = append(opts, withNames(guardNames, actionNames))
+	}
+
+	return New(defs[0].initialState, finals, states,
+		transitions, MergeHooks(hooks...), opts...)
+}
+
+// isEmptyConfig checks if the state has no configuration.
+func isEmptyConfig(cfg StateConfig) bool {
+	return len(cfg.OnEntry) == 0 && len(cfg.OnExit) == 0 &&
+		len(cfg.AcceptIf) == 0 && cfg.ErrorState == ""
+}
+
+// sameConfig checks if the configurations have the same error state and
+// the same guards and actions in the same order.
+func sameConfig(a, b StateConfig) bool {
+	return a.ErrorState == b.ErrorState &&
+		sameObjects(a.OnEntry, b.OnEntry) &&
+		sameObjects(a.OnExit, b.OnExit) &&
+		sameObjects(a.AcceptIf, b.AcceptIf)
+}
+
+// sameObjects checks if the slices hold identical objects. Objects of
+// incomparable types are never considered identical.
+func sameObjects[T any](a, b []T) bool {
+	return slices.EqualFunc(a, b, func(x, y T) bool {
+		vx, vy := any(x), any(y)
+		if vx == nil || vy == nil {
+			return vx == vy
+		}
+
+		return reflect.TypeOf(vx).Comparable() && vx == vy
+	})
+}
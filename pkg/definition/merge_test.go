@@ -0,0 +1,96 @@
+package definition
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dr-dobermann/gonfa/pkg/gonfa"
+)
+
+func TestMerge(t *testing.T) {
+	notify := &testAction{name: "notify"}
+	audit := &testAction{name: "audit"}
+
+	intake, err := New("Draft", []gonfa.State{"Review"},
+		map[gonfa.State]StateConfig{
+			"Draft":  {},
+			"Review": {OnEntry: []gonfa.Action{notify}},
+		},
+		[]Transition{{From: "Draft", To: "Review", On: "Submit"}},
+		Hooks{OnSuccess: []gonfa.Action{audit}},
+		DeclareEvents("Submit"),
+		WithContextValues(map[any]any{"tenant": "acme"}))
+	require.NoError(t, err)
+
+	approval, err := New("Review", []gonfa.State{"Approved", "Rejected"},
+		map[gonfa.State]StateConfig{
+			"Review":   {OnEntry: []gonfa.Action{notify}},
+			"Approved": {},
+			"Rejected": {},
+		},
+		[]Transition{
+			{From: "Review", To: "Approved", On: "Approve"},
+			{From: "Review", To: "Rejected", On: "Reject"},
+		},
+		Hooks{OnSuccess: []gonfa.Action{notify}},
+		WithContextValues(map[any]any{"tenant": "other", "beta": true}))
+	require.NoError(t, err)
+
+	t.Run("union", func(t *testing.T) {
+		merged, err := Merge(intake, approval)
+		require.NoError(t, err)
+
+		assert.Equal(t, gonfa.State("Draft"), merged.InitialState())
+		// Review hands over from intake to approval, so it isn't final
+		assert.Equal(t, []gonfa.State{"Approved", "Rejected"},
+			merged.FinalStates())
+		assert.Len(t, merged.Transitions(), 3)
+		assert.Len(t, merged.States(), 4)
+		assert.Equal(t, []gonfa.Action{notify},
+			merged.States()["Review"].OnEntry)
+
+		assert.Equal(t, []gonfa.Action{audit, notify},
+			merged.Hooks().OnSuccess)
+		events, declared := merged.DeclaredEvents()
+		assert.True(t, declared)
+		assert.Equal(t, []gonfa.Event{"Submit"}, events)
+		assert.Equal(t, map[any]any{"tenant": "acme", "beta": true},
+			merged.ContextValues())
+	})
+
+	t.Run("first definition sets the initial state", func(t *testing.T) {
+		_, err := Merge(approval, intake)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "states check failed")
+	})
+
+	t.Run("conflicting state configuration", func(t *testing.T) {
+		other, err := New("Review", []gonfa.State{"Done"},
+			map[gonfa.State]StateConfig{
+				"Review": {OnEntry: []gonfa.Action{audit}},
+				"Done":   {},
+			},
+			[]Transition{{From: "Review", To: "Done", On: "Close"}}, Hooks{})
+		require.NoError(t, err)
+
+		_, err = Merge(intake, approval, other)
+		assert.EqualError(t, err, "state 'Review' is configured "+
+			"differently in definitions 0 and 2")
+	})
+
+	t.Run("duplicate transition", func(t *testing.T) {
+		_, err := Merge(intake, approval, approval)
+		assert.EqualError(t, err, "transition from 'Review' to 'Approved' "+
+			"on event 'Approve' is declared in definitions 1 and 2")
+	})
+
+	t.Run("invalid arguments", func(t *testing.T) {
+		_, err := Merge()
+		assert.EqualError(t, err, "no definitions to merge")
+
+		_, err = Merge(intake, nil)
+		assert.EqualError(t, err, "definition 1 is nil")
+	})
+}
@@ -0,0 +1,105 @@
+package definition
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/dr-dobermann/gonfa/pkg/gonfa"
+)
+
+// mermaidID matches the state names Mermaid accepts as state ids.
+var mermaidID = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_]*$`)
+
+// mermaidKeywords can't be used as state ids in a state diagram.
+var mermaidKeywords = map[string]bool{
+	"state": true, "note": true, "direction": true, "class": true,
+	"classDef": true, "style": true, "end": true,
+}
+
+// ToMermaid renders the definition as a Mermaid stateDiagram-v2, ready to
+// be put into a ```mermaid block of a Markdown page. The initial state is
+// entered from [*], final states lead to [*], and every transition gets its
+// own line labeled with its event, so self-transitions and several events
+// between the same states are all shown.
+//
+// States whose names aren't valid Mermaid ids (e.g. containing spaces) are
+// declared under generated ids with their names as descriptions. The
+// output is sorted, so it's stable.
+func ToMermaid(d *Definition) string {
+	states := make([]gonfa.State, 0, len(d.states))
+	for s := range d.states {
+		states = append(states, s)
+	}
+	slices.Sort(states)
+
+	ids := mermaidIDs(states)
+
+	var sb strings.Builder
+
+	sb.WriteString("stateDiagram-v2\n")
+
+	for _, s := range states {
+		if ids[s] != string(s) {
+			fmt.Fprintf(&sb, "    state \"%s\" as %s\n",
+				mermaidText(string(s)), ids[s])
+		}
+	}
+
+	fmt.Fprintf(&sb, "    [*] --> %s\n", ids[d.initialState])
+
+	for _, t := range sortTransitions(d.transitions) {
+		fmt.Fprintf(&sb, "    %s --> %s : %s\n",
+			ids[t.From], ids[t.To], mermaidText(string(t.On)))
+	}
+
+	finals := slices.Clone(d.finalStates)
+	slices.Sort(finals)
+
+	for _, s := range finals {
+		fmt.Fprintf(&sb, "    %s --> [*]\n", ids[s])
+	}
+
+	return sb.String()
+}
+
+// mermaidIDs returns the Mermaid ids of the sorted states. Valid names are
+// used as is, others get ids like s1, s2 not clashing with any name.
+func mermaidIDs(states []gonfa.State) map[gonfa.State]string {
+	ids := make(map[gonfa.State]string, len(states))
+	used := make(map[string]bool, len(states))
+
+	for _, s := range states {
+		used[string(s)] = true
+	}
+
+	n := 0
+	for _, s := range states {
+		if mermaidID.MatchString(string(s)) && !mermaidKeywords[string(s)] {
+			ids[s] = string(s)
+			continue
+		}
+
+		id := ""
+		for id == "" || used[id] {
+			n++
+			id = "s" + strconv.Itoa(n)
+		}
+
+		ids[s] = id
+		used[id] = true
+	}
+
+	return ids
+}
+
+// mermaidText escapes the characters breaking Mermaid labels and state
+// descriptions with entity codes.
+func mermaidText(s string) string {
+	r := strings.NewReplacer(
+		"#", "#35;", `"`, "#quot;", ";", "#59;", "\n", " ")
+
+	return r.Replace(s)
+}
@@ -0,0 +1,63 @@
+package definition
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dr-dobermann/gonfa/pkg/gonfa"
+)
+
+func TestToMermaid(t *testing.T) {
+	t.Run("plain names", func(t *testing.T) {
+		def, err := New("Draft", []gonfa.State{"Rejected", "Approved"},
+			map[gonfa.State]StateConfig{
+				"Draft": {}, "Review": {}, "Approved": {}, "Rejected": {},
+			},
+			[]Transition{
+				{From: "Draft", To: "Review", On: "Submit"},
+				{From: "Review", To: "Approved", On: "Approve"},
+				{From: "Review", To: "Rejected", On: "Reject"},
+				{From: "Review", To: "Rejected", On: "Timeout"},
+				{From: "Review", To: "Review", On: "Comment"},
+			},
+			Hooks{})
+		require.NoError(t, err)
+
+		expected := `stateDiagram-v2
+    [*] --> Draft
+    Draft --> Review : Submit
+    Review --> Approved : Approve
+    Review --> Review : Comment
+    Review --> Rejected : Reject
+    Review --> Rejected : Timeout
+    Approved --> [*]
+    Rejected --> [*]
+`
+		assert.Equal(t, expected, ToMermaid(def))
+	})
+
+	t.Run("generated ids", func(t *testing.T) {
+		def, err := New("In Review", []gonfa.State{"end"},
+			map[gonfa.State]StateConfig{
+				"In Review": {}, "end": {}, "s1": {},
+			},
+			[]Transition{
+				{From: "In Review", To: "s1", On: "Send; notify"},
+				{From: "s1", To: "end", On: `Say "done"`},
+			},
+			Hooks{})
+		require.NoError(t, err)
+
+		expected := `stateDiagram-v2
+    state "In Review" as s2
+    state "end" as s3
+    [*] --> s2
+    s2 --> s1 : Send#59; notify
+    s1 --> s3 : Say #quot;done#quot;
+    s3 --> [*]
+`
+		assert.Equal(t, expected, ToMermaid(def))
+	})
+}
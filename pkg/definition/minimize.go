@@ -0,0 +1,255 @@
+package definition
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/dr-dobermann/gonfa/pkg/gonfa"
+)
+
+// Minimize returns the smallest Definition equivalent to d, merging states
+// which can't be distinguished by any sequence of events.
+//
+// Classical equivalence only holds for deterministic automata, so Minimize
+// returns an error if any transition has guards or preconditions or if a
+// state has several transitions on the same event. States unreachable from
+// the initial state are dropped. States with OnEntry/OnExit actions or AcceptIf guards, or
+// with outgoing transitions carrying actions, are considered
+// distinguishable from any other state and are never merged. States are
+// merged only if their transitions on every event are disabled alike and
+// their error states (see StateConfig.ErrorState) are equivalent; the error
+// states are renamed along with the merged states.
+//
+// A merged state is named by joining the names of its members in sorted
+// order with '+' (e.g. "Approved+Done"). Unmerged states keep their names.
+func Minimize(d *Definition) (*Definition, error) {
+	if d == nil {
+		return nil, fmt.Errorf("definition cannot be nil")
+	}
+
+	delta, err := newDeltaTable(d.transitions)
+	if err != nil {
+		return nil, fmt.Errorf("can't minimize definition: %w", err)
+	}
+
+	graph, _ := newTransitionGraph(d.transitions)
+	for s, cfg := range d.states {
+		if cfg.ErrorState != "" {
+			if graph[s] == nil {
+				graph[s] = make(stateSet)
+			}
+			graph[s][cfg.ErrorState] = struct{}{}
+		}
+	}
+	reachable := findReachableStates(d.initialState, graph)
+
+	states := make([]gonfa.State, 0, len(reachable))
+	for s := range reachable {
+		states = append(states, s)
+	}
+	slices.Sort(states)
+
+	events := make([]gonfa.Event, 0)
+	for _, t := range d.transitions {
+		if !slices.Contains(events, t.On) {
+			events = append(events, t.On)
+		}
+	}
+	slices.Sort(events)
+
+	blockOf := refinePartition(d, delta, states, events)
+
+	// Collect blocks ordered by their smallest member
+	var blocks [][]gonfa.State
+	index := make(map[int]int)
+	for _, s := range states {
+		b := blockOf[s]
+		i, ok := index[b]
+		if !ok {
+			i = len(blocks)
+			index[b] = i
+			blocks = append(blocks, nil)
+		}
+		blocks[i] = append(blocks[i], s)
+	}
+
+	names := make(map[gonfa.State]gonfa.State, len(states))
+	for _, members := range blocks {
+		parts := make([]string, len(members))
+		for i, m := range members {
+			parts[i] = string(m)
+		}
+		name := gonfa.State(strings.Join(parts, "+"))
+		for _, m := range members {
+			names[m] = name
+		}
+	}
+
+	var (
+		newStates      = make(map[gonfa.State]StateConfig, len(blocks))
+		newTransitions []Transition
+		newFinals      []gonfa.State
+	)
+
+	for _, members := range blocks {
+		rep := members[0]
+		name := names[rep]
+
+		cfg := d.states[rep]
+		if cfg.ErrorState != "" {
+			cfg.ErrorState = names[cfg.ErrorState]
+		}
+		newStates[name] = cfg
+		if d.IsFinalState(rep) {
+			newFinals = append(newFinals, name)
+		}
+
+		for _, t := range d.transitions {
+			if t.From != rep {
+				continue
+			}
+
+			t.From = name
+			t.To = names[t.To]
+			newTransitions = append(newTransitions, t)
+		}
+	}
+
+	return New(names[d.initialState], newFinals, newStates, newTransitions,
+		d.hooks, d.options()...)
+}
+
+// deltaTable is a deterministic transition function.
+type deltaTable map[gonfa.State]map[gonfa.Event]Transition
+
+// newDeltaTable builds the transition function of a deterministic
+// definition without guards and preconditions.
+func newDeltaTable(transitions []Transition) (deltaTable, error) {
+	delta := make(deltaTable)
+
+	for _, t := range transitions {
+		if len(t.Guards) > 0 {
+			return nil, fmt.Errorf(
+				"transition from '%s' to '%s' on event '%s' has guards",
+				t.From, t.To, t.On)
+		}
+
+		if len(t.Preconditions) > 0 {
+			return nil, fmt.Errorf(
+				"transition from '%s' to '%s' on event '%s' has preconditions",
+				t.From, t.To, t.On)
+		}
+
+		if delta[t.From] == nil {
+			delta[t.From] = make(map[gonfa.Event]Transition)
+		}
+
+		if _, exists := delta[t.From][t.On]; exists {
+			return nil, fmt.Errorf(
+				"state '%s' has several transitions on event '%s'",
+				t.From, t.On)
+		}
+
+		delta[t.From][t.On] = t
+	}
+
+	return delta, nil
+}
+
+// refinePartition splits states into blocks of equivalent states using
+// iterative partition refinement. Returns the block number of every state.
+func refinePartition(
+	d *Definition,
+	delta deltaTable,
+	states []gonfa.State,
+	events []gonfa.Event,
+) map[gonfa.State]int {
+	blockOf := make(map[gonfa.State]int, len(states))
+
+	// Initial partition: states with actions or accept conditions get their
+	// own blocks, others are split by being final, having an error state and
+	// the events of their disabled transitions.
+	keys := make(map[string]int)
+	for _, s := range states {
+		var key strings.Builder
+		if hasActions(d, delta, s) || len(d.states[s].AcceptIf) > 0 {
+			fmt.Fprintf(&key, "own %q", s)
+		} else {
+			fmt.Fprintf(&key, "%t,%t", d.IsFinalState(s),
+				d.states[s].ErrorState != "")
+			for _, e := range events {
+				if t, ok := delta[s][e]; ok && t.Disabled {
+					fmt.Fprintf(&key, ",%q", e)
+				}
+			}
+		}
+
+		b, ok := keys[key.String()]
+		if !ok {
+			b = len(keys)
+			keys[key.String()] = b
+		}
+		blockOf[s] = b
+	}
+
+	for {
+		signatures := make(map[string]int)
+		next := make(map[gonfa.State]int, len(states))
+
+		for _, s := range states {
+			var sig strings.Builder
+			fmt.Fprintf(&sig, "%d", blockOf[s])
+			for _, e := range events {
+				target := -1
+				if t, ok := delta[s][e]; ok {
+					target = blockOf[t.To]
+				}
+				fmt.Fprintf(&sig, ",%d", target)
+			}
+			if es := d.states[s].ErrorState; es != "" {
+				fmt.Fprintf(&sig, ",error %d", blockOf[es])
+			}
+
+			b, ok := signatures[sig.String()]
+			if !ok {
+				b = len(signatures)
+				signatures[sig.String()] = b
+			}
+			next[s] = b
+		}
+
+		if countBlocks(next) == countBlocks(blockOf) {
+			return next
+		}
+
+		blockOf = next
+	}
+}
+
+// countBlocks returns the number of distinct blocks in the partition.
+func countBlocks(blockOf map[gonfa.State]int) int {
+	blocks := make(map[int]struct{}, len(blockOf))
+	for _, b := range blockOf {
+		blocks[b] = struct{}{}
+	}
+
+	return len(blocks)
+}
+
+// hasActions checks if the state or its outgoing transitions have actions.
+func hasActions(d *Definition, delta deltaTable, s gonfa.State) bool {
+	cfg := d.states[s]
+	if len(cfg.OnEntry) > 0 || len(cfg.OnExit) > 0 {
+		return true
+	}
+
+	for _, t := range delta[s] {
+		if len(t.Actions) > 0 || len(t.AfterActions) > 0 ||
+			len(t.Compensations) > 0 || len(t.AsyncActions) > 0 {
+			return true
+		}
+	}
+
+	return false
+}
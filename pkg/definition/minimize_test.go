@@ -0,0 +1,177 @@
+package definition
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dr-dobermann/gonfa/pkg/gonfa"
+)
+
+func TestMinimize(t *testing.T) {
+	states := func(names ...gonfa.State) map[gonfa.State]StateConfig {
+		m := make(map[gonfa.State]StateConfig, len(names))
+		for _, n := range names {
+			m[n] = StateConfig{}
+		}
+		return m
+	}
+
+	t.Run("merges equivalent states", func(t *testing.T) {
+		def, err := New("A", []gonfa.State{"D"},
+			states("A", "B", "C", "D"),
+			[]Transition{
+				{From: "A", To: "B", On: "left"},
+				{From: "A", To: "C", On: "right"},
+				{From: "B", To: "D", On: "next"},
+				{From: "C", To: "D", On: "next"},
+			}, Hooks{})
+		require.NoError(t, err)
+
+		minDef, err := Minimize(def)
+		require.NoError(t, err)
+
+		assert.Equal(t, gonfa.State("A"), minDef.InitialState())
+		assert.Equal(t, []gonfa.State{"D"}, minDef.FinalStates())
+		assert.Len(t, minDef.States(), 3)
+		assert.Contains(t, minDef.States(), gonfa.State("B+C"))
+		assert.Len(t, minDef.GetTransitions("A", "left"), 1)
+		assert.Equal(t, gonfa.State("B+C"), minDef.GetTransitions("A", "right")[0].To)
+		assert.Equal(t, gonfa.State("D"), minDef.GetTransitions("B+C", "next")[0].To)
+	})
+
+	t.Run("states with actions aren't merged", func(t *testing.T) {
+		cfg := states("A", "B", "C", "D")
+		cfg["B"] = StateConfig{OnEntry: []gonfa.Action{&testAction{}}}
+
+		def, err := New("A", []gonfa.State{"D"}, cfg,
+			[]Transition{
+				{From: "A", To: "B", On: "left"},
+				{From: "A", To: "C", On: "right"},
+				{From: "B", To: "D", On: "next"},
+				{From: "C", To: "D", On: "next"},
+			}, Hooks{})
+		require.NoError(t, err)
+
+		minDef, err := Minimize(def)
+		require.NoError(t, err)
+		assert.Len(t, minDef.States(), 4)
+	})
+
+	t.Run("distinguishing state attributes", func(t *testing.T) {
+		type fixture struct {
+			cfg         map[gonfa.State]StateConfig
+			transitions []Transition
+			finals      []gonfa.State
+		}
+
+		tests := []struct {
+			name   string
+			update func(f *fixture)
+		}{
+			{"accept condition", func(f *fixture) {
+				// B and C lead to final states differing by the condition
+				f.cfg["D"] = StateConfig{AcceptIf: []gonfa.Guard{&testGuard{}}}
+				f.cfg["E"] = StateConfig{}
+				f.finals = append(f.finals, "E")
+				f.transitions[3].To = "E"
+			}},
+			{"error state", func(f *fixture) {
+				f.cfg["B"] = StateConfig{ErrorState: "D"}
+			}},
+			{"disabled transition", func(f *fixture) {
+				f.transitions[2].Disabled = true
+			}},
+		}
+
+		for _, test := range tests {
+			t.Run(test.name, func(t *testing.T) {
+				f := fixture{
+					cfg: states("A", "B", "C", "D"),
+					transitions: []Transition{
+						{From: "A", To: "B", On: "left"},
+						{From: "A", To: "C", On: "right"},
+						{From: "B", To: "D", On: "next"},
+						{From: "C", To: "D", On: "next"},
+					},
+					finals: []gonfa.State{"D"},
+				}
+				test.update(&f)
+
+				def, err := New("A", f.finals, f.cfg, f.transitions, Hooks{})
+				require.NoError(t, err)
+
+				minDef, err := Minimize(def)
+				require.NoError(t, err)
+				assert.Contains(t, minDef.States(), gonfa.State("B"))
+				assert.Contains(t, minDef.States(), gonfa.State("C"))
+			})
+		}
+	})
+
+	t.Run("error states are renamed", func(t *testing.T) {
+		cfg := states("A", "B", "C", "D")
+		cfg["A"] = StateConfig{ErrorState: "C"}
+
+		def, err := New("A", []gonfa.State{"D"}, cfg,
+			[]Transition{
+				{From: "A", To: "B", On: "left",
+					Actions: []gonfa.Action{&testAction{}}},
+				{From: "A", To: "C", On: "right"},
+				{From: "B", To: "D", On: "next"},
+				{From: "C", To: "D", On: "next"},
+			}, Hooks{})
+		require.NoError(t, err)
+
+		minDef, err := Minimize(def)
+		require.NoError(t, err)
+		assert.Contains(t, minDef.States(), gonfa.State("B+C"))
+		assert.Equal(t, gonfa.State("B+C"),
+			minDef.GetStateConfig("A").ErrorState)
+	})
+
+	t.Run("guards are rejected", func(t *testing.T) {
+		def, err := New("A", []gonfa.State{"B"}, states("A", "B"),
+			[]Transition{{From: "A", To: "B", On: "go",
+				Guards: []gonfa.Guard{&testGuard{result: true}}}},
+			Hooks{})
+		require.NoError(t, err)
+
+		_, err = Minimize(def)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "has guards")
+	})
+
+	t.Run("preconditions are rejected", func(t *testing.T) {
+		// A can never leave on "go", B always can, so merging them would
+		// change the language.
+		def, err := New("A", []gonfa.State{"X"}, states("A", "B", "X"),
+			[]Transition{
+				{From: "A", To: "X", On: "go",
+					Preconditions: []gonfa.Guard{&testGuard{result: false}}},
+				{From: "B", To: "X", On: "go"},
+				{From: "A", To: "B", On: "skip"},
+			},
+			Hooks{})
+		require.NoError(t, err)
+
+		_, err = Minimize(def)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "has preconditions")
+	})
+
+	t.Run("nondeterminism is rejected", func(t *testing.T) {
+		def, err := New("A", []gonfa.State{"B", "C"}, states("A", "B", "C"),
+			[]Transition{
+				{From: "A", To: "B", On: "go"},
+				{From: "A", To: "C", On: "go"},
+			}, Hooks{})
+		require.NoError(t, err)
+
+		_, err = Minimize(def)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(),
+			"state 'A' has several transitions on event 'go'")
+	})
+}
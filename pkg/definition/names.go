@@ -0,0 +1,32 @@
+package definition
+
+import "slices"
+
+// withNames records the registry names of the guards and actions the
+// definition was loaded with.
+func withNames(guards, actions []string) Option {
+	return func(d *Definition) {
+		d.guardNames = sortedNames(guards)
+		d.actionNames = sortedNames(actions)
+	}
+}
+
+// RequiredNames returns the sorted distinct registry names of the guards
+// and actions a definition loaded from YAML refers to, as written in the
+// YAML (guard expressions aren't included). It's nil for definitions built
+// in code. Use registry.Registry.MissingFor to check that a registry
+// provides all of them.
+func (d *Definition) RequiredNames() (guards []string, actions []string) {
+	return slices.Clone(d.guardNames), slices.Clone(d.actionNames)
+}
+
+// sortedNames returns the sorted distinct names.
+func sortedNames(names []string) []string {
+	if len(names) == 0 {
+		return nil
+	}
+
+	sorted := slices.Sorted(slices.Values(names))
+
+	return slices.Compact(sorted)
+}
@@ -0,0 +1,81 @@
+package definition
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/dr-dobermann/gonfa/pkg/gonfa"
+	"github.com/dr-dobermann/gonfa/pkg/registry"
+)
+
+// NamespaceSeparator separates the registry namespace from the object name
+// in qualified YAML names like "billing:chargeCard".
+const NamespaceSeparator = ":"
+
+// nameResolver resolves YAML guard and action names. If a name isn't
+// resolved, the second result describes where it was searched for error
+// messages.
+type nameResolver interface {
+	guard(name string) (gonfa.Guard, string, bool)
+	action(name string) (gonfa.Action, string, bool)
+}
+
+// registryResolver resolves names in a single registry.
+type registryResolver struct {
+	reg registry.Resolver
+}
+
+func (r registryResolver) guard(name string) (gonfa.Guard, string, bool) {
+	g, ok := r.reg.GetGuard(name)
+	return g, "registry", ok
+}
+
+func (r registryResolver) action(name string) (gonfa.Action, string, bool) {
+	a, ok := r.reg.GetAction(name)
+	return a, "registry", ok
+}
+
+// nsResolver resolves qualified names in the registry of their namespace
+// and unqualified names in the default registry (namespace "").
+type nsResolver map[string]registry.Resolver
+
+// lookup splits the name and returns the registry of its namespace, the
+// unqualified name and the description of the searched registry.
+func (r nsResolver) lookup(name string) (registry.Resolver, string, string) {
+	ns, local, qualified := strings.Cut(name, NamespaceSeparator)
+	if !qualified {
+		ns, local = "", name
+	}
+
+	where := fmt.Sprintf("registry namespace '%s'", ns)
+	if ns == "" {
+		where = "default registry"
+	}
+
+	reg := r[ns]
+	if reg == nil {
+		where += " (not configured)"
+	}
+
+	return reg, local, where
+}
+
+func (r nsResolver) guard(name string) (gonfa.Guard, string, bool) {
+	reg, local, where := r.lookup(name)
+	if reg == nil {
+		return nil, where, false
+	}
+
+	g, ok := reg.GetGuard(local)
+	return g, where, ok
+}
+
+func (r nsResolver) action(name string) (gonfa.Action, string, bool) {
+	reg, local, where := r.lookup(name)
+	if reg == nil {
+		return nil, where, false
+	}
+
+	a, ok := reg.GetAction(local)
+	return a, where, ok
+}
@@ -34,3 +34,20 @@ func (a *testAction) Execute(
 	a.executed = true
 	return a.err
 }
+
+// constGuard is a gonfa.ConstantGuard for testing.
+type constGuard struct {
+	result bool
+}
+
+func (g *constGuard) Check(
+	ctx context.Context,
+	state gonfa.MachineState,
+	payload gonfa.Payload,
+) bool {
+	return g.result
+}
+
+func (g *constGuard) ConstantResult() bool {
+	return g.result
+}
@@ -0,0 +1,153 @@
+package definition
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/dr-dobermann/gonfa/pkg/gonfa"
+)
+
+// ValidateGraph checks the states and transitions the way New does, but
+// doesn't stop at the first problem: it returns all of them, e.g. for
+// editor tooling. It reports unknown states, duplicate transitions,
+// invalid transition settings, hanging and dead-end states, final states
+// with outgoing transitions and unreachable states.
+//
+// The errors are grouped by the kind of the problem in the order New
+// checks them; within a group they follow the sorted states or the order
+// of transitions, so the result is stable. Checks depending on the initial
+// state are skipped if it doesn't exist.
+//
+// errorStates maps states to their error states (see
+// StateConfig.ErrorState) and may be nil. As in New, a state is left
+// through its error state too, so it isn't a dead end, and the error state
+// is entered from it. ValidateGraph returns nil for a valid graph.
+func ValidateGraph(
+	initialState gonfa.State,
+	states []gonfa.State,
+	transitions []Transition,
+	finalStates []gonfa.State,
+	errorStates map[gonfa.State]gonfa.State,
+) []error {
+	var errs []error
+
+	known := newStateSet(states)
+	finalSet := newStateSet(finalStates)
+
+	sorted := make([]gonfa.State, 0, len(known))
+	for s := range known {
+		sorted = append(sorted, s)
+	}
+	slices.Sort(sorted)
+
+	initialExists := known.contains(initialState)
+	switch {
+	case initialState == "":
+		errs = append(errs, fmt.Errorf("initial state cannot be empty"))
+	case !initialExists:
+		errs = append(errs, validateInitialState(initialState, known))
+	}
+
+	for _, s := range unknownStates(finalStates, known) {
+		errs = append(errs, fmt.Errorf(
+			"final state '%s' doesn't exist in states", s))
+	}
+
+	graph := make(transitionGraph)
+	seen := make(map[transitionKey]struct{})
+	var sources, targets []gonfa.State
+
+	for _, t := range transitions {
+		key := transitionKey{from: t.From, to: t.To, on: t.On}
+		if _, exists := seen[key]; exists {
+			errs = append(errs, fmt.Errorf(
+				"duplicate transition from '%s' to '%s' on event '%s'",
+				t.From, t.To, t.On))
+		}
+		seen[key] = struct{}{}
+
+		if graph[t.From] == nil {
+			graph[t.From] = make(stateSet)
+		}
+		graph[t.From][t.To] = struct{}{}
+
+		sources = append(sources, t.From)
+		targets = append(targets, t.To)
+	}
+
+	for _, from := range slices.Sorted(maps.Keys(errorStates)) {
+		to := errorStates[from]
+		if !known.contains(to) {
+			errs = append(errs, fmt.Errorf(
+				"error state '%s' of state '%s' doesn't exist in states",
+				to, from))
+			continue
+		}
+
+		if graph[from] == nil {
+			graph[from] = make(stateSet)
+		}
+		graph[from][to] = struct{}{}
+	}
+
+	for _, s := range unknownStates(sources, known) {
+		errs = append(errs, fmt.Errorf(
+			"state '%s' doesn't exist as transition source", s))
+	}
+	for _, s := range unknownStates(targets, known) {
+		errs = append(errs, fmt.Errorf(
+			"state '%s' doesn't exist as transition target", s))
+	}
+
+	errs = append(errs, weightErrors(transitions)...)
+	errs = append(errs, asyncActionErrors(transitions)...)
+	for _, t := range transitions {
+		if err := validateTransitionNames([]Transition{t}); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if initialExists {
+		if err := validateInitialStateUsage(initialState, graph); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	counters := buildStateCounters(known, graph)
+	for _, s := range sorted {
+		errs = append(errs,
+			connectivityErrors(s, counters[s], initialState, finalSet)...)
+	}
+
+	if initialExists {
+		reachable := findReachableStates(initialState, graph)
+		for _, s := range sorted {
+			if finalSet.contains(s) && !reachable.contains(s) {
+				errs = append(errs, fmt.Errorf(
+					"final state '%s' is not reachable from initial state", s))
+			}
+		}
+		for _, s := range sorted {
+			if !finalSet.contains(s) && !reachable.contains(s) {
+				errs = append(errs, fmt.Errorf(
+					"state '%s' is not reachable from initial state", s))
+			}
+		}
+	}
+
+	return errs
+}
+
+// unknownStates returns the sorted distinct states missing in the set.
+func unknownStates(states []gonfa.State, known stateSet) []gonfa.State {
+	var unknown []gonfa.State
+	for _, s := range states {
+		if !known.contains(s) && !slices.Contains(unknown, s) {
+			unknown = append(unknown, s)
+		}
+	}
+	slices.Sort(unknown)
+
+	return unknown
+}
@@ -0,0 +1,121 @@
+package definition
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dr-dobermann/gonfa/pkg/gonfa"
+)
+
+func errorStrings(errs []error) []string {
+	ss := make([]string, len(errs))
+	for i, err := range errs {
+		ss[i] = err.Error()
+	}
+
+	return ss
+}
+
+func TestValidateGraph(t *testing.T) {
+	t.Run("valid graph", func(t *testing.T) {
+		errs := ValidateGraph("Start",
+			[]gonfa.State{"Start", "End"},
+			[]Transition{{From: "Start", To: "End", On: "finish"}},
+			[]gonfa.State{"End"}, nil)
+
+		assert.Nil(t, errs)
+	})
+
+	t.Run("all problems", func(t *testing.T) {
+		states := []gonfa.State{"Start", "Middle", "Orphan", "End", "Lost"}
+		transitions := []Transition{
+			{From: "Start", To: "Middle", On: "go"},
+			{From: "Start", To: "Middle", On: "go"},
+			{From: "Middle", To: "Ghost", On: "vanish"},
+			{From: "Phantom", To: "Start", On: "haunt", Weight: -1},
+			{From: "End", To: "Start", On: "restart"},
+		}
+
+		errs := ValidateGraph("Start", states, transitions,
+			[]gonfa.State{"End", "Lost", "Missing"}, nil)
+
+		assert.Equal(t, []string{
+			"final state 'Missing' doesn't exist in states",
+			"duplicate transition from 'Start' to 'Middle' on event 'go'",
+			"state 'Phantom' doesn't exist as transition source",
+			"state 'Ghost' doesn't exist as transition target",
+			"transition from 'Phantom' to 'Start' on event 'haunt' has negative weight -1",
+			"state 'End' isn't an initial state but has no incoming transitions",
+			"final state 'End' has outgoing transition(s)",
+			"state 'Lost' isn't an initial state but has no incoming transitions",
+			"state 'Orphan' isn't an initial state but has no incoming transitions",
+			"state 'Orphan' is a dead-end state",
+			"final state 'End' is not reachable from initial state",
+			"final state 'Lost' is not reachable from initial state",
+			"state 'Orphan' is not reachable from initial state",
+		}, errorStrings(errs))
+	})
+
+	t.Run("missing initial state", func(t *testing.T) {
+		errs := ValidateGraph("Nowhere",
+			[]gonfa.State{"Start", "End"},
+			[]Transition{{From: "Start", To: "End", On: "finish"}},
+			[]gonfa.State{"End"}, nil)
+
+		assert.Equal(t, []string{
+			"initial state 'Nowhere' doesn't exist in states",
+			"state 'Start' isn't an initial state but has no incoming transitions",
+		}, errorStrings(errs))
+	})
+
+	t.Run("error states", func(t *testing.T) {
+		states := []gonfa.State{"Start", "Work", "Failed", "End"}
+		transitions := []Transition{
+			{From: "Start", To: "Work", On: "begin"},
+			{From: "Failed", To: "End", On: "giveUp"},
+		}
+		finals := []gonfa.State{"End"}
+
+		// Work is left and Failed is entered only through the error state
+		assert.Nil(t, ValidateGraph("Start", states, transitions, finals,
+			map[gonfa.State]gonfa.State{"Work": "Failed"}))
+		require.NoError(t, checkStates("Start", states, transitions, finals,
+			map[gonfa.State]gonfa.State{"Work": "Failed"}))
+
+		assert.Equal(t, []string{
+			"state 'Failed' isn't an initial state but has no incoming transitions",
+			"state 'Work' is a dead-end state",
+			"final state 'End' is not reachable from initial state",
+			"state 'Failed' is not reachable from initial state",
+		}, errorStrings(ValidateGraph("Start", states, transitions, finals,
+			nil)))
+
+		errorStates := map[gonfa.State]gonfa.State{"Work": "Ghost"}
+		errs := ValidateGraph("Start", states, transitions, finals,
+			errorStates)
+		require.NotEmpty(t, errs)
+		assert.EqualError(t, errs[0],
+			"error state 'Ghost' of state 'Work' doesn't exist in states")
+		assert.EqualError(t,
+			checkStates("Start", states, transitions, finals, errorStates),
+			errs[0].Error())
+	})
+
+	t.Run("first error matches New", func(t *testing.T) {
+		states := []gonfa.State{"Start", "End", "Orphan"}
+		transitions := []Transition{
+			{From: "Start", To: "End", On: "finish"},
+			{From: "Start", To: "End", On: "finish"},
+		}
+
+		errs := ValidateGraph("Start", states, transitions,
+			[]gonfa.State{"End"}, nil)
+		require.Len(t, errs, 4)
+
+		err := checkStates("Start", states, transitions,
+			[]gonfa.State{"End"}, nil)
+		assert.EqualError(t, err, errs[0].Error())
+	})
+}
@@ -0,0 +1,63 @@
+package gonfa
+
+import "fmt"
+
+// HistoryDiff is a difference between two histories at the same index.
+// A or B is nil if the corresponding history is shorter than Index+1.
+type HistoryDiff struct {
+	Index int
+	A     *HistoryEntry
+	B     *HistoryEntry
+}
+
+// String returns the difference in a human-readable form, e.g.
+//
+//	#2: Review -Approve-> Approved != Review -Reject-> Rejected
+//	#3: Approved -Archive-> Archived (only in a)
+func (d HistoryDiff) String() string {
+	switch {
+	case d.B == nil:
+		return fmt.Sprintf("#%d: %s (only in a)", d.Index, formatEntry(*d.A))
+	case d.A == nil:
+		return fmt.Sprintf("#%d: %s (only in b)", d.Index, formatEntry(*d.B))
+	default:
+		return fmt.Sprintf("#%d: %s != %s",
+			d.Index, formatEntry(*d.A), formatEntry(*d.B))
+	}
+}
+
+// formatEntry formats the transition of the history entry.
+func formatEntry(e HistoryEntry) string {
+	return fmt.Sprintf("%s -%s-> %s", e.From, e.On, e.To)
+}
+
+// DiffHistory compares two histories entry by entry and returns the
+// differences in index order, so the first one is the point where the runs
+// diverged. Entries are equal if they have the same From, To and On;
+// timestamps are ignored. Entries missing in the shorter history are
+// reported as differences too. Returns nil if the histories are equal.
+//
+// It helps to find out why two machines running the same definition ended
+// up in different states, or to compare expected and actual runs in tests.
+func DiffHistory(a, b []HistoryEntry) []HistoryDiff {
+	var diffs []HistoryDiff
+
+	for i := range max(len(a), len(b)) {
+		d := HistoryDiff{Index: i}
+		if i < len(a) {
+			d.A = &a[i]
+		}
+		if i < len(b) {
+			d.B = &b[i]
+		}
+
+		if d.A != nil && d.B != nil &&
+			d.A.From == d.B.From && d.A.To == d.B.To && d.A.On == d.B.On {
+			continue
+		}
+
+		diffs = append(diffs, d)
+	}
+
+	return diffs
+}
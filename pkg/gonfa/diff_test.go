@@ -0,0 +1,54 @@
+package gonfa
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffHistory(t *testing.T) {
+	now := time.Now()
+	submit := HistoryEntry{
+		From: "Draft", To: "Review", On: "Submit", Timestamp: now}
+	approve := HistoryEntry{From: "Review", To: "Approved", On: "Approve"}
+	reject := HistoryEntry{From: "Review", To: "Rejected", On: "Reject"}
+	archive := HistoryEntry{From: "Approved", To: "Archived", On: "Archive"}
+
+	t.Run("equal", func(t *testing.T) {
+		later := submit
+		later.Timestamp = now.Add(time.Hour)
+
+		assert.Nil(t, DiffHistory(nil, nil))
+		assert.Nil(t, DiffHistory(
+			[]HistoryEntry{submit, approve}, []HistoryEntry{later, approve}))
+	})
+
+	t.Run("diverged", func(t *testing.T) {
+		diffs := DiffHistory(
+			[]HistoryEntry{submit, approve, archive},
+			[]HistoryEntry{submit, reject})
+		require.Len(t, diffs, 2)
+
+		assert.Equal(t, 1, diffs[0].Index)
+		assert.Equal(t, approve, *diffs[0].A)
+		assert.Equal(t, reject, *diffs[0].B)
+		assert.Equal(t,
+			"#1: Review -Approve-> Approved != Review -Reject-> Rejected",
+			diffs[0].String())
+
+		assert.Equal(t, 2, diffs[1].Index)
+		assert.Nil(t, diffs[1].B)
+		assert.Equal(t, "#2: Approved -Archive-> Archived (only in a)",
+			diffs[1].String())
+	})
+
+	t.Run("longer b", func(t *testing.T) {
+		diffs := DiffHistory(nil, []HistoryEntry{submit})
+		require.Len(t, diffs, 1)
+		assert.Nil(t, diffs[0].A)
+		assert.Equal(t, "#0: Draft -Submit-> Review (only in b)",
+			diffs[0].String())
+	})
+}
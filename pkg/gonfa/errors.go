@@ -0,0 +1,57 @@
+package gonfa
+
+import "errors"
+
+// TransitionError is returned by Machine.Fire when the event failed to
+// commit a transition: a precondition was violated, an action failed, the
+// context was done or, if the machine is configured to report rejections,
+// no transition passed its guards. Use errors.As to get the state and the
+// event, and errors.Is to check the cause.
+type TransitionError struct {
+	From  State // State the event was fired in
+	Event Event // Fired event
+	Err   error // Cause of the failure
+}
+
+// Error returns the message of the cause.
+func (e *TransitionError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the cause.
+func (e *TransitionError) Unwrap() error {
+	return e.Err
+}
+
+var (
+	// ErrRateLimited is returned by Machine.Fire when the event is rejected
+	// by the machine's rate limiter.
+	ErrRateLimited = errors.New("event rate limit exceeded")
+
+	// ErrUnknownEvent is returned by Machine.Fire for an event which isn't
+	// used in any transition of the definition, if the machine is configured
+	// to report it.
+	ErrUnknownEvent = errors.New("unknown event")
+
+	// ErrEventDeferred is returned by Machine.Fire when a DeferringGuard
+	// asked to retry the event later and the event was queued for a retry.
+	ErrEventDeferred = errors.New("event deferred")
+
+	// ErrNoTransition is the cause of a TransitionError when the current
+	// state has no enabled transition on the event. It's also reported by
+	// Machine.FireTransaction.
+	ErrNoTransition = errors.New("no transition")
+
+	// ErrGuardRejected is the cause of a TransitionError when guards
+	// rejected all candidate transitions.
+	ErrGuardRejected = errors.New("guard rejected the transition")
+
+	// ErrMachineClosed is the error of Machine.FireAsync outcomes for events
+	// submitted after the machine's async queue was closed.
+	ErrMachineClosed = errors.New("machine is closed")
+
+	// ErrQueueFull is the error of Machine.FireAsync outcomes for events
+	// submitted from the async queue worker (by guards, actions or hooks of
+	// an event it fires) while the queue is full.
+	ErrQueueFull = errors.New("async queue is full")
+)
@@ -0,0 +1,53 @@
+package gonfa
+
+import (
+	"context"
+	"reflect"
+)
+
+// extenderMethodGuard calls a boolean method of the state extender.
+type extenderMethodGuard struct {
+	method string
+}
+
+// ExtenderMethodGuard returns a guard which calls the method with the given
+// name on the machine's state extender (e.g. "IsComplete" for
+// doc.IsComplete()) and passes if it returns true. It lets a business
+// object expose workflow conditions as plain methods instead of guard
+// types.
+//
+// The method must be exported, take no arguments and return a single bool.
+// It's looked up in the method set of the extender's dynamic type, so a
+// method with a pointer receiver is found only if the extender is a
+// pointer. The guard rejects the transition if the extender is nil or has
+// no such method.
+//
+// The method is resolved through reflection on every check, which costs
+// much more than a direct call; write a guard type for hot paths.
+func ExtenderMethodGuard(methodName string) Guard {
+	return &extenderMethodGuard{method: methodName}
+}
+
+// Check implements Guard.
+func (g *extenderMethodGuard) Check(
+	_ context.Context,
+	state MachineState,
+	_ Payload,
+) bool {
+	ext := state.StateExtender()
+	if ext == nil {
+		return false
+	}
+
+	m := reflect.ValueOf(ext).MethodByName(g.method)
+	if !m.IsValid() {
+		return false
+	}
+
+	t := m.Type()
+	if t.NumIn() != 0 || t.NumOut() != 1 || t.Out(0).Kind() != reflect.Bool {
+		return false
+	}
+
+	return m.Call(nil)[0].Bool()
+}
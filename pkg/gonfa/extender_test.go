@@ -0,0 +1,59 @@
+package gonfa
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// extenderState is a MachineState carrying just the state extender.
+type extenderState struct {
+	ext StateExtender
+}
+
+func (s extenderState) CurrentState() State          { return "" }
+func (s extenderState) History() []HistoryEntry      { return nil }
+func (s extenderState) IsInFinalState() bool         { return false }
+func (s extenderState) StateExtender() StateExtender { return s.ext }
+func (s extenderState) VisitCount(State) int         { return 0 }
+
+type document struct {
+	pages int
+}
+
+func (d *document) IsComplete() bool { return d.pages > 0 }
+func (d *document) Pages() int       { return d.pages }
+func (d *document) HasPage(n int) bool {
+	return n <= d.pages
+}
+
+func TestExtenderMethodGuard(t *testing.T) {
+	ctx := context.Background()
+
+	check := func(ext StateExtender, method string) bool {
+		return ExtenderMethodGuard(method).Check(ctx, extenderState{ext}, nil)
+	}
+
+	t.Run("method result", func(t *testing.T) {
+		assert.True(t, check(&document{pages: 3}, "IsComplete"))
+		assert.False(t, check(&document{}, "IsComplete"))
+	})
+
+	t.Run("missing method", func(t *testing.T) {
+		assert.False(t, check(&document{pages: 3}, "IsApproved"))
+	})
+
+	t.Run("wrong signature", func(t *testing.T) {
+		assert.False(t, check(&document{pages: 3}, "Pages"))
+		assert.False(t, check(&document{pages: 3}, "HasPage"))
+	})
+
+	t.Run("pointer receiver on value", func(t *testing.T) {
+		assert.False(t, check(document{pages: 3}, "IsComplete"))
+	})
+
+	t.Run("nil extender", func(t *testing.T) {
+		assert.False(t, check(nil, "IsComplete"))
+	})
+}
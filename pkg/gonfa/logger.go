@@ -0,0 +1,11 @@
+package gonfa
+
+// Logger receives the diagnostics of a machine (see machine.WithLogger).
+// It's satisfied by most logging libraries directly or with a thin adapter.
+type Logger interface {
+	// Debugf logs the phases of a transition.
+	Debugf(format string, args ...any)
+
+	// Errorf logs failed transitions.
+	Errorf(format string, args ...any)
+}
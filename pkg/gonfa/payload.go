@@ -0,0 +1,36 @@
+package gonfa
+
+// PayloadBag is a Payload carrying several named values.
+// Guards and actions read the values with Get without asserting the whole
+// payload type. A PayloadBag isn't safe for concurrent modification.
+type PayloadBag map[string]any
+
+// NewPayloadBag creates an empty PayloadBag.
+func NewPayloadBag() PayloadBag {
+	return make(PayloadBag)
+}
+
+// Set stores the value under the key and returns the bag for chaining.
+func (b PayloadBag) Set(key string, value any) PayloadBag {
+	b[key] = value
+	return b
+}
+
+// Get returns the value stored under the key in the payload if the payload
+// is a PayloadBag and the value has type T. Otherwise it returns the zero
+// value of T and false.
+func Get[T any](payload Payload, key string) (T, bool) {
+	var zero T
+
+	bag, ok := payload.(PayloadBag)
+	if !ok {
+		return zero, false
+	}
+
+	v, ok := bag[key].(T)
+	if !ok {
+		return zero, false
+	}
+
+	return v, true
+}
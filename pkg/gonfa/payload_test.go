@@ -0,0 +1,42 @@
+package gonfa
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPayloadBag(t *testing.T) {
+	bag := NewPayloadBag().
+		Set("userID", 42).
+		Set("comment", "looks good")
+
+	t.Run("typed values", func(t *testing.T) {
+		id, ok := Get[int](bag, "userID")
+		assert.True(t, ok)
+		assert.Equal(t, 42, id)
+
+		comment, ok := Get[string](bag, "comment")
+		assert.True(t, ok)
+		assert.Equal(t, "looks good", comment)
+	})
+
+	t.Run("wrong type", func(t *testing.T) {
+		id, ok := Get[string](bag, "userID")
+		assert.False(t, ok)
+		assert.Empty(t, id)
+	})
+
+	t.Run("missing key", func(t *testing.T) {
+		_, ok := Get[int](bag, "missing")
+		assert.False(t, ok)
+	})
+
+	t.Run("payload isn't a bag", func(t *testing.T) {
+		_, ok := Get[int](Payload(42), "userID")
+		assert.False(t, ok)
+
+		_, ok = Get[int](nil, "userID")
+		assert.False(t, ok)
+	})
+}
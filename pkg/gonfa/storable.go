@@ -0,0 +1,65 @@
+package gonfa
+
+import "fmt"
+
+// ValidateStorable checks that the Storable is internally well-formed
+// regardless of any definition:
+//   - the current state isn't empty;
+//   - every history entry has non-empty From, To and On and a non-zero
+//     Timestamp;
+//   - the history is a contiguous chain, i.e. every entry starts where the
+//     previous one ended and the last one ends in the current state;
+//   - every pending async action has non-empty From, To and On, a
+//     non-negative Index and was started by a transition to the current
+//     state.
+//
+// It's a cheap check of persisted data before restoring a machine from it.
+func ValidateStorable(s *Storable) error {
+	if s == nil {
+		return fmt.Errorf("storable state cannot be nil")
+	}
+
+	if s.CurrentState == "" {
+		return fmt.Errorf("current state cannot be empty")
+	}
+
+	for i, h := range s.History {
+		switch {
+		case h.From == "":
+			return fmt.Errorf("history entry %d has empty from state", i)
+		case h.To == "":
+			return fmt.Errorf("history entry %d has empty to state", i)
+		case h.On == "":
+			return fmt.Errorf("history entry %d has empty event", i)
+		case h.Timestamp.IsZero():
+			return fmt.Errorf("history entry %d has zero timestamp", i)
+		}
+
+		if i > 0 && h.From != s.History[i-1].To {
+			return fmt.Errorf(
+				"history entry %d starts in '%s' but entry %d ended in '%s'",
+				i, h.From, i-1, s.History[i-1].To)
+		}
+	}
+
+	if n := len(s.History); n > 0 && s.History[n-1].To != s.CurrentState {
+		return fmt.Errorf(
+			"last history entry ends in '%s' but current state is '%s'",
+			s.History[n-1].To, s.CurrentState)
+	}
+
+	for i, p := range s.Pending {
+		switch {
+		case p.From == "" || p.To == "" || p.On == "":
+			return fmt.Errorf("pending action %d has empty transition", i)
+		case p.Index < 0:
+			return fmt.Errorf("pending action %d has negative index", i)
+		case p.To != s.CurrentState:
+			return fmt.Errorf(
+				"pending action %d waits in '%s' but current state is '%s'",
+				i, p.To, s.CurrentState)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,108 @@
+package gonfa
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateStorable(t *testing.T) {
+	now := time.Now()
+
+	valid := func() *Storable {
+		return &Storable{
+			CurrentState: "Done",
+			History: []HistoryEntry{
+				{From: "Draft", To: "Review", On: "Submit", Timestamp: now},
+				{From: "Review", To: "Done", On: "Approve", Timestamp: now},
+			},
+		}
+	}
+
+	t.Run("valid", func(t *testing.T) {
+		assert.NoError(t, ValidateStorable(valid()))
+		assert.NoError(t, ValidateStorable(&Storable{CurrentState: "Draft"}))
+	})
+
+	tests := []struct {
+		name   string
+		modify func(s *Storable)
+		errMsg string
+	}{
+		{
+			name:   "empty current state",
+			modify: func(s *Storable) { s.CurrentState = "" },
+			errMsg: "current state cannot be empty",
+		},
+		{
+			name:   "empty from",
+			modify: func(s *Storable) { s.History[0].From = "" },
+			errMsg: "history entry 0 has empty from state",
+		},
+		{
+			name:   "empty to",
+			modify: func(s *Storable) { s.History[1].To = "" },
+			errMsg: "history entry 1 has empty to state",
+		},
+		{
+			name:   "empty event",
+			modify: func(s *Storable) { s.History[1].On = "" },
+			errMsg: "history entry 1 has empty event",
+		},
+		{
+			name:   "zero timestamp",
+			modify: func(s *Storable) { s.History[0].Timestamp = time.Time{} },
+			errMsg: "history entry 0 has zero timestamp",
+		},
+		{
+			name:   "broken chain",
+			modify: func(s *Storable) { s.History[1].From = "Draft" },
+			errMsg: "history entry 1 starts in 'Draft' but entry 0 ended in 'Review'",
+		},
+		{
+			name:   "current state mismatch",
+			modify: func(s *Storable) { s.CurrentState = "Review" },
+			errMsg: "last history entry ends in 'Done' but current state is 'Review'",
+		},
+		{
+			name: "pending with empty event",
+			modify: func(s *Storable) {
+				s.Pending = []PendingAction{{From: "Review", To: "Done"}}
+			},
+			errMsg: "pending action 0 has empty transition",
+		},
+		{
+			name: "pending with negative index",
+			modify: func(s *Storable) {
+				s.Pending = []PendingAction{
+					{From: "Review", To: "Done", On: "Approve", Index: -1},
+				}
+			},
+			errMsg: "pending action 0 has negative index",
+		},
+		{
+			name: "pending in another state",
+			modify: func(s *Storable) {
+				s.Pending = []PendingAction{
+					{From: "Draft", To: "Review", On: "Submit"},
+				}
+			},
+			errMsg: "pending action 0 waits in 'Review' but current state is 'Done'",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := valid()
+			tt.modify(s)
+
+			err := ValidateStorable(s)
+			assert.EqualError(t, err, tt.errMsg)
+		})
+	}
+
+	t.Run("nil", func(t *testing.T) {
+		assert.Error(t, ValidateStorable(nil))
+	})
+}
@@ -15,15 +15,49 @@ package gonfa
 
 import (
 	"context"
+	"fmt"
+	"strings"
 	"time"
 )
 
 // State represents a state in the state machine.
 type State string
 
+// String returns the state name.
+func (s State) String() string {
+	return string(s)
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (s State) MarshalText() ([]byte, error) {
+	return []byte(s), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (s *State) UnmarshalText(text []byte) error {
+	*s = State(text)
+	return nil
+}
+
 // Event represents an event that triggers a transition.
 type Event string
 
+// String returns the event name.
+func (e Event) String() string {
+	return string(e)
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (e Event) MarshalText() ([]byte, error) {
+	return []byte(e), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (e *Event) UnmarshalText(text []byte) error {
+	*e = Event(text)
+	return nil
+}
+
 // Payload is an interface for passing event-specific runtime data.
 type Payload interface{}
 
@@ -40,6 +74,9 @@ type MachineState interface {
 	IsInFinalState() bool
 	// StateExtender returns the attached user-defined business object.
 	StateExtender() StateExtender
+	// VisitCount returns how many times the machine has entered the state
+	// according to its history.
+	VisitCount(s State) int
 }
 
 // Guard is the interface for guard objects.
@@ -50,6 +87,45 @@ type Guard interface {
 	Check(ctx context.Context, state MachineState, payload Payload) bool
 }
 
+// ConstantGuard is an optional interface for guards whose result never
+// depends on the context, machine state or payload (e.g. a feature switch
+// turned off at compile time). It lets validation tools detect branches that
+// can never be taken.
+type ConstantGuard interface {
+	Guard
+	// ConstantResult returns the value Check always returns.
+	ConstantResult() bool
+}
+
+// DeferringGuard is an optional interface for guards which may be unable to
+// decide yet (e.g. the data they check isn't ready). When the machine fires
+// an event it calls CheckDefer instead of Check.
+type DeferringGuard interface {
+	Guard
+	// CheckDefer evaluates the transition like Check. If deferred is true,
+	// pass is ignored and the event is queued to be retried later instead
+	// of being rejected.
+	CheckDefer(
+		ctx context.Context,
+		state MachineState,
+		payload Payload,
+	) (pass bool, deferred bool)
+}
+
+// ErrGuard is an optional interface for guards which may fail to evaluate
+// the transition (e.g. a database query failed). When the machine fires an
+// event it calls CheckErr instead of Check (and CheckDefer), and an error
+// aborts the Fire instead of rejecting the transition.
+type ErrGuard interface {
+	Guard
+	// CheckErr evaluates the transition like Check. If err isn't nil,
+	// pass is ignored.
+	CheckErr(ctx context.Context, state MachineState, payload Payload) (
+		pass bool,
+		err error,
+	)
+}
+
 // Action is the interface for action and hook objects.
 // Actions are executed during transitions, state entry/exit, or as hooks.
 type Action interface {
@@ -58,12 +134,48 @@ type Action interface {
 	Execute(ctx context.Context, state MachineState, payload Payload) error
 }
 
+// Initializable is an optional interface for guards and actions that need
+// setup (e.g. opening a DB connection) before they are used.
+//
+// An object shared by several definitions (e.g. a registry instance, or
+// definitions derived with Merge, Minimize, Clone or builder fragments) is
+// owned by all of them: Init is called by the first one created and Close
+// (see Closable) by the last one closed, so every definition should be
+// closed once discarded. Objects are told apart by identity, so only
+// comparable ones (e.g. pointers) can be shared; other objects are
+// initialized and closed by every definition they're bound into. Init and
+// Close calls are serialized across all definitions, so they must not
+// create or close definitions themselves.
+type Initializable interface {
+	Init() error
+}
+
+// Closable is an optional interface for guards and actions that hold
+// resources which must be released when the Definition is discarded.
+// See Initializable for objects shared by several definitions.
+type Closable interface {
+	Close() error
+}
+
+// Composite is an optional interface for guards and actions built of other
+// guards and actions (e.g. guard combinators). A Definition manages the
+// lifecycle (see Initializable and Closable) of the parts too.
+type Composite interface {
+	// Parts returns the guards and actions the object is built of.
+	Parts() []any
+}
+
 // HistoryEntry records a single transition in the machine's history.
 type HistoryEntry struct {
 	From      State     `json:"from"`
 	To        State     `json:"to"`
 	On        Event     `json:"on"`
 	Timestamp time.Time `json:"timestamp"`
+
+	// Label is the human-readable label of the transition (see
+	// definition.Transition.Label), if any. On always keeps the raw event,
+	// so histories can be replayed.
+	Label string `json:"label,omitempty"`
 }
 
 // Storable represents a serializable state of a Machine instance.
@@ -71,4 +183,117 @@ type HistoryEntry struct {
 type Storable struct {
 	CurrentState State          `json:"currentState"`
 	History      []HistoryEntry `json:"history"`
+
+	// Pending lists the async actions which were in flight in the current
+	// state when the machine was marshaled.
+	Pending []PendingAction `json:"pending,omitempty"`
+}
+
+// TransitionResult describes the transition committed by Machine.FireResult.
+// Index is the position of the transition in the list returned by
+// definition.Definition.GetTransitions for From and On, so it tells apart
+// competing transitions, even ones with the same target.
+type TransitionResult struct {
+	From  State `json:"from"`
+	To    State `json:"to"`
+	On    Event `json:"on"`
+	Index int   `json:"index"`
+}
+
+// FireDiagnostic explains the outcome of an event fired by
+// Machine.FireExplain: the candidate transitions attempted from the state
+// in the order they were attempted. No candidates means that the state has
+// no enabled transition on the event.
+type FireDiagnostic struct {
+	From       State                 `json:"from"`
+	Event      Event                 `json:"event"`
+	Candidates []CandidateDiagnostic `json:"candidates,omitempty"`
+}
+
+// CandidateDiagnostic is the outcome of a candidate transition attempted by
+// Machine.FireExplain. A candidate neither committed nor blocked by a guard
+// failed with the error returned by FireExplain (e.g. a precondition was
+// violated or an action failed).
+type CandidateDiagnostic struct {
+	To State `json:"to"`
+
+	// Index is the position of the transition in the list returned by
+	// definition.Definition.GetTransitions.
+	Index int `json:"index"`
+
+	// GuardIndex is the index of the guard which rejected or deferred the
+	// transition, -1 if no guard did; Guard is its name.
+	GuardIndex int    `json:"guardIndex"`
+	Guard      string `json:"guard,omitempty"`
+
+	Deferred  bool `json:"deferred,omitempty"`
+	Committed bool `json:"committed,omitempty"`
+}
+
+// String returns a human-readable explanation, a line per candidate.
+func (d FireDiagnostic) String() string {
+	if len(d.Candidates) == 0 {
+		return fmt.Sprintf("no transition defined for event '%s' in state '%s'",
+			d.Event, d.From)
+	}
+
+	lines := make([]string, len(d.Candidates))
+	for i, c := range d.Candidates {
+		outcome := "failed"
+		switch {
+		case c.Committed:
+			outcome = "committed"
+		case c.GuardIndex >= 0 && c.Deferred:
+			outcome = fmt.Sprintf("deferred by guard #%d '%s'",
+				c.GuardIndex, c.Guard)
+		case c.GuardIndex >= 0:
+			outcome = fmt.Sprintf("rejected by guard #%d '%s'",
+				c.GuardIndex, c.Guard)
+		}
+
+		lines[i] = fmt.Sprintf("%s->%s on %s (#%d): %s",
+			d.From, c.To, d.Event, c.Index, outcome)
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// Snapshot is a consistent view of a machine taken by Machine.Snapshot.
+// LastTransition is the last history entry, nil if the history is empty.
+// HistoryLen counts the entries kept in the history, so it's capped if
+// the machine's history is bounded.
+type Snapshot struct {
+	CurrentState   State         `json:"currentState"`
+	IsFinal        bool          `json:"isFinal"`
+	HistoryLen     int           `json:"historyLen"`
+	LastTransition *HistoryEntry `json:"lastTransition,omitempty"`
+}
+
+// FireEvent describes a completed Fire attempt for the machine listeners
+// (see machine.Machine.AddListener). To is empty and Success is false if no
+// transition was committed; Err is the error Fire returned, if any.
+type FireEvent struct {
+	Event   Event
+	From    State
+	To      State
+	Success bool
+	Err     error
+}
+
+// FireOutcome is the outcome of an event fired by Machine.FireAsync: the
+// committed transition, nil if there is none, and the error, as they would
+// be returned by Machine.FireResult.
+type FireOutcome struct {
+	Result *TransitionResult
+	Err    error
+}
+
+// PendingAction identifies an in-flight async action by the transition
+// which started it and the action's index among the transition's async
+// actions.
+type PendingAction struct {
+	From  State `json:"from"`
+	To    State `json:"to"`
+	On    Event `json:"on"`
+	Index int   `json:"index"`
 }
@@ -0,0 +1,72 @@
+package gonfa
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	_ encoding.TextMarshaler   = State("")
+	_ encoding.TextUnmarshaler = (*State)(nil)
+	_ encoding.TextMarshaler   = Event("")
+	_ encoding.TextUnmarshaler = (*Event)(nil)
+)
+
+func TestStateEventText(t *testing.T) {
+	t.Run("String", func(t *testing.T) {
+		assert.Equal(t, "Draft", State("Draft").String())
+		assert.Equal(t, "Submit", Event("Submit").String())
+	})
+
+	t.Run("JSON map keys round trip", func(t *testing.T) {
+		visits := map[State]int{"Draft": 1, "Review": 2}
+		counts := map[Event]int{"Submit": 3}
+
+		data, err := json.Marshal(struct {
+			Visits map[State]int `json:"visits"`
+			Counts map[Event]int `json:"counts"`
+		}{visits, counts})
+		require.NoError(t, err)
+		assert.JSONEq(t,
+			`{"visits":{"Draft":1,"Review":2},"counts":{"Submit":3}}`,
+			string(data))
+
+		var decoded struct {
+			Visits map[State]int `json:"visits"`
+			Counts map[Event]int `json:"counts"`
+		}
+		require.NoError(t, json.Unmarshal(data, &decoded))
+		assert.Equal(t, visits, decoded.Visits)
+		assert.Equal(t, counts, decoded.Counts)
+	})
+
+	t.Run("Storable JSON is unchanged", func(t *testing.T) {
+		ts := time.Date(2025, 9, 24, 0, 0, 0, 0, time.UTC)
+		data, err := json.Marshal(Storable{
+			CurrentState: "Review",
+			History: []HistoryEntry{
+				{From: "Draft", To: "Review", On: "Submit", Timestamp: ts},
+			},
+		})
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"currentState":"Review","history":[`+
+			`{"from":"Draft","to":"Review","on":"Submit",`+
+			`"timestamp":"2025-09-24T00:00:00Z"}]}`, string(data))
+	})
+
+	t.Run("slog", func(t *testing.T) {
+		var buf bytes.Buffer
+		logger := slog.New(slog.NewJSONHandler(&buf, nil))
+		logger.Info("transition", "from", State("Draft"), "on", Event("Submit"))
+
+		assert.Contains(t, buf.String(), `"from":"Draft"`)
+		assert.Contains(t, buf.String(), `"on":"Submit"`)
+	})
+}
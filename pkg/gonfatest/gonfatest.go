@@ -0,0 +1,130 @@
+// Package gonfatest provides helpers for testing state machines and their
+// guards and actions.
+//
+// goNFA is a universal, lightweight and idiomatic Go library for creating
+// and managing non-deterministic finite automata (NFA). It provides reliable
+// state management mechanisms for complex systems such as business process
+// engines (BPM).
+//
+// Project: https://github.com/dr-dobermann/gonfa
+// Author: dr-dobermann (rgabtiov@gmail.com)
+// License: LGPL-2.1 (see LICENSE file in the project root)
+package gonfatest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/dr-dobermann/gonfa/pkg/gonfa"
+	"github.com/dr-dobermann/gonfa/pkg/machine"
+)
+
+// RunConcurrently stress-tests the machine by firing the events from the
+// given number of goroutines at once; every goroutine fires all the events
+// in order with a nil payload. It checks that the user's guards and actions
+// keep the machine consistent under concurrency and returns the joined
+// inconsistencies found:
+//   - a panic in Fire (e.g. in a guard or an action);
+//   - a current state or a history state not found in the definition;
+//   - a history which isn't a contiguous chain ending in the current state
+//     (see gonfa.ValidateStorable).
+//
+// Errors returned by Fire aren't inconsistencies, since guards and actions
+// may fail legitimately, so they are ignored.
+func RunConcurrently(
+	m *machine.Machine,
+	events []gonfa.Event,
+	goroutines int,
+) error {
+	if m == nil {
+		return fmt.Errorf("machine cannot be nil")
+	}
+
+	if goroutines < 1 {
+		return fmt.Errorf("goroutines must be positive, got %d", goroutines)
+	}
+
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs []error
+	)
+
+	report := func(err error) {
+		mu.Lock()
+		errs = append(errs, err)
+		mu.Unlock()
+	}
+
+	for g := range goroutines {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for _, e := range events {
+				if err := fire(m, e); err != nil {
+					report(fmt.Errorf("goroutine %d: %w", g, err))
+				}
+
+				if err := checkState(m, m.CurrentState()); err != nil {
+					report(fmt.Errorf("goroutine %d: %w", g, err))
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	if err := checkHistory(m); err != nil {
+		errs = append(errs, err)
+	}
+
+	return errors.Join(errs...)
+}
+
+// fire fires the event recovering a panic.
+func fire(m *machine.Machine, e gonfa.Event) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic firing event '%s': %v", e, r)
+		}
+	}()
+
+	_, _ = m.Fire(context.Background(), e, nil)
+
+	return nil
+}
+
+// checkState checks that the state exists in the machine's definition.
+func checkState(m *machine.Machine, s gonfa.State) error {
+	if _, ok := m.Definition().States()[s]; !ok {
+		return fmt.Errorf("state '%s' not found in definition", s)
+	}
+
+	return nil
+}
+
+// checkHistory checks that the machine's history is a contiguous chain of
+// known states ending in the current state.
+func checkHistory(m *machine.Machine) error {
+	stored, err := m.Marshal()
+	if err != nil {
+		return fmt.Errorf("failed to marshal machine: %w", err)
+	}
+
+	if err := gonfa.ValidateStorable(stored); err != nil {
+		return fmt.Errorf("inconsistent history: %w", err)
+	}
+
+	for i, h := range stored.History {
+		for _, s := range []gonfa.State{h.From, h.To} {
+			if err := checkState(m, s); err != nil {
+				return fmt.Errorf("history entry %d: %w", i, err)
+			}
+		}
+	}
+
+	return nil
+}
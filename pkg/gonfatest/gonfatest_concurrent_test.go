@@ -0,0 +1,80 @@
+package gonfatest
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dr-dobermann/gonfa/pkg/builder"
+	"github.com/dr-dobermann/gonfa/pkg/gonfa"
+	"github.com/dr-dobermann/gonfa/pkg/machine"
+)
+
+// countingAction counts its calls and panics on the given call.
+type countingAction struct {
+	calls   atomic.Int64
+	panicOn int64
+}
+
+func (a *countingAction) Execute(
+	context.Context,
+	gonfa.MachineState,
+	gonfa.Payload,
+) error {
+	if a.calls.Add(1) == a.panicOn {
+		panic("not thread-safe")
+	}
+
+	return nil
+}
+
+func createPingPong(t *testing.T, action gonfa.Action) *machine.Machine {
+	def, err := builder.New().
+		InitialState("Ping").
+		FinalStates("End").
+		AddTransition("Ping", "Pong", "Hit").
+		WithActions(action).
+		AddTransition("Pong", "Ping", "Hit").
+		AddTransition("Pong", "End", "Stop").
+		Build()
+	require.NoError(t, err)
+
+	m, err := machine.New(def)
+	require.NoError(t, err)
+
+	return m
+}
+
+func TestRunConcurrently(t *testing.T) {
+	events := []gonfa.Event{"Hit", "Hit", "Hit", "Unknown"}
+
+	t.Run("consistent", func(t *testing.T) {
+		action := &countingAction{}
+		m := createPingPong(t, action)
+
+		require.NoError(t, RunConcurrently(m, events, 8))
+
+		history := m.History()
+		assert.Len(t, history, 8*3)
+		assert.Equal(t, int64(8*3/2), action.calls.Load())
+	})
+
+	t.Run("panic", func(t *testing.T) {
+		m := createPingPong(t, &countingAction{panicOn: 3})
+
+		err := RunConcurrently(m, events, 4)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "panic firing event 'Hit': not thread-safe")
+	})
+
+	t.Run("invalid arguments", func(t *testing.T) {
+		assert.EqualError(t, RunConcurrently(nil, events, 1),
+			"machine cannot be nil")
+		assert.EqualError(t,
+			RunConcurrently(createPingPong(t, &countingAction{}), events, 0),
+			"goroutines must be positive, got 0")
+	})
+}
@@ -0,0 +1,44 @@
+// Package guards provides ready-to-use guards for common conditions
+// which don't depend on the business object of the machine.
+//
+// goNFA is a universal, lightweight and idiomatic Go library for creating
+// and managing non-deterministic finite automata (NFA). It provides reliable
+// state management mechanisms for complex systems such as business process
+// engines (BPM).
+//
+// Project: https://github.com/dr-dobermann/gonfa
+// Author: dr-dobermann (rgabtiov@gmail.com)
+// License: LGPL-2.1 (see LICENSE file in the project root)
+package guards
+
+import (
+	"context"
+
+	"github.com/dr-dobermann/gonfa/pkg/gonfa"
+)
+
+// maxTransitions rejects transitions once the history reaches the limit.
+type maxTransitions struct {
+	limit int
+}
+
+// MaxTransitions returns a guard which passes only while the machine
+// history has fewer than n entries, i.e. it caps the total length of a
+// workflow run. It's a safety valve against runaway loops in cyclic
+// definitions. A restored machine whose history already reaches n is
+// blocked immediately.
+//
+// The guard counts the entries kept by the machine, so with a bounded
+// history (machine.WithHistoryEviction) n must not exceed the history cap.
+func MaxTransitions(n int) gonfa.Guard {
+	return &maxTransitions{limit: n}
+}
+
+// Check implements gonfa.Guard.
+func (g *maxTransitions) Check(
+	_ context.Context,
+	state gonfa.MachineState,
+	_ gonfa.Payload,
+) bool {
+	return len(state.History()) < g.limit
+}
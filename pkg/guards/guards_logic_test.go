@@ -0,0 +1,241 @@
+package guards
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dr-dobermann/gonfa/pkg/builder"
+	"github.com/dr-dobermann/gonfa/pkg/gonfa"
+	"github.com/dr-dobermann/gonfa/pkg/machine"
+)
+
+// countingGuard returns a fixed result and counts its calls.
+type countingGuard struct {
+	result bool
+	err    error
+	calls  int
+}
+
+func (g *countingGuard) Check(
+	ctx context.Context,
+	state gonfa.MachineState,
+	payload gonfa.Payload,
+) bool {
+	g.calls++
+	return g.result
+}
+
+// failingGuard is a countingGuard which implements gonfa.ErrGuard.
+type failingGuard struct {
+	countingGuard
+}
+
+func (g *failingGuard) CheckErr(
+	ctx context.Context,
+	state gonfa.MachineState,
+	payload gonfa.Payload,
+) (bool, error) {
+	g.calls++
+	return g.result, g.err
+}
+
+func guardCalls(guards ...*countingGuard) []int {
+	calls := make([]int, len(guards))
+	for i, g := range guards {
+		calls[i] = g.calls
+	}
+
+	return calls
+}
+
+func TestAnd(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("all pass", func(t *testing.T) {
+		a, b := &countingGuard{result: true}, &countingGuard{result: true}
+
+		assert.True(t, And(a, b).Check(ctx, nil, nil))
+		assert.Equal(t, []int{1, 1}, guardCalls(a, b))
+	})
+
+	t.Run("stops at the first false", func(t *testing.T) {
+		a, b, c := &countingGuard{result: true},
+			&countingGuard{result: false},
+			&countingGuard{result: true}
+
+		assert.False(t, And(a, b, c).Check(ctx, nil, nil))
+		assert.Equal(t, []int{1, 1, 0}, guardCalls(a, b, c))
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		assert.True(t, And().Check(ctx, nil, nil))
+	})
+}
+
+func TestOr(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("stops at the first true", func(t *testing.T) {
+		a, b, c := &countingGuard{result: false},
+			&countingGuard{result: true},
+			&countingGuard{result: false}
+
+		assert.True(t, Or(a, b, c).Check(ctx, nil, nil))
+		assert.Equal(t, []int{1, 1, 0}, guardCalls(a, b, c))
+	})
+
+	t.Run("none pass", func(t *testing.T) {
+		a, b := &countingGuard{result: false}, &countingGuard{result: false}
+
+		assert.False(t, Or(a, b).Check(ctx, nil, nil))
+		assert.Equal(t, []int{1, 1}, guardCalls(a, b))
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		assert.False(t, Or().Check(ctx, nil, nil))
+	})
+}
+
+func TestNot(t *testing.T) {
+	ctx := context.Background()
+
+	assert.False(t, Not(&countingGuard{result: true}).Check(ctx, nil, nil))
+	assert.True(t, Not(&countingGuard{result: false}).Check(ctx, nil, nil))
+
+	// (a || b) && !c
+	a, b, c := &countingGuard{result: true},
+		&countingGuard{result: true},
+		&countingGuard{result: false}
+	assert.True(t, And(Or(a, b), Not(c)).Check(ctx, nil, nil))
+	assert.Equal(t, []int{1, 0, 1}, guardCalls(a, b, c))
+}
+
+func TestLogicErrors(t *testing.T) {
+	ctx := context.Background()
+	errLookup := errors.New("lookup failed")
+
+	tests := []struct {
+		name  string
+		guard func(failing gonfa.Guard, next *countingGuard) gonfa.Guard
+	}{
+		{"And", func(f gonfa.Guard, n *countingGuard) gonfa.Guard {
+			return And(f, n)
+		}},
+		{"Or", func(f gonfa.Guard, n *countingGuard) gonfa.Guard {
+			return Or(f, n)
+		}},
+		{"Not", func(f gonfa.Guard, _ *countingGuard) gonfa.Guard {
+			return Not(f)
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			failing := &failingGuard{countingGuard{err: errLookup}}
+			next := &countingGuard{result: true}
+			guard := tt.guard(failing, next)
+
+			eg, ok := guard.(gonfa.ErrGuard)
+			require.True(t, ok)
+
+			pass, err := eg.CheckErr(ctx, nil, nil)
+			assert.False(t, pass)
+			require.ErrorIs(t, err, errLookup)
+			assert.Zero(t, next.calls, "the evaluation stops at the error")
+
+			// Check rejects the transition
+			assert.False(t, guard.Check(ctx, nil, nil))
+		})
+	}
+}
+
+// lifecycleGuard records Init and Close calls.
+type lifecycleGuard struct {
+	countingGuard
+	journal []string
+}
+
+func (g *lifecycleGuard) Init() error {
+	g.journal = append(g.journal, "init")
+	return nil
+}
+
+func (g *lifecycleGuard) Close() error {
+	g.journal = append(g.journal, "close")
+	return nil
+}
+
+func TestLogicLifecycle(t *testing.T) {
+	inner := &lifecycleGuard{}
+
+	def, err := builder.New().
+		InitialState("Start").
+		FinalStates("End").
+		AddTransition("Start", "End", "Finish").
+		WithGuards(Not(And(Or(inner)))).
+		Build()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"init"}, inner.journal)
+
+	require.NoError(t, def.Close())
+	assert.Equal(t, []string{"init", "close"}, inner.journal)
+}
+
+// undecidedGuard can't decide yet and defers the event.
+type undecidedGuard struct{}
+
+func (undecidedGuard) Check(
+	ctx context.Context,
+	state gonfa.MachineState,
+	payload gonfa.Payload,
+) bool {
+	return false
+}
+
+func (undecidedGuard) CheckDefer(
+	ctx context.Context,
+	state gonfa.MachineState,
+	payload gonfa.Payload,
+) (bool, bool) {
+	return false, true
+}
+
+func TestLogicDeferringGuard(t *testing.T) {
+	ctx := context.Background()
+
+	create := func(t *testing.T, guard gonfa.Guard) *machine.Machine {
+		def, err := builder.New().
+			InitialState("Start").
+			FinalStates("End").
+			AddTransition("Start", "End", "Finish").
+			WithGuards(guard).
+			Build()
+		require.NoError(t, err)
+
+		m, err := machine.New(def)
+		require.NoError(t, err)
+
+		return m
+	}
+
+	t.Run("direct guard defers", func(t *testing.T) {
+		m := create(t, undecidedGuard{})
+
+		ok, err := m.Fire(ctx, "Finish", nil)
+		assert.False(t, ok)
+		assert.ErrorIs(t, err, gonfa.ErrEventDeferred)
+	})
+
+	t.Run("wrapped guard is evaluated with Check", func(t *testing.T) {
+		m := create(t, And(&countingGuard{result: true}, undecidedGuard{}))
+
+		ok, err := m.Fire(ctx, "Finish", nil)
+		require.NoError(t, err)
+		assert.False(t, ok)
+		assert.Empty(t, m.DeferredEvents())
+	})
+}
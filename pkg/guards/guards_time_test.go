@@ -0,0 +1,98 @@
+package guards
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/dr-dobermann/gonfa/pkg/gonfa"
+)
+
+// clockState is a machine state with a fixed current time.
+type clockState struct {
+	gonfa.MachineState
+	now time.Time
+}
+
+func (s clockState) Now() time.Time {
+	return s.now
+}
+
+func TestWithinTimeWindow(t *testing.T) {
+	start := time.Date(2025, 11, 1, 0, 0, 0, 0, time.UTC)
+	end := start.Add(24 * time.Hour)
+	guard := WithinTimeWindow(start, end)
+
+	tests := []struct {
+		name     string
+		now      time.Time
+		expected bool
+	}{
+		{"before", start.Add(-time.Second), false},
+		{"at start", start, true},
+		{"inside", start.Add(12 * time.Hour), true},
+		{"at end", end, false},
+		{"after", end.Add(time.Hour), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected,
+				guard.Check(context.Background(), clockState{now: tt.now}, nil))
+		})
+	}
+
+	t.Run("empty window", func(t *testing.T) {
+		assert.False(t, WithinTimeWindow(end, start).Check(
+			context.Background(), clockState{now: start}, nil))
+	})
+
+	t.Run("system clock", func(t *testing.T) {
+		guard := WithinTimeWindow(time.Now().Add(-time.Hour),
+			time.Now().Add(time.Hour))
+		assert.True(t, guard.Check(context.Background(), nil, nil))
+	})
+}
+
+func TestDuringHours(t *testing.T) {
+	cet := time.FixedZone("CET", 60*60)
+
+	// at returns the UTC time of the given hour in CET
+	at := func(hour int) time.Time {
+		return time.Date(2025, 1, 15, hour, 30, 0, 0, cet).UTC()
+	}
+
+	tests := []struct {
+		name       string
+		start, end int
+		hour       int
+		expected   bool
+	}{
+		{"business hours, inside", 9, 18, 9, true},
+		{"business hours, last hour", 9, 18, 17, true},
+		{"business hours, end", 9, 18, 18, false},
+		{"business hours, before", 9, 18, 8, false},
+		{"night, late evening", 22, 6, 23, true},
+		{"night, early morning", 22, 6, 5, true},
+		{"night, morning", 22, 6, 6, false},
+		{"night, afternoon", 22, 6, 15, false},
+		{"all day", 0, 24, 0, true},
+		{"empty", 10, 10, 10, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			guard := DuringHours(tt.start, tt.end, cet)
+			assert.Equal(t, tt.expected, guard.Check(
+				context.Background(), clockState{now: at(tt.hour)}, nil))
+		})
+	}
+
+	t.Run("location matters", func(t *testing.T) {
+		// 9:30 CET is 8:30 UTC
+		assert.False(t, DuringHours(9, 18, time.UTC).Check(
+			context.Background(), clockState{now: at(9)}, nil))
+	})
+}
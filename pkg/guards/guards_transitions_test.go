@@ -0,0 +1,81 @@
+package guards
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dr-dobermann/gonfa/pkg/builder"
+	"github.com/dr-dobermann/gonfa/pkg/definition"
+	"github.com/dr-dobermann/gonfa/pkg/gonfa"
+	"github.com/dr-dobermann/gonfa/pkg/machine"
+)
+
+func createLoopDefinition(t *testing.T, guard gonfa.Guard) *definition.Definition {
+	def, err := builder.New().
+		InitialState("Ping").
+		FinalStates("End").
+		AddTransition("Ping", "Pong", "Hit").
+		WithGuards(guard).
+		AddTransition("Pong", "Ping", "Hit").
+		WithGuards(guard).
+		AddTransition("Pong", "End", "Stop").
+		Build()
+	require.NoError(t, err)
+
+	return def
+}
+
+func TestMaxTransitions(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("stops the loop", func(t *testing.T) {
+		m, err := machine.New(createLoopDefinition(t, MaxTransitions(3)), nil)
+		require.NoError(t, err)
+
+		for range 3 {
+			ok, err := m.Fire(ctx, "Hit", nil)
+			require.NoError(t, err)
+			assert.True(t, ok)
+		}
+
+		ok, err := m.Fire(ctx, "Hit", nil)
+		require.NoError(t, err)
+		assert.False(t, ok)
+		assert.Len(t, m.History(), 3)
+	})
+
+	t.Run("restored machine over the limit", func(t *testing.T) {
+		now := time.Now()
+		storable := &gonfa.Storable{
+			CurrentState: "Ping",
+			History: []gonfa.HistoryEntry{
+				{From: "Ping", To: "Pong", On: "Hit", Timestamp: now},
+				{From: "Pong", To: "Ping", On: "Hit", Timestamp: now},
+				{From: "Ping", To: "Pong", On: "Hit", Timestamp: now},
+				{From: "Pong", To: "Ping", On: "Hit", Timestamp: now},
+			},
+		}
+
+		m, err := machine.Restore(
+			createLoopDefinition(t, MaxTransitions(2)), storable, nil)
+		require.NoError(t, err)
+
+		ok, err := m.Fire(ctx, "Hit", nil)
+		require.NoError(t, err)
+		assert.False(t, ok)
+		assert.Equal(t, gonfa.State("Ping"), m.CurrentState())
+	})
+
+	t.Run("zero limit blocks everything", func(t *testing.T) {
+		m, err := machine.New(createLoopDefinition(t, MaxTransitions(0)), nil)
+		require.NoError(t, err)
+
+		ok, err := m.Fire(ctx, "Hit", nil)
+		require.NoError(t, err)
+		assert.False(t, ok)
+	})
+}
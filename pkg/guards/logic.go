@@ -0,0 +1,176 @@
+package guards
+
+import (
+	"context"
+
+	"github.com/dr-dobermann/gonfa/pkg/gonfa"
+)
+
+// and passes if all its guards pass.
+type and struct {
+	guards []gonfa.Guard
+}
+
+// And returns a guard which passes if all the guards pass. The guards are
+// evaluated in order with the same context, machine state and payload, and
+// the evaluation stops at the first guard which rejects the transition.
+// And without guards always passes.
+//
+// The returned guard implements gonfa.ErrGuard: an error of a guard
+// implementing it stops the evaluation and is returned to the machine. It
+// also implements gonfa.Composite, so a definition initializes and closes
+// the guards.
+//
+// Deferral isn't propagated: a gonfa.DeferringGuard is evaluated with Check,
+// so it can't defer the event from within a combinator. Add deferring
+// guards to the transition directly.
+func And(guards ...gonfa.Guard) gonfa.Guard {
+	return &and{guards: guards}
+}
+
+// Check implements gonfa.Guard.
+func (g *and) Check(
+	ctx context.Context,
+	state gonfa.MachineState,
+	payload gonfa.Payload,
+) bool {
+	pass, err := g.CheckErr(ctx, state, payload)
+
+	return pass && err == nil
+}
+
+// CheckErr implements gonfa.ErrGuard.
+func (g *and) CheckErr(
+	ctx context.Context,
+	state gonfa.MachineState,
+	payload gonfa.Payload,
+) (bool, error) {
+	for _, guard := range g.guards {
+		if pass, err := check(ctx, guard, state, payload); err != nil || !pass {
+			return false, err
+		}
+	}
+
+	return true, nil
+}
+
+// Parts implements gonfa.Composite.
+func (g *and) Parts() []any {
+	return parts(g.guards...)
+}
+
+// or passes if any of its guards passes.
+type or struct {
+	guards []gonfa.Guard
+}
+
+// Or returns a guard which passes if any of the guards passes. The guards
+// are evaluated in order with the same context, machine state and payload,
+// and the evaluation stops at the first guard which passes. Or without
+// guards always rejects.
+//
+// Like And, the returned guard implements gonfa.ErrGuard and
+// gonfa.Composite.
+func Or(guards ...gonfa.Guard) gonfa.Guard {
+	return &or{guards: guards}
+}
+
+// Check implements gonfa.Guard.
+func (g *or) Check(
+	ctx context.Context,
+	state gonfa.MachineState,
+	payload gonfa.Payload,
+) bool {
+	pass, err := g.CheckErr(ctx, state, payload)
+
+	return pass && err == nil
+}
+
+// CheckErr implements gonfa.ErrGuard.
+func (g *or) CheckErr(
+	ctx context.Context,
+	state gonfa.MachineState,
+	payload gonfa.Payload,
+) (bool, error) {
+	for _, guard := range g.guards {
+		if pass, err := check(ctx, guard, state, payload); err != nil || pass {
+			return pass && err == nil, err
+		}
+	}
+
+	return false, nil
+}
+
+// Parts implements gonfa.Composite.
+func (g *or) Parts() []any {
+	return parts(g.guards...)
+}
+
+// not inverts its guard.
+type not struct {
+	guard gonfa.Guard
+}
+
+// Not returns a guard which passes if the guard rejects the transition.
+//
+// Like And, the returned guard implements gonfa.ErrGuard and
+// gonfa.Composite, and an error of the guard isn't inverted: Not rejects
+// the transition with it.
+func Not(guard gonfa.Guard) gonfa.Guard {
+	return &not{guard: guard}
+}
+
+// Check implements gonfa.Guard.
+func (g *not) Check(
+	ctx context.Context,
+	state gonfa.MachineState,
+	payload gonfa.Payload,
+) bool {
+	pass, err := g.CheckErr(ctx, state, payload)
+
+	return pass && err == nil
+}
+
+// CheckErr implements gonfa.ErrGuard.
+func (g *not) CheckErr(
+	ctx context.Context,
+	state gonfa.MachineState,
+	payload gonfa.Payload,
+) (bool, error) {
+	pass, err := check(ctx, g.guard, state, payload)
+	if err != nil {
+		return false, err
+	}
+
+	return !pass, nil
+}
+
+// Parts implements gonfa.Composite.
+func (g *not) Parts() []any {
+	return parts(g.guard)
+}
+
+// check evaluates the guard, using CheckErr for a gonfa.ErrGuard. A
+// gonfa.DeferringGuard is evaluated with Check like any other guard.
+func check(
+	ctx context.Context,
+	guard gonfa.Guard,
+	state gonfa.MachineState,
+	payload gonfa.Payload,
+) (bool, error) {
+	if eg, ok := guard.(gonfa.ErrGuard); ok {
+		return eg.CheckErr(ctx, state, payload)
+	}
+
+	return guard.Check(ctx, state, payload), nil
+}
+
+// parts returns the guards as gonfa.Composite parts.
+func parts(guards ...gonfa.Guard) []any {
+	p := make([]any, len(guards))
+	for i, g := range guards {
+		p[i] = g
+	}
+
+	return p
+}
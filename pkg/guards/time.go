@@ -0,0 +1,91 @@
+package guards
+
+import (
+	"context"
+	"time"
+
+	"github.com/dr-dobermann/gonfa/pkg/gonfa"
+)
+
+// clock is implemented by machine states which provide the current time,
+// so time-based guards could be tested with a fake clock.
+type clock interface {
+	Now() time.Time
+}
+
+// now returns the current time of the machine state, falling back to the
+// system time.
+func now(state gonfa.MachineState) time.Time {
+	if c, ok := state.(clock); ok {
+		return c.Now()
+	}
+
+	return time.Now()
+}
+
+// timeWindow passes within a fixed time window.
+type timeWindow struct {
+	start time.Time
+	end   time.Time
+}
+
+// WithinTimeWindow returns a guard which passes only between start
+// (inclusive) and end (exclusive), e.g. during a promotion. It never passes
+// if end isn't after start.
+//
+// Like other time-based guards, it takes the current time from the machine
+// state if it provides one with a Now() time.Time method, otherwise from
+// the system clock.
+func WithinTimeWindow(start, end time.Time) gonfa.Guard {
+	return &timeWindow{start: start, end: end}
+}
+
+// Check implements gonfa.Guard.
+func (g *timeWindow) Check(
+	_ context.Context,
+	state gonfa.MachineState,
+	_ gonfa.Payload,
+) bool {
+	t := now(state)
+
+	return !t.Before(g.start) && t.Before(g.end)
+}
+
+// duringHours passes within a daily range of hours.
+type duringHours struct {
+	start int
+	end   int
+	loc   *time.Location
+}
+
+// DuringHours returns a guard which passes every day from startHour
+// (inclusive) to endHour (exclusive) in the location loc, e.g.
+// DuringHours(9, 18, loc) for business hours. If startHour is greater than
+// endHour, the range wraps around midnight: DuringHours(22, 6, loc) passes
+// from 22:00 to 05:59. Hours are in the range 0-24; equal hours make an
+// empty range, while DuringHours(0, 24, loc) passes all day.
+//
+// A nil loc means time.Local. The current time is taken like in
+// WithinTimeWindow.
+func DuringHours(startHour, endHour int, loc *time.Location) gonfa.Guard {
+	if loc == nil {
+		loc = time.Local
+	}
+
+	return &duringHours{start: startHour, end: endHour, loc: loc}
+}
+
+// Check implements gonfa.Guard.
+func (g *duringHours) Check(
+	_ context.Context,
+	state gonfa.MachineState,
+	_ gonfa.Payload,
+) bool {
+	hour := now(state).In(g.loc).Hour()
+
+	if g.start <= g.end {
+		return hour >= g.start && hour < g.end
+	}
+
+	return hour >= g.start || hour < g.end
+}
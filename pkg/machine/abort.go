@@ -0,0 +1,65 @@
+package machine
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/dr-dobermann/gonfa/pkg/gonfa"
+)
+
+// AbortEvent is the synthetic event recorded in the history by Abort.
+const AbortEvent gonfa.Event = "$abort"
+
+// Abort unconditionally moves the machine to the abort state configured by
+// WithAbortState. It's used to force-terminate a workflow on a fatal
+// external condition.
+//
+// Unlike Fire, Abort ignores transitions, guards, OnExit actions of the
+// current state and hooks, since nothing may prevent the abort. The state
+// change is recorded in the history with AbortEvent, and then OnEntry
+// actions of the abort state are executed with the reason as the payload.
+// Their errors are returned, but the machine stays in the abort state.
+//
+// Aborting a machine already in the abort state does nothing.
+func (m *Machine) Abort(ctx context.Context, reason string) error {
+	m.mu.Lock()
+	defer m.unlockAndNotify()
+
+	if m.abortState == "" {
+		return fmt.Errorf("abort state isn't configured")
+	}
+
+	if m.currentState == m.abortState {
+		return nil
+	}
+
+	oldState := m.currentState
+	m.currentState = m.abortState
+
+	m.recordHistory(gonfa.HistoryEntry{
+		From:      oldState,
+		To:        m.abortState,
+		On:        AbortEvent,
+		Timestamp: m.clock.Now(),
+	})
+
+	for _, action := range m.definition.GetStateConfig(m.abortState).OnEntry {
+		if err := action.Execute(ctx, lockedState{m}, reason); err != nil {
+			return fmt.Errorf("abort state OnEntry action failed: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// validateOptions checks the options against the machine definition.
+func (m *Machine) validateOptions() error {
+	if m.abortState != "" {
+		if _, ok := m.definition.States()[m.abortState]; !ok {
+			return fmt.Errorf("abort state '%s' not found in definition",
+				m.abortState)
+		}
+	}
+
+	return nil
+}
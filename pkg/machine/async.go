@@ -0,0 +1,147 @@
+package machine
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/dr-dobermann/gonfa/pkg/definition"
+	"github.com/dr-dobermann/gonfa/pkg/gonfa"
+)
+
+// startAsyncActions starts the async actions of the committed transition.
+// Must be called under the write lock.
+func (m *Machine) startAsyncActions(
+	ctx context.Context,
+	transition definition.Transition,
+	payload gonfa.Payload,
+) {
+	for i, a := range transition.AsyncActions {
+		m.startAsyncAction(ctx, gonfa.PendingAction{
+			From:  transition.From,
+			To:    transition.To,
+			On:    transition.On,
+			Index: i,
+		}, a, payload)
+	}
+}
+
+// startAsyncAction registers the pending async action and runs it in a
+// goroutine.
+// Must be called under the write lock.
+func (m *Machine) startAsyncAction(
+	ctx context.Context,
+	p gonfa.PendingAction,
+	a definition.AsyncAction,
+	payload gonfa.Payload,
+) {
+	m.pending = append(m.pending, p)
+
+	m.async.Add(1)
+	go m.runAsyncAction(ctx, p, a, payload)
+}
+
+// runAsyncAction executes the async action and fires its completion event
+// if the machine is still in the pending state.
+func (m *Machine) runAsyncAction(
+	ctx context.Context,
+	p gonfa.PendingAction,
+	a definition.AsyncAction,
+	payload gonfa.Payload,
+) {
+	defer m.async.Done()
+
+	event, result := a.OnDone, payload
+	if err := a.Action.Execute(ctx, m, payload); err != nil {
+		event, result = a.OnFail, err
+	}
+
+	m.mu.Lock()
+	defer m.unlockAndNotify()
+
+	if i := slices.Index(m.pending, p); i >= 0 {
+		m.pending = slices.Delete(m.pending, i, i+1)
+	}
+
+	if m.currentState != p.To {
+		return
+	}
+
+	// The completion is delivered even if the action was canceled
+	_, _ = m.fire(context.WithoutCancel(ctx), event, result)
+}
+
+// asyncAction returns the async action of the definition identified by p.
+func asyncAction(
+	def *definition.Definition,
+	p gonfa.PendingAction,
+) (definition.AsyncAction, error) {
+	for _, t := range def.GetTransitions(p.From, p.On) {
+		if t.To != p.To {
+			continue
+		}
+
+		if p.Index < 0 || p.Index >= len(t.AsyncActions) {
+			break
+		}
+
+		return t.AsyncActions[p.Index], nil
+	}
+
+	return definition.AsyncAction{}, fmt.Errorf(
+		"no async action %d on transition from '%s' to '%s' on event '%s'",
+		p.Index, p.From, p.To, p.On)
+}
+
+// pendingActions returns the pending async actions of the current state,
+// both running and restored ones.
+// Must be called under the lock.
+func (m *Machine) pendingActions() []gonfa.PendingAction {
+	var pending []gonfa.PendingAction
+	for _, p := range slices.Concat(m.pending, m.suspended) {
+		if p.To == m.currentState {
+			pending = append(pending, p)
+		}
+	}
+
+	return pending
+}
+
+// ResumeAsync restarts the async actions which were in flight when the
+// restored machine was marshaled (see gonfa.Storable.Pending). The actions
+// get ctx and a nil payload since payloads aren't persisted; on completion
+// they fire their events like the actions started by Fire. Calling it on a
+// machine without restored pending actions does nothing.
+//
+// If an action isn't found in the machine definition, ResumeAsync returns
+// an error and starts none of them.
+func (m *Machine) ResumeAsync(ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	actions := make([]definition.AsyncAction, len(m.suspended))
+	for i, p := range m.suspended {
+		a, err := asyncAction(m.definition, p)
+		if err != nil {
+			return fmt.Errorf("invalid pending async action: %w", err)
+		}
+
+		actions[i] = a
+	}
+
+	suspended := m.suspended
+	m.suspended = nil
+
+	for i, p := range suspended {
+		m.startAsyncAction(ctx, p, actions[i], nil)
+	}
+
+	return nil
+}
+
+// WaitAsync waits until all running async actions (see
+// builder.Builder.WithAsyncAction) complete and their completion events
+// are fired. It must not be called from actions, guards or hooks.
+func (m *Machine) WaitAsync() {
+	m.async.Wait()
+}
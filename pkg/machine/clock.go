@@ -0,0 +1,35 @@
+package machine
+
+import (
+	"time"
+)
+
+// Clock provides the current time to the machine.
+type Clock interface {
+	Now() time.Time
+}
+
+// systemClock is the default Clock reading the system time.
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+// WithClock sets the clock which timestamps the history entries, so tests
+// could use a fake clock and assert exact timestamps. Guards and actions get
+// the clock's time through the machine state's Now method, which the
+// time-based guards of the guards package use. By default the system time
+// is used.
+func WithClock(c Clock) Option {
+	return func(m *Machine) {
+		if c != nil {
+			m.clock = c
+		}
+	}
+}
+
+// Now returns the current time of the machine's clock (see WithClock).
+func (m *Machine) Now() time.Time {
+	return m.clock.Now()
+}
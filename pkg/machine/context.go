@@ -0,0 +1,32 @@
+package machine
+
+import "context"
+
+// defaultsContext adds the definition's default values (see
+// definition.WithContextValues) to a context. Values of the wrapped
+// context win over the defaults.
+type defaultsContext struct {
+	context.Context
+	values map[any]any
+}
+
+// Value returns the wrapped context's value for key or, if there is none,
+// the default one.
+func (c defaultsContext) Value(key any) any {
+	if v := c.Context.Value(key); v != nil {
+		return v
+	}
+
+	return c.values[key]
+}
+
+// withDefaults returns ctx carrying the definition's default values.
+// Must be called under the lock.
+func (m *Machine) withDefaults(ctx context.Context) context.Context {
+	values := m.definition.ContextValues()
+	if len(values) == 0 {
+		return ctx
+	}
+
+	return defaultsContext{Context: ctx, values: values}
+}
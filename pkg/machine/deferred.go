@@ -0,0 +1,87 @@
+package machine
+
+import (
+	"context"
+	"errors"
+
+	"github.com/dr-dobermann/gonfa/pkg/gonfa"
+)
+
+// deferredEvent is an event queued by a deferring guard.
+type deferredEvent struct {
+	event   gonfa.Event
+	payload gonfa.Payload
+}
+
+// checkGuard evaluates the guard, using CheckErr for a gonfa.ErrGuard and
+// CheckDefer for a gonfa.DeferringGuard. A deferred or failed guard never
+// passes.
+func checkGuard(
+	ctx context.Context,
+	guard gonfa.Guard,
+	state gonfa.MachineState,
+	payload gonfa.Payload,
+) (bool, bool, error) {
+	switch g := guard.(type) {
+	case gonfa.ErrGuard:
+		passed, err := g.CheckErr(ctx, state, payload)
+
+		return passed && err == nil, false, err
+
+	case gonfa.DeferringGuard:
+		passed, deferred := g.CheckDefer(ctx, state, payload)
+
+		return passed && !deferred, deferred, nil
+	}
+
+	return guard.Check(ctx, state, payload), false, nil
+}
+
+// DeferredEvents returns the events queued by deferring guards (see
+// gonfa.DeferringGuard) in the order they were deferred.
+func (m *Machine) DeferredEvents() []gonfa.Event {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	events := make([]gonfa.Event, len(m.deferred))
+	for i, d := range m.deferred {
+		events[i] = d.event
+	}
+
+	return events
+}
+
+// RetryDeferred fires the queued deferred events again with their original
+// payloads, in the order they were deferred, and returns the number of
+// events which made a transition. An event deferred again is put back to
+// the queue; an event rejected by the guards is dropped like a regular
+// rejected event. Errors of the fired events other than
+// gonfa.ErrEventDeferred are joined.
+//
+// The queue isn't persisted by Marshal, and the retries aren't scheduled by
+// the machine: call RetryDeferred when the deferring guards may be able to
+// decide (e.g. on a timer or when the awaited data arrives).
+func (m *Machine) RetryDeferred(ctx context.Context) (int, error) {
+	m.mu.Lock()
+	defer m.unlockAndNotify()
+
+	queue := m.deferred
+	m.deferred = nil
+
+	var (
+		fired int
+		errs  []error
+	)
+	for _, d := range queue {
+		result, err := m.fire(ctx, d.event, d.payload)
+		if result != nil {
+			fired++
+		}
+
+		if err != nil && !errors.Is(err, gonfa.ErrEventDeferred) {
+			errs = append(errs, err)
+		}
+	}
+
+	return fired, errors.Join(errs...)
+}
@@ -0,0 +1,44 @@
+package machine
+
+import (
+	"context"
+	"time"
+
+	"github.com/dr-dobermann/gonfa/pkg/gonfa"
+)
+
+// CurrentStateInfo is a plain snapshot of the machine's current step.
+type CurrentStateInfo struct {
+	State        gonfa.State   // Current state
+	IsFinal      bool          // True if the machine is in an accepting final state
+	Events       []gonfa.Event // Sorted events of AvailableEvents
+	EntryActions int           // Number of the state's OnEntry actions
+	ExitActions  int           // Number of the state's OnExit actions
+	EnteredAt    time.Time     // Time of the last transition; zero if none
+}
+
+// DescribeCurrent returns everything needed to render the current step of
+// the workflow in one consistent read.
+// IsFinal agrees with IsInFinalState, and Events with AvailableEvents:
+// guards aren't evaluated, so Events lists all events the state has enabled
+// transitions for.
+func (m *Machine) DescribeCurrent() CurrentStateInfo {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	info := CurrentStateInfo{
+		State:   m.currentState,
+		IsFinal: m.inFinalState(context.Background(), nil),
+		Events:  m.availableEvents(),
+	}
+
+	config := m.definition.GetStateConfig(m.currentState)
+	info.EntryActions = len(config.OnEntry)
+	info.ExitActions = len(config.OnExit)
+
+	if n := len(m.history); n > 0 {
+		info.EnteredAt = m.history[n-1].Timestamp
+	}
+
+	return info
+}
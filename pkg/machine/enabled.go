@@ -0,0 +1,68 @@
+package machine
+
+import (
+	"github.com/dr-dobermann/gonfa/pkg/definition"
+	"github.com/dr-dobermann/gonfa/pkg/gonfa"
+)
+
+// transitionKey identifies transitions by their source, target and event.
+type transitionKey struct {
+	from gonfa.State
+	to   gonfa.State
+	on   gonfa.Event
+}
+
+// SetTransitionEnabled enables or disables at runtime the transitions from
+// the state from to the state to on the event on, overriding their
+// definition.Transition.Disabled flag. It allows to turn off a risky
+// workflow path without rebuilding the definition.
+//
+// Disabled transitions are skipped by Fire and Accepts as if they weren't
+// declared: no guards or actions are called, and if no other transition
+// succeeds, Fire returns false and calls the failure hooks.
+//
+// The setting applies to every transition with the given identity and
+// survives MigrateTo; setting it for a transition missing in the definition
+// has no effect. It's a part of the machine's configuration, not of its
+// state, so it isn't persisted by Marshal.
+func (m *Machine) SetTransitionEnabled(
+	from, to gonfa.State,
+	on gonfa.Event,
+	enabled bool,
+) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.enabled == nil {
+		m.enabled = make(map[transitionKey]bool)
+	}
+
+	m.enabled[transitionKey{from: from, to: to, on: on}] = enabled
+}
+
+// isEnabled checks if the transition is enabled.
+// Must be called under the lock.
+func (m *Machine) isEnabled(t definition.Transition) bool {
+	key := transitionKey{from: t.From, to: t.To, on: t.On}
+	if enabled, ok := m.enabled[key]; ok {
+		return enabled
+	}
+
+	return !t.Disabled
+}
+
+// enabledTransitions returns the transitions from the current state on the
+// event which are enabled.
+// Must be called under the lock.
+func (m *Machine) enabledTransitions(event gonfa.Event) []definition.Transition {
+	transitions := m.definition.GetTransitions(m.currentState, event)
+
+	enabled := transitions[:0:0]
+	for _, t := range transitions {
+		if m.isEnabled(t) {
+			enabled = append(enabled, t)
+		}
+	}
+
+	return enabled
+}
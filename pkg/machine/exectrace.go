@@ -0,0 +1,158 @@
+package machine
+
+import (
+	"fmt"
+
+	"github.com/dr-dobermann/gonfa/pkg/definition"
+	"github.com/dr-dobermann/gonfa/pkg/gonfa"
+)
+
+// maxTraceSteps caps the execution trace of a single Fire. Steps beyond
+// the cap are dropped.
+const maxTraceSteps = 1024
+
+// TracePhase identifies the phase of Fire a trace step belongs to.
+type TracePhase string
+
+const (
+	PhaseGuard       TracePhase = "guard"       // guard check
+	PhaseOnExit      TracePhase = "onExit"      // OnExit action of the source state
+	PhaseAction      TracePhase = "action"      // transition action
+	PhaseStateChange TracePhase = "stateChange" // state change
+	PhaseOnEntry     TracePhase = "onEntry"     // OnEntry action of the entered state
+	PhaseAfterAction TracePhase = "afterAction" // after action or event action
+	PhaseHook        TracePhase = "hook"        // success or failure hook
+)
+
+// TraceStep is a single step of the execution trace recorded by Fire (see
+// WithExecutionTrace).
+type TraceStep struct {
+	Phase TracePhase
+
+	// Name of the guard or action, or the transition for PhaseStateChange.
+	// Guards and actions are named after the definition's GuardNames and
+	// ActionNames or the registry set by WithRegistry, falling back to
+	// their type (%T).
+	Name string
+
+	Passed bool  // guard result, or whether the action succeeded
+	Err    error // error returned by the action
+}
+
+// String returns a human-readable description of the step.
+func (s TraceStep) String() string {
+	switch {
+	case s.Phase == PhaseStateChange:
+		return fmt.Sprintf("%s %s", s.Phase, s.Name)
+	case s.Err != nil:
+		return fmt.Sprintf("%s %s: failed: %v", s.Phase, s.Name, s.Err)
+	case s.Passed:
+		return fmt.Sprintf("%s %s: passed", s.Phase, s.Name)
+	}
+
+	return fmt.Sprintf("%s %s: rejected", s.Phase, s.Name)
+}
+
+// WithExecutionTrace makes Fire record every executed step (checked guards
+// with their results, actions, the state change and hooks) in order. The
+// steps of the last fired event are returned by LastTrace. Tracing is off
+// by default since it allocates on every Fire; the trace of a single Fire
+// is capped at 1024 steps.
+func WithExecutionTrace() Option {
+	return func(m *Machine) {
+		m.execTrace = true
+	}
+}
+
+// LastTrace returns a copy of the execution trace of the last fired event
+// or nil if tracing isn't enabled with WithExecutionTrace. Events fired
+// internally (e.g. by completed async actions or RetryDeferred) replace
+// the trace as well.
+func (m *Machine) LastTrace() []TraceStep {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if !m.execTrace {
+		return nil
+	}
+
+	return append([]TraceStep{}, m.lastTrace...)
+}
+
+// resetTrace starts the execution trace of a new Fire.
+// Must be called under the write lock.
+func (m *Machine) resetTrace() {
+	if m.execTrace {
+		m.lastTrace = nil
+	}
+}
+
+// traceStep appends the step to the execution trace if tracing is on.
+// Must be called under the write lock.
+func (m *Machine) traceStep(step TraceStep) {
+	if m.execTrace && len(m.lastTrace) < maxTraceSteps {
+		m.lastTrace = append(m.lastTrace, step)
+	}
+}
+
+// traceAction records the action executed in the phase with its error.
+// Must be called under the write lock.
+func (m *Machine) traceAction(phase TracePhase, a gonfa.Action, err error) {
+	if m.execTrace {
+		m.traceStep(TraceStep{
+			Phase:  phase,
+			Name:   m.actionName(a),
+			Passed: err == nil,
+			Err:    err,
+		})
+	}
+}
+
+// traceTransitionAction records the transition's i-th action with its
+// error.
+// Must be called under the write lock.
+func (m *Machine) traceTransitionAction(
+	t definition.Transition,
+	i int,
+	err error,
+) {
+	if !m.execTrace {
+		return
+	}
+
+	name := m.actionName(t.Actions[i])
+	if i < len(t.ActionNames) {
+		name = t.ActionNames[i]
+	}
+
+	m.traceStep(TraceStep{
+		Phase:  PhaseAction,
+		Name:   name,
+		Passed: err == nil,
+		Err:    err,
+	})
+}
+
+// traceGuard records the transition's i-th guard result.
+// Must be called under the write lock.
+func (m *Machine) traceGuard(t definition.Transition, i int, passed bool) {
+	if m.execTrace {
+		m.traceStep(TraceStep{
+			Phase:  PhaseGuard,
+			Name:   m.transitionGuardName(t, i),
+			Passed: passed,
+		})
+	}
+}
+
+// actionName returns the registered name of the action or its type name
+// if the action isn't found in the machine's registry.
+func (m *Machine) actionName(a gonfa.Action) string {
+	if m.registry != nil {
+		if name, ok := m.registry.NameOfAction(a); ok {
+			return name
+		}
+	}
+
+	return fmt.Sprintf("%T", a)
+}
@@ -0,0 +1,61 @@
+package machine
+
+import (
+	"context"
+
+	"github.com/dr-dobermann/gonfa/pkg/definition"
+	"github.com/dr-dobermann/gonfa/pkg/gonfa"
+)
+
+// FireExplain fires the event like Fire and also explains the outcome: the
+// diagnostic lists every candidate transition attempted, in order, with
+// the guard which rejected or deferred it, or whether it committed. An
+// empty list means the state has no enabled transition on the event, so
+// it tells apart the reasons for which Fire returns (false, nil).
+//
+// Candidates after the committed one and the ones skipped because Fire
+// stopped with an error aren't listed.
+func (m *Machine) FireExplain(
+	ctx context.Context,
+	event gonfa.Event,
+	payload gonfa.Payload,
+) (bool, gonfa.FireDiagnostic, error) {
+	m.mu.Lock()
+	defer m.unlockAndNotify()
+
+	diag := gonfa.FireDiagnostic{From: m.currentState, Event: event}
+
+	m.diagnostic = &diag
+	result, err := m.fire(ctx, event, payload)
+	m.diagnostic = nil
+
+	return result != nil, diag, err
+}
+
+// explain records the outcome of the candidate transition for FireExplain:
+// the index of the guard which rejected or deferred it (-1 if none did)
+// and whether it committed.
+// Must be called under the write lock.
+func (m *Machine) explain(
+	t definition.Transition,
+	guard int,
+	deferred bool,
+	committed bool,
+) {
+	if m.diagnostic == nil {
+		return
+	}
+
+	c := gonfa.CandidateDiagnostic{
+		To:         t.To,
+		Index:      t.Index(),
+		GuardIndex: guard,
+		Deferred:   deferred,
+		Committed:  committed,
+	}
+	if guard >= 0 {
+		c.Guard = m.transitionGuardName(t, guard)
+	}
+
+	m.diagnostic.Candidates = append(m.diagnostic.Candidates, c)
+}
@@ -0,0 +1,84 @@
+package machine
+
+import (
+	"github.com/dr-dobermann/gonfa/pkg/gonfa"
+)
+
+// recordHistory appends the entry to the history and evicts the oldest
+// entries exceeding the history cap.
+// Must be called under the write lock.
+func (m *Machine) recordHistory(entry gonfa.HistoryEntry) {
+	m.history = append(m.history, entry)
+	m.trimHistory()
+
+	if m.onSelf != nil && entry.From == entry.To {
+		m.selfTransitions = append(m.selfTransitions, entry)
+	}
+}
+
+// trimHistory drops the oldest history entries exceeding the history cap.
+// If an eviction callback is set, the dropped entries are queued for it.
+// Must be called under the write lock.
+func (m *Machine) trimHistory() {
+	if m.maxHistory <= 0 || len(m.history) <= m.maxHistory {
+		return
+	}
+
+	n := len(m.history) - m.maxHistory
+	if m.onEvict != nil {
+		m.evicted = append(m.evicted, m.history[:n]...)
+	}
+
+	// Copy the kept entries so the evicted ones could be garbage collected
+	m.history = append(
+		make([]gonfa.HistoryEntry, 0, m.maxHistory), m.history[n:]...)
+}
+
+// unlockAndNotify releases the write lock and passes the entries evicted
+// under it to the eviction callback, then the self-transitions made under
+// it to the self-transition callback, and then the Fire attempts made under
+// it to the listeners.
+// The notification lock is taken before the write lock is released, so
+// callbacks receive entries in order even when several goroutines fire
+// events concurrently.
+func (m *Machine) unlockAndNotify() {
+	evicted, self, fired := m.evicted, m.selfTransitions, m.fired
+	m.evicted, m.selfTransitions, m.fired = nil, nil, nil
+	listeners := m.listeners
+
+	if len(evicted) == 0 && len(self) == 0 && len(fired) == 0 {
+		m.mu.Unlock()
+		return
+	}
+
+	m.notifyMu.Lock()
+	defer m.notifyMu.Unlock()
+
+	m.mu.Unlock()
+
+	for _, e := range evicted {
+		m.onEvict(e)
+	}
+
+	for _, e := range self {
+		m.onSelf(e)
+	}
+
+	for _, e := range fired {
+		for _, l := range listeners {
+			l(e)
+		}
+	}
+}
+
+// visitCount returns the number of history entries ending in s.
+func visitCount(history []gonfa.HistoryEntry, s gonfa.State) int {
+	n := 0
+	for _, h := range history {
+		if h.To == s {
+			n++
+		}
+	}
+
+	return n
+}
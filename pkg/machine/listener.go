@@ -0,0 +1,52 @@
+package machine
+
+import (
+	"github.com/dr-dobermann/gonfa/pkg/gonfa"
+)
+
+// AddListener adds a function called after every Fire attempt, e.g. to
+// count transitions and failures in metrics without adding hooks to the
+// definition. The function receives the event, the state the machine was
+// in, the committed target state, if any, and the error Fire returned.
+//
+// Events fired internally (async action completions, RetryDeferred and
+// transactions) are reported as well, including the attempts of rolled back
+// transactions. Listeners are called in the order
+// they were added. Like the eviction callback (see WithHistoryEviction),
+// they run after the machine lock is released, in the order of the
+// attempts, and must not fire events on the same machine.
+func (m *Machine) AddListener(fn func(gonfa.FireEvent)) {
+	if fn == nil {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.listeners = append(m.listeners, fn)
+}
+
+// recordFire queues the Fire attempt for the listeners.
+// Must be called under the write lock.
+func (m *Machine) recordFire(
+	event gonfa.Event,
+	from gonfa.State,
+	result *gonfa.TransitionResult,
+	err error,
+) {
+	if len(m.listeners) == 0 {
+		return
+	}
+
+	e := gonfa.FireEvent{
+		Event:   event,
+		From:    from,
+		Success: result != nil,
+		Err:     err,
+	}
+	if result != nil {
+		e.To = result.To
+	}
+
+	m.fired = append(m.fired, e)
+}
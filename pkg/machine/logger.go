@@ -0,0 +1,32 @@
+package machine
+
+import (
+	"github.com/dr-dobermann/gonfa/pkg/definition"
+	"github.com/dr-dobermann/gonfa/pkg/gonfa"
+)
+
+// WithLogger sets the logger of the machine. Fire logs at debug level the
+// end of every phase of a transition (guards checked, OnExit actions run,
+// transition actions run, state changed, OnEntry actions run, hooks run)
+// naming the event and the states, and failed transitions at error level.
+// The logger is called under the machine lock and must not call the
+// machine's methods. By default nothing is logged.
+func WithLogger(l gonfa.Logger) Option {
+	return func(m *Machine) {
+		if l != nil {
+			m.logger = l
+		}
+	}
+}
+
+// nopLogger discards all messages.
+type nopLogger struct{}
+
+func (nopLogger) Debugf(string, ...any) {}
+func (nopLogger) Errorf(string, ...any) {}
+
+// logPhase logs the end of the transition's phase at debug level.
+func (m *Machine) logPhase(t definition.Transition, phase string) {
+	m.logger.Debugf("event '%s' from '%s' to '%s': %s", t.On, t.From, t.To,
+		phase)
+}
@@ -14,12 +14,14 @@ package machine
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"slices"
 	"sync"
-	"time"
 
 	"github.com/dr-dobermann/gonfa/pkg/definition"
 	"github.com/dr-dobermann/gonfa/pkg/gonfa"
+	"github.com/dr-dobermann/gonfa/pkg/registry"
 )
 
 // Machine represents an instance of a state machine.
@@ -31,21 +33,145 @@ type Machine struct {
 	currentState  gonfa.State
 	history       []gonfa.HistoryEntry
 	stateExtender gonfa.StateExtender
+	selection     SelectionStrategy
+	registry      *registry.Registry
+	guardTrace    GuardTraceFunc
+	logger        gonfa.Logger
+	clock         Clock
+
+	limiter         RateLimiter
+	abortState      gonfa.State
+	unknownEventErr bool
+	rejectionErrs   bool
+	entryRollback   bool
+	parallelGuards  bool
+
+	// active saga
+	inSaga    bool
+	sagaSteps []sagaStep
+
+	// bounded history
+	maxHistory int
+	onEvict    func(gonfa.HistoryEntry)
+	evicted    []gonfa.HistoryEntry
+
+	// self-transitions observer
+	onSelf          func(gonfa.HistoryEntry)
+	selfTransitions []gonfa.HistoryEntry
+
+	// runtime overrides of the transitions' Disabled flags
+	enabled map[transitionKey]bool
+
+	// async actions: running, and restored but not resumed yet
+	async     sync.WaitGroup
+	pending   []gonfa.PendingAction
+	suspended []gonfa.PendingAction
+
+	// events deferred by guards, see RetryDeferred
+	deferred []deferredEvent
+
+	// outcomes of the candidates collected by FireExplain
+	diagnostic *gonfa.FireDiagnostic
+
+	// execution trace of the last Fire, see WithExecutionTrace
+	execTrace bool
+	lastTrace []TraceStep
+
+	// Fire listeners and the attempts not delivered to them yet
+	listeners []func(gonfa.FireEvent)
+	fired     []gonfa.FireEvent
+
+	// async queue of FireAsync, see WithAsyncQueue
+	queueEnabled bool
+	queueSize    int
+	queue        chan queuedEvent
+	queueDone    chan struct{}
+	queueMu      sync.RWMutex
+	queueClosed  bool
+
+	// notifyMu orders callbacks invoked after the write lock is released
+	notifyMu sync.Mutex
 }
 
-// New creates a new Machine instance from a Definition,
-// attaching a user-defined business object as its state extender.
-func New(def *definition.Definition, extender gonfa.StateExtender) (*Machine, error) {
+// New creates a new Machine instance from a Definition configured by the
+// options, e.g. WithExtender attaches a user-defined business object as its
+// state extender.
+func New(def *definition.Definition, opts ...Option) (*Machine, error) {
 	if def == nil {
 		return nil, fmt.Errorf("definition cannot be nil")
 	}
 
-	return &Machine{
-		definition:    def,
-		currentState:  def.InitialState(),
-		history:       make([]gonfa.HistoryEntry, 0),
-		stateExtender: extender,
-	}, nil
+	m := &Machine{
+		definition:   def,
+		currentState: def.InitialState(),
+		history:      make([]gonfa.HistoryEntry, 0),
+		selection:    FirstMatch(),
+		logger:       nopLogger{},
+		clock:        systemClock{},
+	}
+	m.applyOptions(opts)
+
+	if err := m.validateOptions(); err != nil {
+		return nil, err
+	}
+
+	m.startQueue()
+
+	return m, nil
+}
+
+// NewWithExtender creates a new Machine instance from a Definition,
+// attaching a user-defined business object as its state extender. It's
+// a shorthand for New with WithExtender before the other options.
+func NewWithExtender(
+	def *definition.Definition,
+	extender gonfa.StateExtender,
+	opts ...Option,
+) (*Machine, error) {
+	return New(def, append([]Option{WithExtender(extender)}, opts...)...)
+}
+
+// NewWithInitial works like New but starts the machine in the given state
+// instead of the definition's initial state, e.g. a pre-approved document
+// may start in Review rather than Draft. The state must exist in the
+// definition; it's reachable since the definition validates that every state
+// is reachable from its initial state.
+//
+// Like New, it doesn't run the OnEntry actions of the starting state, and
+// the history starts empty. If initial is a final state, IsInFinalState
+// reports true right away.
+func NewWithInitial(
+	def *definition.Definition,
+	extender gonfa.StateExtender,
+	initial gonfa.State,
+	opts ...Option,
+) (*Machine, error) {
+	if def == nil {
+		return nil, fmt.Errorf("definition cannot be nil")
+	}
+
+	if _, exists := def.States()[initial]; !exists {
+		return nil,
+			fmt.Errorf("initial state '%s' not found in definition", initial)
+	}
+
+	m, err := NewWithExtender(def, extender, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	m.currentState = initial
+
+	return m, nil
+}
+
+// applyOptions applies all non-nil options to the machine.
+func (m *Machine) applyOptions(opts []Option) {
+	for _, opt := range opts {
+		if opt != nil {
+			opt(m)
+		}
+	}
 }
 
 // Restore restores a Machine instance from a Storable state,
@@ -54,6 +180,7 @@ func Restore(
 	def *definition.Definition,
 	state *gonfa.Storable,
 	extender gonfa.StateExtender,
+	opts ...Option,
 ) (*Machine, error) {
 	if def == nil {
 		return nil, fmt.Errorf("definition cannot be nil")
@@ -73,12 +200,67 @@ func Restore(
 				state.CurrentState)
 	}
 
-	return &Machine{
+	m := &Machine{
 		definition:    def,
 		currentState:  state.CurrentState,
 		history:       append([]gonfa.HistoryEntry{}, state.History...),
 		stateExtender: extender,
-	}, nil
+		selection:     FirstMatch(),
+		logger:        nopLogger{},
+		clock:         systemClock{},
+		suspended:     slices.Clone(state.Pending),
+	}
+	m.applyOptions(opts)
+
+	if err := m.validateOptions(); err != nil {
+		return nil, err
+	}
+
+	for _, p := range m.suspended {
+		if p.To != m.currentState {
+			return nil, fmt.Errorf(
+				"pending async action waits in '%s' but current state is '%s'",
+				p.To, m.currentState)
+		}
+
+		if _, err := asyncAction(m.definition, p); err != nil {
+			return nil, fmt.Errorf("invalid pending async action: %w", err)
+		}
+	}
+
+	// Apply the history cap to the restored history
+	m.mu.Lock()
+	m.trimHistory()
+	m.unlockAndNotify()
+
+	m.startQueue()
+
+	return m, nil
+}
+
+// RestoreValidated works like Restore but first checks the state with
+// gonfa.ValidateStorable, so corrupt persisted data is rejected with a
+// precise error.
+func RestoreValidated(
+	def *definition.Definition,
+	state *gonfa.Storable,
+	extender gonfa.StateExtender,
+	opts ...Option,
+) (*Machine, error) {
+	if err := gonfa.ValidateStorable(state); err != nil {
+		return nil, fmt.Errorf("invalid storable state: %w", err)
+	}
+
+	return Restore(def, state, extender, opts...)
+}
+
+// Definition returns the definition the machine runs on.
+// The Definition is immutable, so it's safe to share.
+func (m *Machine) Definition() *definition.Definition {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.definition
 }
 
 // CurrentState returns the current state of the machine.
@@ -88,100 +270,769 @@ func (m *Machine) CurrentState() gonfa.State {
 	return m.currentState
 }
 
+// Accepts checks if the current state has any enabled transition on the
+// event (see SetTransitionEnabled).
+// Guards and preconditions are ignored and no user code is run, so it's a
+// cheap way to filter out events irrelevant in the current state. A true
+// result doesn't guarantee that Fire succeeds.
+func (m *Machine) Accepts(event gonfa.Event) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return len(m.enabledTransitions(event)) > 0
+}
+
+// CanFire checks if firing the event would commit a transition, without
+// firing it: the preconditions and guards of the enabled transitions on the
+// event are evaluated in declaration order, and it returns true once all of
+// them pass for a transition. No actions or hooks are executed and neither
+// the history nor the rate limiter is touched, so it suits enabling UI
+// controls. A deferring guard (see gonfa.DeferringGuard) counts as a
+// rejection, and so does a failed precondition, since it makes Fire fail.
+//
+// CanFire holds the read lock while the guards run, so guards must not call
+// the machine's methods which take the write lock. The result may be stale
+// by the time Fire is called, and actions failing at Fire time aren't
+// predicted.
+func (m *Machine) CanFire(
+	ctx context.Context,
+	event gonfa.Event,
+	payload gonfa.Payload,
+) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.canFire(m.withDefaults(ctx), event, payload)
+}
+
+// canFire checks if the preconditions and guards of any enabled transition
+// on the event pass.
+// Must be called under the lock.
+func (m *Machine) canFire(
+	ctx context.Context,
+	event gonfa.Event,
+	payload gonfa.Payload,
+) bool {
+	state := lockedState{m}
+
+	for _, t := range m.enabledTransitions(event) {
+		for _, p := range t.Preconditions {
+			if !p.Check(ctx, state, payload) {
+				return false
+			}
+		}
+
+		if guardsPass(ctx, t, state, payload) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// AvailableEvents returns the sorted distinct events of the enabled
+// transitions (see SetTransitionEnabled) leaving the current state, e.g.
+// for building menus. Guards aren't evaluated; use AvailableEventsPassing
+// to filter out the events which would be rejected now.
+func (m *Machine) AvailableEvents() []gonfa.Event {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.availableEvents()
+}
+
+// AvailableEventsPassing returns the sorted events of AvailableEvents for
+// which CanFire holds, i.e. the preconditions and guards of at least one
+// transition currently pass. Like CanFire, it runs no actions or hooks and
+// holds the read lock while the guards run.
+func (m *Machine) AvailableEventsPassing(
+	ctx context.Context,
+	payload gonfa.Payload,
+) []gonfa.Event {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	ctx = m.withDefaults(ctx)
+
+	events := []gonfa.Event{}
+	for _, e := range m.availableEvents() {
+		if m.canFire(ctx, e, payload) {
+			events = append(events, e)
+		}
+	}
+
+	return events
+}
+
+// availableEvents returns the sorted events of the enabled transitions
+// leaving the current state.
+// Must be called under the lock.
+func (m *Machine) availableEvents() []gonfa.Event {
+	events := []gonfa.Event{}
+	for _, e := range m.definition.NextEvents(m.currentState) {
+		if slices.ContainsFunc(m.definition.GetTransitions(m.currentState, e),
+			m.isEnabled) {
+			events = append(events, e)
+		}
+	}
+
+	return events
+}
+
+// guardsPass checks if all the transition's guards pass.
+func guardsPass(
+	ctx context.Context,
+	t definition.Transition,
+	state gonfa.MachineState,
+	payload gonfa.Payload,
+) bool {
+	for _, g := range t.Guards {
+		if passed, _, _ := checkGuard(ctx, g, state, payload); !passed {
+			return false
+		}
+	}
+
+	return true
+}
+
+// PreviewAfter returns the sorted events which would be available after
+// firing the event in the current state, without firing it, e.g. for
+// multi-step wizards ("if you approve, next you can archive or revise").
+// Returns false if the current state has no enabled transition on the
+// event.
+//
+// The preview is structural only: guards and preconditions are ignored. If
+// several transitions compete for the event, any of them could be taken,
+// so the events available after each of them are merged. Disabled
+// transitions (see SetTransitionEnabled) are skipped in both steps.
+func (m *Machine) PreviewAfter(event gonfa.Event) ([]gonfa.Event, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	transitions := m.enabledTransitions(event)
+	if len(transitions) == 0 {
+		return nil, false
+	}
+
+	var events []gonfa.Event
+	for _, t := range transitions {
+		for _, e := range m.definition.NextEvents(t.To) {
+			if slices.Contains(events, e) {
+				continue
+			}
+
+			if slices.ContainsFunc(m.definition.GetTransitions(t.To, e),
+				m.isEnabled) {
+				events = append(events, e)
+			}
+		}
+	}
+	slices.Sort(events)
+
+	return events, true
+}
+
 // Fire triggers a transition based on an event with the provided payload.
 // The method is thread-safe and follows this execution order:
-// 1. Find matching transitions
-// 2. Check all Guards
+// 1. Find matching enabled transitions
+// 2. Check all Preconditions and Guards
 // 3. Execute OnExit actions for current state
 // 4. Execute transition Actions
 // 5. Change state
 // 6. Execute OnEntry actions for new state
-// 7. Call appropriate Hooks (OnSuccess/OnFailure)
+// 7. Execute AfterActions of the transition
+// 8. Execute event actions (Builder.OnEvent) for the fired event
+// 9. Call appropriate Hooks (OnSuccess/OnFailure)
+//
+// A transition is committed once the OnEntry actions of the new state
+// succeeded (step 6). Only the errors of AfterActions, event actions and
+// OnSuccess hooks are returned along with true; all AfterActions and event
+// actions run even if some of them fail. A failed OnEntry action or a
+// context canceled between steps 5 and 6 makes Fire return false, although
+// the machine stays in the new state unless WithEntryFailureRollback is
+// set.
+//
+// Fire checks ctx before steps 2-6 of every attempted transition. Once ctx
+// is done, Fire stops with an error wrapping ctx.Err(), runs the OnFailure
+// hooks and returns false. If the state hasn't been changed yet, the
+// machine stays where it is; the error state isn't entered. If ctx is done
+// right after the state change (before step 6), the machine stays in the
+// target state without running its OnEntry actions, unless
+// WithEntryFailureRollback is set, in which case the state change is
+// undone as for a failed OnEntry action.
+//
+// Internal self-transitions (see definition.Transition.Internal) skip steps
+// 3 and 6, but are still recorded in the history.
+//
+// A violated precondition fails Fire with an error (and runs the OnFailure
+// hooks), while a rejecting guard just makes Fire try the next transition.
+// Errors of transitions which failed to commit are *gonfa.TransitionError
+// values carrying the state and the event; if no transition passed its
+// guards, Fire returns (false, nil) unless WithRejectionErrors is set.
+//
+// A failed transition aborts the active saga before the OnFailure hooks
+// are called, see BeginSaga.
+//
+// If an OnExit or transition action fails (steps 3-4) and the current state
+// has an error state (see builder.Builder.OnActionError), the machine moves
+// to the error state and runs its OnEntry actions. The move is recorded in
+// the history under the fired event. Fire still returns false with the
+// action error and runs the OnFailure hooks.
+//
+// Async actions of the transition (see builder.Builder.WithAsyncAction) are
+// started in their own goroutines after step 5 and run without the machine
+// lock, receiving ctx, the machine and the payload. Canceling ctx should
+// make them fail. On completion the machine fires the async action's OnDone
+// event with the original payload, or its OnFail event with the action's
+// error as the payload, but only if it's still in the pending state (the
+// transition's target); the result of that Fire is discarded, so use hooks
+// to observe it. The completion event is fired with a context which isn't
+// canceled along with ctx. Use WaitAsync to wait for running async actions.
+//
+// If the machine has a rate limiter (see WithRateLimit) which rejects the
+// event, Fire returns gonfa.ErrRateLimited before step 1. With
+// WithUnknownEventError, an event not used in any transition makes Fire
+// return gonfa.ErrUnknownEvent even before the rate limiter is consulted.
+//
+// OnFailure hooks receive the guards which rejected candidate transitions
+// through the context, see GuardRejections.
+//
+// If a gonfa.DeferringGuard defers a candidate transition and no other
+// candidate succeeds, the event and its payload are queued and Fire returns
+// gonfa.ErrEventDeferred without calling the OnFailure hooks, since a
+// deferral isn't a failure. Use RetryDeferred to fire queued events again.
+//
+// Guards, actions and hooks receive ctx extended with the definition's
+// default context values (see definition.WithContextValues); values set in
+// ctx under the same keys win.
+//
+// Use FireResult to find out which of the competing transitions fired.
 func (m *Machine) Fire(
 	ctx context.Context,
 	event gonfa.Event,
 	payload gonfa.Payload,
 ) (bool, error) {
+	result, err := m.FireResult(ctx, event, payload)
+
+	return result != nil, err
+}
+
+// FireResult fires the event like Fire, but returns the committed
+// transition instead of a success flag, so the concrete path taken through
+// an NFA definition can be logged. The result is nil if no transition was
+// committed. Like Fire's success flag, the result is set even if the after
+// actions or success hooks of the committed transition failed.
+func (m *Machine) FireResult(
+	ctx context.Context,
+	event gonfa.Event,
+	payload gonfa.Payload,
+) (*gonfa.TransitionResult, error) {
 	m.mu.Lock()
-	defer m.mu.Unlock()
+	defer m.unlockAndNotify()
+
+	return m.fire(ctx, event, payload)
+}
 
-	// Find possible transitions
-	transitions := m.definition.GetTransitions(m.currentState, event)
+// FireIf fires the event only if cond holds for the machine state.
+// The condition is evaluated under the same lock as the transition, so the
+// state can't change between the check and the fire. If cond returns false,
+// FireIf returns (false, nil) without calling any guards, actions or hooks.
+// cond receives a lock-free view of the machine and must not call the
+// machine's methods directly.
+func (m *Machine) FireIf(
+	ctx context.Context,
+	event gonfa.Event,
+	payload gonfa.Payload,
+	cond func(gonfa.MachineState) bool,
+) (bool, error) {
+	m.mu.Lock()
+	defer m.unlockAndNotify()
+
+	if cond != nil && !cond(lockedState{m}) {
+		return false, nil
+	}
+
+	result, err := m.fire(ctx, event, payload)
+
+	return result != nil, err
+}
+
+// fire performs the transition on the event and queues the attempt for the
+// listeners.
+// Must be called under the write lock.
+func (m *Machine) fire(
+	ctx context.Context,
+	event gonfa.Event,
+	payload gonfa.Payload,
+) (*gonfa.TransitionResult, error) {
+	from := m.currentState
+
+	result, err := m.fireTransition(ctx, event, payload)
+	m.recordFire(event, from, result, err)
+
+	return result, err
+}
+
+// fireTransition performs the transition on the event.
+// Must be called under the write lock.
+func (m *Machine) fireTransition(
+	ctx context.Context,
+	event gonfa.Event,
+	payload gonfa.Payload,
+) (*gonfa.TransitionResult, error) {
+	ctx = m.withDefaults(ctx)
+	m.resetTrace()
+
+	if m.unknownEventErr && !m.definition.HasEvent(event) {
+		return nil, fmt.Errorf("%w '%s'", gonfa.ErrUnknownEvent, event)
+	}
+
+	if m.limiter != nil && !m.limiter.Allow() {
+		return nil, gonfa.ErrRateLimited
+	}
+
+	// Find possible transitions and order them by the selection strategy
+	transitions := m.selection.Order(m.enabledTransitions(event))
 
 	// For NFA, try each transition until one succeeds
+	var (
+		rejections []GuardRejection
+		deferred   bool
+		from       = m.currentState
+	)
+
+	fail := func(err error) (*gonfa.TransitionResult, error) {
+		err = m.failTransition(ctx, payload, rejections, err)
+		m.logger.Errorf("event '%s' in '%s' failed: %v", event, from, err)
+
+		return nil, &gonfa.TransitionError{
+			From:  from,
+			Event: event,
+			Err:   err,
+		}
+	}
+
 	for _, transition := range transitions {
-		success, err := m.attemptTransition(ctx, transition, payload)
+		if err := canceled(ctx, transition); err != nil {
+			return fail(err)
+		}
+
+		if transition.Trivial() {
+			// Fast path: there is nothing to check or execute, but the
+			// phases are logged as on the regular path
+			m.logPhase(transition, "guards checked")
+			m.logPhase(transition, "OnExit actions run")
+			m.logPhase(transition, "transition actions run")
+			m.changeState(transition)
+			m.logPhase(transition, "OnEntry actions run")
+			m.explain(transition, -1, false, true)
+			return m.completeTransition(ctx, transition, payload)
+		}
+
+		if err := m.checkPreconditions(ctx, transition, payload); err != nil {
+			m.explain(transition, -1, false, false)
+			return fail(err)
+		}
+
+		i, d, err := m.checkGuards(ctx, transition, payload)
 		if err != nil {
-			// Call failure hooks and return error
-			if hookErr := m.callHooks(ctx, payload, false); hookErr != nil {
-				return false, fmt.Errorf("transition failed: %v, hook error: %v",
-					err, hookErr)
+			m.explain(transition, -1, false, false)
+			return fail(fmt.Errorf("guard #%d '%s' failed: %w",
+				i, m.transitionGuardName(transition, i), err))
+		}
+
+		if i >= 0 {
+			m.logPhase(transition, fmt.Sprintf("guard #%d rejected", i))
+			m.explain(transition, i, d, false)
+			if d {
+				// Undecided guard, try next transition and retry later
+				deferred = true
+				continue
 			}
 
-			return false, err
+			// Guard failed, try next transition
+			rejections = append(rejections, GuardRejection{
+				Transition: transition,
+				GuardIndex: i,
+				Guard:      transition.Guards[i],
+			})
+
+			continue
 		}
+		m.logPhase(transition, "guards checked")
 
-		if success {
-			// Transition succeeded, call success hooks
-			return true, m.callHooks(ctx, payload, true)
+		if err := m.attemptTransition(ctx, transition, payload); err != nil {
+			m.explain(transition, -1, false, false)
+			return fail(err)
 		}
+
+		m.explain(transition, -1, false, true)
+		return m.completeTransition(ctx, transition, payload)
+	}
+
+	if deferred {
+		// A deferred event isn't a failure, so no hooks are called
+		m.deferred = append(m.deferred, deferredEvent{event, payload})
+		return nil, fmt.Errorf("%w '%s'", gonfa.ErrEventDeferred, event)
 	}
 
 	// No transition succeeded, call failure hooks
-	return false, m.callHooks(ctx, payload, false)
+	hookErr := m.callHooks(
+		withGuardRejections(ctx, rejections), payload, false)
+	m.logger.Debugf("event '%s' in '%s': no transition, failure hooks run",
+		event, from)
+	if !m.rejectionErrs {
+		return nil, hookErr
+	}
+
+	err := fmt.Errorf("%w for event '%s' in state '%s'",
+		gonfa.ErrNoTransition, event, from)
+	if len(rejections) > 0 {
+		err = fmt.Errorf("%w: %s", gonfa.ErrGuardRejected, rejections[0])
+	}
+
+	return nil, &gonfa.TransitionError{
+		From:  from,
+		Event: event,
+		Err:   errors.Join(err, hookErr),
+	}
 }
 
-// attemptTransition attempts to execute a single transition.
-// Returns true if successful, false if guards failed, error on action failure.
-func (m *Machine) attemptTransition(
+// completeTransition starts the async actions and runs the after actions and
+// success hooks of the committed transition. Returns the transition's
+// result along with the errors of the after actions and hooks.
+// Must be called under the write lock.
+func (m *Machine) completeTransition(
 	ctx context.Context,
 	transition definition.Transition,
 	payload gonfa.Payload,
+) (*gonfa.TransitionResult, error) {
+	m.recordSagaStep(transition, payload)
+	m.startAsyncActions(ctx, transition, payload)
+
+	result := &gonfa.TransitionResult{
+		From:  transition.From,
+		To:    transition.To,
+		On:    transition.On,
+		Index: transition.Index(),
+	}
+
+	err := errors.Join(
+		m.runAfterActions(ctx, transition, payload),
+		m.callHooks(ctx, payload, true))
+	if err != nil {
+		m.logger.Errorf("event '%s' from '%s' to '%s' committed with errors: %v",
+			transition.On, transition.From, transition.To, err)
+	} else {
+		m.logPhase(transition, "hooks run")
+	}
+
+	return result, err
+}
+
+// failTransition aborts the active saga and calls the failure hooks after
+// the transition failed with err. Returns the error to be reported by Fire.
+func (m *Machine) failTransition(
+	ctx context.Context,
+	payload gonfa.Payload,
+	rejections []GuardRejection,
+	err error,
+) error {
+	if m.inSaga {
+		if sagaErr := m.abortSaga(ctx); sagaErr != nil {
+			err = errors.Join(err, fmt.Errorf("saga abort failed: %w", sagaErr))
+		}
+	}
+
+	hookErr := m.callHooks(withGuardRejections(ctx, rejections), payload, false)
+	if hookErr != nil {
+		return fmt.Errorf("transition failed: %v, hook error: %v", err, hookErr)
+	}
+
+	return err
+}
+
+// FireWithBag fires the event passing the bag as the payload. Guards and
+// actions read the bag values with gonfa.Get.
+func (m *Machine) FireWithBag(
+	ctx context.Context,
+	event gonfa.Event,
+	bag gonfa.PayloadBag,
 ) (bool, error) {
-	// 1. Check all guards
-	for _, guard := range transition.Guards {
-		if !guard.Check(ctx, m, payload) {
-			return false, nil // Guard failed, try next transition
+	return m.Fire(ctx, event, bag)
+}
+
+// checkPreconditions evaluates the transition's preconditions in order.
+// Returns an error naming the first violated precondition.
+// Preconditions get a lock-free view of the machine, so they can read its
+// state without deadlocks.
+func (m *Machine) checkPreconditions(
+	ctx context.Context,
+	transition definition.Transition,
+	payload gonfa.Payload,
+) error {
+	for i, p := range transition.Preconditions {
+		if !p.Check(ctx, lockedState{m}, payload) {
+			return fmt.Errorf(
+				"precondition #%d (%T) violated on transition "+
+					"from '%s' to '%s' on event '%s'",
+				i, p, transition.From, transition.To, transition.On)
+		}
+	}
+
+	return nil
+}
+
+// checkGuards evaluates the transition's guards in order.
+// Returns the index of the first guard which rejected, deferred or failed
+// to evaluate the transition (with whether it deferred it and its error)
+// or -1 if all guards passed.
+// Guards get a lock-free view of the machine, so they can read its state
+// (e.g. history) without deadlocks.
+func (m *Machine) checkGuards(
+	ctx context.Context,
+	transition definition.Transition,
+	payload gonfa.Payload,
+) (int, bool, error) {
+	if m.parallelGuards && len(transition.Guards) > 1 {
+		return m.checkGuardsParallel(ctx, transition, payload)
+	}
+
+	for i, guard := range transition.Guards {
+		passed, deferred, err := checkGuard(
+			ctx, guard, lockedState{m}, payload)
+		if m.guardTrace != nil {
+			m.guardTrace(m.transitionGuardName(transition, i),
+				transition.From, transition.On, passed)
+		}
+		m.traceGuard(transition, i, passed)
+
+		if !passed {
+			return i, deferred, err
 		}
 	}
 
-	// 2. Execute OnExit actions for current state
+	return -1, false, nil
+}
+
+// attemptTransition executes a single transition whose guards have already
+// passed. Returns an error on action failure.
+// Like guards, actions get a lock-free view of the machine.
+func (m *Machine) attemptTransition(
+	ctx context.Context,
+	transition definition.Transition,
+	payload gonfa.Payload,
+) error {
+	internal := transition.IsInternal()
+
+	// 1. Execute OnExit actions for current state
+	if err := canceled(ctx, transition); err != nil {
+		return err
+	}
 	currentConfig := m.definition.GetStateConfig(m.currentState)
+	if internal {
+		currentConfig.OnExit = nil
+	}
 	for _, action := range currentConfig.OnExit {
-		if err := action.Execute(ctx, m, payload); err != nil {
-			return false, fmt.Errorf("OnExit action failed: %w", err)
+		err := action.Execute(ctx, lockedState{m}, payload)
+		m.traceAction(PhaseOnExit, action, err)
+		if err != nil {
+			return m.catchActionError(ctx, transition, payload,
+				fmt.Errorf("OnExit action failed: %w", err))
 		}
 	}
+	m.logPhase(transition, "OnExit actions run")
 
-	// 3. Execute transition actions
-	for _, action := range transition.Actions {
-		if err := action.Execute(ctx, m, payload); err != nil {
-			return false, fmt.Errorf("transition action failed: %w", err)
+	// 2. Execute transition actions
+	if err := canceled(ctx, transition); err != nil {
+		return err
+	}
+	for i, action := range transition.Actions {
+		err := action.Execute(ctx, lockedState{m}, payload)
+		m.traceTransitionAction(transition, i, err)
+		if err != nil {
+			if i < len(transition.ActionNames) {
+				err = fmt.Errorf("transition action '%s' failed on "+
+					"transition %s: %w", transition.ActionNames[i],
+					transitionName(transition), err)
+			} else {
+				err = fmt.Errorf("transition action failed: %w", err)
+			}
+
+			return m.catchActionError(ctx, transition, payload, err)
+		}
+	}
+	m.logPhase(transition, "transition actions run")
+
+	// 3. Change state and record history
+	if err := canceled(ctx, transition); err != nil {
+		return err
+	}
+	m.changeState(transition)
+
+	// 4. Execute OnEntry actions for new state
+	if err := canceled(ctx, transition); err != nil {
+		if m.entryRollback {
+			m.undoStateChange(transition)
+			return fmt.Errorf("%w, transition rolled back", err)
 		}
+
+		// Transition already happened, but OnEntry actions weren't run
+		return err
+	}
+	newConfig := m.definition.GetStateConfig(m.currentState)
+	if internal {
+		newConfig.OnEntry = nil
+	}
+	for _, action := range newConfig.OnEntry {
+		err := action.Execute(ctx, lockedState{m}, payload)
+		m.traceAction(PhaseOnEntry, action, err)
+		if err != nil {
+			if m.entryRollback {
+				m.undoStateChange(transition)
+				return fmt.Errorf(
+					"OnEntry action failed, transition rolled back: %w", err)
+			}
+
+			// Transition already happened, but OnEntry failed
+			return fmt.Errorf("OnEntry action failed: %w", err)
+		}
+	}
+	m.logPhase(transition, "OnEntry actions run")
+
+	return nil
+}
+
+// canceled returns an error wrapping the context error if ctx is done, so
+// Fire stops before the next phase of the transition.
+func canceled(ctx context.Context, transition definition.Transition) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("transition from '%s' to '%s' on event '%s' "+
+			"canceled: %w", transition.From, transition.To, transition.On, err)
 	}
 
-	// 4. Change state and record history
+	return nil
+}
+
+// changeState moves the machine to the transition's target state and
+// records the transition in the history.
+// Must be called under the write lock.
+func (m *Machine) changeState(transition definition.Transition) {
 	oldState := m.currentState
 	m.currentState = transition.To
 
-	historyEntry := gonfa.HistoryEntry{
+	m.recordHistory(gonfa.HistoryEntry{
 		From:      oldState,
 		To:        transition.To,
 		On:        transition.On,
-		Timestamp: time.Now(),
+		Timestamp: m.clock.Now(),
+		Label:     transition.Label,
+	})
+
+	m.traceStep(TraceStep{
+		Phase:  PhaseStateChange,
+		Name:   transitionName(transition),
+		Passed: true,
+	})
+	m.logPhase(transition, "state changed")
+}
+
+// undoStateChange moves the machine back to the transition's source state
+// and drops the history entry recorded by changeState. Entries evicted by
+// the change aren't restored.
+// Must be called under the write lock.
+func (m *Machine) undoStateChange(transition definition.Transition) {
+	m.currentState = transition.From
+
+	if n := len(m.history); n > 0 {
+		m.history = m.history[:n-1]
 	}
-	m.history = append(m.history, historyEntry)
 
-	// 5. Execute OnEntry actions for new state
-	newConfig := m.definition.GetStateConfig(m.currentState)
-	for _, action := range newConfig.OnEntry {
-		if err := action.Execute(ctx, m, payload); err != nil {
-			// Transition already happened, but OnEntry failed
-			return false, fmt.Errorf("OnEntry action failed: %w", err)
+	if n := len(m.selfTransitions); n > 0 && transition.From == transition.To {
+		m.selfTransitions = m.selfTransitions[:n-1]
+	}
+
+	m.traceStep(TraceStep{
+		Phase: PhaseStateChange,
+		Name: fmt.Sprintf("%s->%s on %s (rolled back)",
+			transition.To, transition.From, transition.On),
+		Passed: true,
+	})
+	m.logPhase(transition, "state change rolled back")
+}
+
+// catchActionError moves the machine to the error state of the
+// transition's source state (see definition.StateConfig.ErrorState) after
+// an OnExit or transition action failed with err. The move is recorded in
+// the history as if the transition led to the error state, and the error
+// state's OnEntry actions are executed; the target's ones aren't.
+// Returns err, joined with the first OnEntry error, if any. If the source
+// state has no error state, the machine stays where it is.
+func (m *Machine) catchActionError(
+	ctx context.Context,
+	transition definition.Transition,
+	payload gonfa.Payload,
+	err error,
+) error {
+	errorState := m.definition.GetStateConfig(transition.From).ErrorState
+	if errorState == "" {
+		return err
+	}
+
+	m.currentState = errorState
+
+	m.recordHistory(gonfa.HistoryEntry{
+		From:      transition.From,
+		To:        errorState,
+		On:        transition.On,
+		Timestamp: m.clock.Now(),
+	})
+
+	m.traceStep(TraceStep{
+		Phase: PhaseStateChange,
+		Name: fmt.Sprintf("%s->%s on %s",
+			transition.From, errorState, transition.On),
+		Passed: true,
+	})
+
+	for _, action := range m.definition.GetStateConfig(errorState).OnEntry {
+		entryErr := action.Execute(ctx, lockedState{m}, payload)
+		m.traceAction(PhaseOnEntry, action, entryErr)
+		if entryErr != nil {
+			return errors.Join(err,
+				fmt.Errorf("error state OnEntry action failed: %w", entryErr))
 		}
 	}
 
-	return true, nil
+	return err
+}
+
+// runAfterActions executes all after actions of the committed transition
+// and then the actions bound to its event. Failures are collected and don't
+// stop the remaining actions.
+func (m *Machine) runAfterActions(
+	ctx context.Context,
+	transition definition.Transition,
+	payload gonfa.Payload,
+) error {
+	var errs []error
+	for _, action := range transition.AfterActions {
+		err := action.Execute(ctx, lockedState{m}, payload)
+		m.traceAction(PhaseAfterAction, action, err)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("after action failed: %w", err))
+		}
+	}
+
+	for _, action := range m.definition.EventActions(transition.On) {
+		err := action.Execute(ctx, lockedState{m}, payload)
+		m.traceAction(PhaseAfterAction, action, err)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("event action failed: %w", err))
+		}
+	}
+
+	return errors.Join(errs...)
 }
 
 // callHooks executes the appropriate global hooks.
@@ -200,7 +1051,9 @@ func (m *Machine) callHooks(
 	}
 
 	for _, action := range actionsToRun {
-		if err := action.Execute(ctx, m, payload); err != nil {
+		err := action.Execute(ctx, lockedState{m}, payload)
+		m.traceAction(PhaseHook, action, err)
+		if err != nil {
 			return fmt.Errorf("hook execution failed: %w", err)
 		}
 	}
@@ -220,6 +1073,7 @@ func (m *Machine) Marshal() (*gonfa.Storable, error) {
 	return &gonfa.Storable{
 		CurrentState: m.currentState,
 		History:      historyCopy,
+		Pending:      m.pendingActions(),
 	}, nil
 }
 
@@ -233,11 +1087,144 @@ func (m *Machine) History() []gonfa.HistoryEntry {
 	return historyCopy
 }
 
+// VisitCount returns how many times the machine has entered the state, i.e.
+// the number of history entries ending in s (self-transitions included).
+// The start of the run in the initial state isn't a visit. The count covers
+// the kept history only: a restored machine counts the visits of its
+// restored history, while entries evicted by WithHistoryEviction are lost.
+func (m *Machine) VisitCount(s gonfa.State) int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return visitCount(m.history, s)
+}
+
+// HistoryPage returns a copy of at most limit history entries starting from
+// the offset (oldest first) and the total number of entries, so large
+// histories could be paginated without copying them entirely.
+// An offset out of the history, a negative offset or a non-positive limit
+// gives an empty page.
+func (m *Machine) HistoryPage(offset, limit int) ([]gonfa.HistoryEntry, int) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	total := len(m.history)
+	if offset < 0 || limit <= 0 || offset >= total {
+		return []gonfa.HistoryEntry{}, total
+	}
+
+	end := total
+	if limit < total-offset {
+		end = offset + limit
+	}
+
+	page := make([]gonfa.HistoryEntry, end-offset)
+	copy(page, m.history[offset:end])
+	return page, total
+}
+
+// StateEqual reports whether two machines are in the same runtime state.
+// It compares the current states and the histories entry by entry (From, To,
+// On and Timestamp, the latter with time.Time.Equal). The state extenders,
+// definitions and options are NOT compared.
+// Each machine is snapshotted under its own read lock, one after another,
+// so the call never holds both locks at once.
+func (m *Machine) StateEqual(other *Machine) bool {
+	if m == other {
+		return true
+	}
+
+	if other == nil {
+		return false
+	}
+
+	state, history := m.snapshot()
+	otherState, otherHistory := other.snapshot()
+
+	if state != otherState || len(history) != len(otherHistory) {
+		return false
+	}
+
+	for i, h := range history {
+		o := otherHistory[i]
+		if h.From != o.From || h.To != o.To || h.On != o.On ||
+			!h.Timestamp.Equal(o.Timestamp) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// snapshot returns the current state and a copy of the history read under
+// the read lock.
+func (m *Machine) snapshot() (gonfa.State, []gonfa.HistoryEntry) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.currentState, append([]gonfa.HistoryEntry{}, m.history...)
+}
+
 // IsInFinalState checks if the machine is currently in a final (accepting) state.
+// It's IsInFinalStateCtx with a background context and nil payload.
 func (m *Machine) IsInFinalState() bool {
+	return m.IsInFinalStateCtx(context.Background(), nil)
+}
+
+// Snapshot returns the current state, whether it's final (like
+// IsInFinalState), the history length and the last history entry, all read
+// under a single lock, so unlike separate calls of these methods the fields
+// are consistent even when events are fired concurrently.
+func (m *Machine) Snapshot() gonfa.Snapshot {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
-	return m.definition.IsFinalState(m.currentState)
+
+	s := gonfa.Snapshot{
+		CurrentState: m.currentState,
+		IsFinal:      m.inFinalState(context.Background(), nil),
+		HistoryLen:   len(m.history),
+	}
+
+	if n := len(m.history); n > 0 {
+		last := m.history[n-1]
+		s.LastTransition = &last
+	}
+
+	return s
+}
+
+// IsInFinalStateCtx checks if the machine is currently in a final
+// (accepting) state. For conditional final states (see
+// definition.StateConfig.AcceptIf) the state's guards are evaluated with
+// the given context and payload and all of them must pass; for static final
+// states it's a simple membership check.
+func (m *Machine) IsInFinalStateCtx(
+	ctx context.Context,
+	payload gonfa.Payload,
+) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.inFinalState(ctx, payload)
+}
+
+// inFinalState checks if the current state is an accepting final state.
+// Must be called under the lock.
+func (m *Machine) inFinalState(
+	ctx context.Context,
+	payload gonfa.Payload,
+) bool {
+	if !m.definition.IsFinalState(m.currentState) {
+		return false
+	}
+
+	for _, g := range m.definition.GetStateConfig(m.currentState).AcceptIf {
+		if !g.Check(ctx, lockedState{m}, payload) {
+			return false
+		}
+	}
+
+	return true
 }
 
 // StateExtender returns the attached user-defined business object.
@@ -0,0 +1,86 @@
+package machine
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dr-dobermann/gonfa/pkg/builder"
+	"github.com/dr-dobermann/gonfa/pkg/definition"
+	"github.com/dr-dobermann/gonfa/pkg/gonfa"
+)
+
+func createAbortDefinition(t *testing.T, onEntry gonfa.Action) *definition.Definition {
+	def, err := builder.New().
+		InitialState("Draft").
+		FinalStates("Done", "Cancelled").
+		OnExit("Draft", &testAction{name: "exit", err: errors.New("exit")}).
+		OnEntry("Cancelled", onEntry).
+		AddTransition("Draft", "Done", "Approve").
+		AddTransition("Draft", "Cancelled", "Cancel").
+		Build()
+	require.NoError(t, err)
+
+	return def
+}
+
+func TestAbort(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("moves to abort state", func(t *testing.T) {
+		var reason gonfa.Payload
+		def := createAbortDefinition(t, &payloadAction{
+			fn: func(p gonfa.Payload) { reason = p },
+		})
+
+		m, err := New(def, WithAbortState("Cancelled"))
+		require.NoError(t, err)
+
+		require.NoError(t, m.Abort(ctx, "customer left"))
+		assert.Equal(t, gonfa.State("Cancelled"), m.CurrentState())
+		assert.Equal(t, "customer left", reason)
+
+		history := m.History()
+		require.Len(t, history, 1)
+		assert.Equal(t, AbortEvent, history[0].On)
+		assert.Equal(t, gonfa.State("Draft"), history[0].From)
+
+		// repeated abort does nothing
+		require.NoError(t, m.Abort(ctx, "again"))
+		assert.Len(t, m.History(), 1)
+	})
+
+	t.Run("OnEntry error", func(t *testing.T) {
+		entryErr := errors.New("entry failed")
+		def := createAbortDefinition(t, &testAction{name: "entry", err: entryErr})
+
+		m, err := New(def, WithAbortState("Cancelled"))
+		require.NoError(t, err)
+
+		assert.ErrorIs(t, m.Abort(ctx, "fatal"), entryErr)
+		assert.Equal(t, gonfa.State("Cancelled"), m.CurrentState())
+	})
+
+	t.Run("abort state isn't configured", func(t *testing.T) {
+		m, err := New(createAbortDefinition(t, &testAction{}))
+		require.NoError(t, err)
+
+		assert.Error(t, m.Abort(ctx, "fatal"))
+		assert.Equal(t, gonfa.State("Draft"), m.CurrentState())
+	})
+
+	t.Run("unknown abort state", func(t *testing.T) {
+		def := createAbortDefinition(t, &testAction{})
+
+		_, err := New(def, WithAbortState("Failed"))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "abort state 'Failed' not found")
+
+		_, err = Restore(def, &gonfa.Storable{CurrentState: "Draft"}, nil,
+			WithAbortState("Failed"))
+		assert.Error(t, err)
+	})
+}
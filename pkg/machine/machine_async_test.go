@@ -0,0 +1,234 @@
+package machine
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dr-dobermann/gonfa/pkg/builder"
+	"github.com/dr-dobermann/gonfa/pkg/gonfa"
+)
+
+// createAsyncMachine creates a machine calling an external service
+// asynchronously in the Calling state.
+func createAsyncMachine(
+	t *testing.T,
+	call gonfa.Action,
+	failed gonfa.Action,
+) *Machine {
+	def, err := builder.New().
+		InitialState("Idle").
+		FinalStates("Done", "Failed", "Canceled").
+		AddTransition("Idle", "Calling", "Call").
+		WithAsyncAction(call, "CallDone", "CallFailed").
+		AddTransition("Calling", "Done", "CallDone").
+		AddTransition("Calling", "Failed", "CallFailed").
+		WithActions(failed).
+		AddTransition("Calling", "Canceled", "Cancel").
+		Build()
+	require.NoError(t, err)
+
+	m, err := New(def)
+	require.NoError(t, err)
+
+	return m
+}
+
+func TestAsyncAction(t *testing.T) {
+	ctx := context.Background()
+	noop := &testAction{}
+
+	t.Run("success", func(t *testing.T) {
+		release := make(chan struct{})
+		call := &funcAction{fn: func(context.Context, gonfa.MachineState) error {
+			<-release
+			return nil
+		}}
+
+		m := createAsyncMachine(t, call, noop)
+
+		ok, err := m.Fire(ctx, "Call", nil)
+		require.NoError(t, err)
+		require.True(t, ok)
+		assert.Equal(t, gonfa.State("Calling"), m.CurrentState())
+
+		close(release)
+		m.WaitAsync()
+
+		assert.Equal(t, gonfa.State("Done"), m.CurrentState())
+		history := m.History()
+		require.Len(t, history, 2)
+		assert.Equal(t, gonfa.Event("CallDone"), history[1].On)
+	})
+
+	t.Run("failure", func(t *testing.T) {
+		callErr := errors.New("service unavailable")
+		call := &funcAction{fn: func(context.Context, gonfa.MachineState) error {
+			return callErr
+		}}
+
+		var payload gonfa.Payload
+		m := createAsyncMachine(t, call,
+			&payloadAction{fn: func(p gonfa.Payload) { payload = p }})
+
+		ok, err := m.Fire(ctx, "Call", nil)
+		require.NoError(t, err)
+		require.True(t, ok)
+
+		m.WaitAsync()
+
+		assert.Equal(t, gonfa.State("Failed"), m.CurrentState())
+		assert.Equal(t, callErr, payload)
+	})
+
+	t.Run("cancellation", func(t *testing.T) {
+		call := &funcAction{fn: func(ctx context.Context, _ gonfa.MachineState) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}}
+
+		m := createAsyncMachine(t, call, noop)
+
+		callCtx, cancel := context.WithCancel(ctx)
+		ok, err := m.Fire(callCtx, "Call", nil)
+		require.NoError(t, err)
+		require.True(t, ok)
+
+		cancel()
+		m.WaitAsync()
+
+		assert.Equal(t, gonfa.State("Failed"), m.CurrentState())
+	})
+
+	t.Run("machine left the pending state", func(t *testing.T) {
+		release := make(chan struct{})
+		call := &funcAction{fn: func(context.Context, gonfa.MachineState) error {
+			<-release
+			return nil
+		}}
+
+		m := createAsyncMachine(t, call, noop)
+
+		ok, err := m.Fire(ctx, "Call", nil)
+		require.NoError(t, err)
+		require.True(t, ok)
+
+		ok, err = m.Fire(ctx, "Cancel", nil)
+		require.NoError(t, err)
+		require.True(t, ok)
+
+		close(release)
+		m.WaitAsync()
+
+		assert.Equal(t, gonfa.State("Canceled"), m.CurrentState())
+		assert.Len(t, m.History(), 2)
+	})
+}
+
+func TestAsyncActionValidation(t *testing.T) {
+	_, err := builder.New().
+		InitialState("Idle").
+		FinalStates("Done").
+		AddTransition("Idle", "Calling", "Call").
+		WithAsyncAction(&testAction{}, "CallDone", "CallFailed").
+		AddTransition("Calling", "Done", "CallDone").
+		Build()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(),
+		"pending state 'Calling' has no transition on "+
+			"async action completion event 'CallFailed'")
+}
+
+func TestAsyncActionPersistence(t *testing.T) {
+	ctx := context.Background()
+	noop := &testAction{}
+
+	release := make(chan struct{})
+	defer close(release)
+
+	blocked := &funcAction{fn: func(context.Context, gonfa.MachineState) error {
+		<-release
+		return nil
+	}}
+
+	m := createAsyncMachine(t, blocked, noop)
+
+	ok, err := m.Fire(ctx, "Call", nil)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	stored, err := m.Marshal()
+	require.NoError(t, err)
+	require.Equal(t, []gonfa.PendingAction{
+		{From: "Idle", To: "Calling", On: "Call", Index: 0},
+	}, stored.Pending)
+
+	data, err := json.Marshal(stored)
+	require.NoError(t, err)
+
+	var loaded gonfa.Storable
+	require.NoError(t, json.Unmarshal(data, &loaded))
+
+	t.Run("resume", func(t *testing.T) {
+		calls := 0
+		call := &funcAction{fn: func(context.Context, gonfa.MachineState) error {
+			calls++
+			return nil
+		}}
+
+		restored, err := Restore(
+			createAsyncMachine(t, call, noop).definition, &loaded, nil)
+		require.NoError(t, err)
+
+		// Restored actions are kept until resumed
+		again, err := restored.Marshal()
+		require.NoError(t, err)
+		assert.Equal(t, loaded.Pending, again.Pending)
+
+		require.NoError(t, restored.ResumeAsync(ctx))
+		restored.WaitAsync()
+
+		assert.Equal(t, 1, calls)
+		assert.Equal(t, gonfa.State("Done"), restored.CurrentState())
+
+		done, err := restored.Marshal()
+		require.NoError(t, err)
+		assert.Empty(t, done.Pending)
+	})
+
+	t.Run("unknown pending action", func(t *testing.T) {
+		bad := loaded
+		bad.Pending = []gonfa.PendingAction{
+			{From: "Idle", To: "Calling", On: "Call", Index: 1},
+		}
+
+		_, err := Restore(m.definition, &bad, nil)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid pending async action")
+	})
+
+	t.Run("migration drops pending action", func(t *testing.T) {
+		restored, err := Restore(m.definition, &loaded, nil)
+		require.NoError(t, err)
+
+		// the same states without the async action
+		def, err := builder.New().
+			InitialState("Idle").
+			FinalStates("Done", "Failed", "Canceled").
+			AddTransition("Idle", "Calling", "Call").
+			AddTransition("Calling", "Done", "CallDone").
+			AddTransition("Calling", "Failed", "CallFailed").
+			AddTransition("Calling", "Canceled", "Cancel").
+			Build()
+		require.NoError(t, err)
+
+		err = restored.MigrateTo(def)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid pending async action")
+		assert.Same(t, m.definition, restored.Definition())
+	})
+}
@@ -0,0 +1,50 @@
+package machine
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dr-dobermann/gonfa/pkg/builder"
+	"github.com/dr-dobermann/gonfa/pkg/gonfa"
+)
+
+func TestAvailableEvents(t *testing.T) {
+	def, err := builder.New().
+		InitialState("Draft").
+		FinalStates("Approved", "Rejected").
+		AddTransition("Draft", "Review", "Submit").
+		AddTransition("Draft", "Rejected", "Cancel").
+		AddTransition("Draft", "Approved", "FastTrack").
+		WithGuards(&testGuard{result: false}).
+		AddTransition("Draft", "Approved", "Archive").
+		WithDisabled().
+		AddTransition("Review", "Approved", "Approve").
+		AddTransition("Review", "Rejected", "Reject").
+		Build()
+	require.NoError(t, err)
+
+	machine, err := New(def)
+	require.NoError(t, err)
+
+	assert.Equal(t, []gonfa.Event{"Cancel", "FastTrack", "Submit"},
+		machine.AvailableEvents())
+	assert.Equal(t, []gonfa.Event{"Cancel", "Submit"},
+		machine.AvailableEventsPassing(context.Background(), nil))
+
+	success, err := machine.Fire(context.Background(), "Submit", nil)
+	require.NoError(t, err)
+	require.True(t, success)
+
+	assert.Equal(t, []gonfa.Event{"Approve", "Reject"},
+		machine.AvailableEvents())
+
+	success, err = machine.Fire(context.Background(), "Approve", nil)
+	require.NoError(t, err)
+	require.True(t, success)
+
+	assert.Empty(t, machine.AvailableEvents())
+	assert.NotNil(t, machine.AvailableEventsPassing(context.Background(), nil))
+}
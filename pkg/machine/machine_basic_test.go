@@ -2,6 +2,7 @@ package machine
 
 import (
 	"context"
+	"math"
 	"testing"
 	"time"
 
@@ -28,7 +29,7 @@ func createTestDefinition(t *testing.T) *definition.Definition {
 
 func TestNewMachine(t *testing.T) {
 	def := createTestDefinition(t)
-	machine, err := New(def, nil)
+	machine, err := New(def)
 
 	require.NoError(t, err)
 	assert.NotNil(t, machine)
@@ -36,6 +37,94 @@ func TestNewMachine(t *testing.T) {
 	assert.Empty(t, machine.History())
 }
 
+func TestPreviewAfter(t *testing.T) {
+	def, err := builder.New().
+		InitialState("Review").
+		FinalStates("Archived").
+		AddTransition("Review", "Approved", "Approve").
+		WithGuards(&testGuard{result: false}).
+		AddTransition("Review", "Escalated", "Approve").
+		AddTransition("Review", "Draft", "Revise").
+		AddTransition("Draft", "Review", "Submit").
+		AddTransition("Approved", "Archived", "Archive").
+		AddTransition("Approved", "Draft", "Revise").
+		AddTransition("Escalated", "Approved", "Confirm").
+		Build()
+	require.NoError(t, err)
+
+	machine, err := New(def)
+	require.NoError(t, err)
+
+	t.Run("competing transitions are merged", func(t *testing.T) {
+		events, ok := machine.PreviewAfter("Approve")
+		require.True(t, ok)
+		assert.Equal(t, []gonfa.Event{"Archive", "Confirm", "Revise"}, events)
+	})
+
+	t.Run("event isn't accepted", func(t *testing.T) {
+		events, ok := machine.PreviewAfter("Archive")
+		assert.False(t, ok)
+		assert.Nil(t, events)
+	})
+
+	t.Run("disabled transitions are skipped", func(t *testing.T) {
+		machine.SetTransitionEnabled("Review", "Escalated", "Approve", false)
+		machine.SetTransitionEnabled("Approved", "Draft", "Revise", false)
+
+		events, ok := machine.PreviewAfter("Approve")
+		require.True(t, ok)
+		assert.Equal(t, []gonfa.Event{"Archive"}, events)
+	})
+
+	assert.Equal(t, gonfa.State("Review"), machine.CurrentState())
+	assert.Empty(t, machine.History())
+}
+
+func TestNewWithInitial(t *testing.T) {
+	def := createTestDefinition(t)
+
+	t.Run("starts in the given state", func(t *testing.T) {
+		machine, err := NewWithInitial(def, nil, "Middle")
+		require.NoError(t, err)
+
+		assert.Equal(t, gonfa.State("Middle"), machine.CurrentState())
+		assert.Empty(t, machine.History())
+		assert.False(t, machine.IsInFinalState())
+
+		// OnEntry of the starting state isn't executed
+		entry := def.GetStateConfig("Middle").OnEntry[0].(*testAction)
+		assert.False(t, entry.executed)
+
+		success, err := machine.Fire(context.Background(), "ToEnd", nil)
+		require.NoError(t, err)
+		assert.True(t, success)
+		assert.True(t, machine.IsInFinalState())
+	})
+
+	t.Run("final initial state", func(t *testing.T) {
+		machine, err := NewWithInitial(def, nil, "End")
+		require.NoError(t, err)
+		assert.True(t, machine.IsInFinalState())
+	})
+
+	t.Run("unknown state", func(t *testing.T) {
+		machine, err := NewWithInitial(def, nil, "Unknown")
+		require.Error(t, err)
+		assert.Nil(t, machine)
+		assert.Contains(t, err.Error(), "initial state 'Unknown' not found")
+	})
+
+	t.Run("nil definition", func(t *testing.T) {
+		_, err := NewWithInitial(nil, nil, "Start")
+		require.Error(t, err)
+	})
+
+	t.Run("options are validated", func(t *testing.T) {
+		_, err := NewWithInitial(def, nil, "Middle", WithAbortState("Nowhere"))
+		require.Error(t, err)
+	})
+}
+
 func TestRestoreMachine(t *testing.T) {
 	def := createTestDefinition(t)
 	extender := &testStateExtender{data: "test"}
@@ -94,7 +183,7 @@ func TestRestoreMachine(t *testing.T) {
 
 func TestCurrentState(t *testing.T) {
 	def := createTestDefinition(t)
-	machine, err := New(def, nil)
+	machine, err := New(def)
 	require.NoError(t, err)
 
 	assert.Equal(t, gonfa.State("Start"), machine.CurrentState())
@@ -102,7 +191,8 @@ func TestCurrentState(t *testing.T) {
 
 func TestHistory(t *testing.T) {
 	def := createTestDefinition(t)
-	machine, err := New(def, nil)
+	start := time.Date(2025, 1, 2, 10, 0, 0, 0, time.UTC)
+	machine, err := New(def, WithClock(&fakeClock{now: start, step: time.Minute}))
 	require.NoError(t, err)
 
 	// Initially empty
@@ -119,16 +209,78 @@ func TestHistory(t *testing.T) {
 	assert.Equal(t, gonfa.State("Start"), history[0].From)
 	assert.Equal(t, gonfa.State("Middle"), history[0].To)
 	assert.Equal(t, gonfa.Event("ToMiddle"), history[0].On)
+	assert.Equal(t, start.Add(time.Minute), history[0].Timestamp)
+
+	success, err = machine.Fire(context.Background(), "ToEnd", nil)
+	require.NoError(t, err)
+	assert.True(t, success)
+
+	history = machine.History()
+	require.Len(t, history, 2)
+	assert.Equal(t, start.Add(2*time.Minute), history[1].Timestamp)
+}
+
+func TestHistoryPage(t *testing.T) {
+	machine := createPingPongMachine(t)
+	for range 5 {
+		success, err := machine.Fire(context.Background(), "Hit", nil)
+		require.NoError(t, err)
+		require.True(t, success)
+	}
+	history := machine.History()
+
+	tests := []struct {
+		name          string
+		offset, limit int
+		expected      []gonfa.HistoryEntry
+	}{
+		{"first page", 0, 2, history[:2]},
+		{"middle page", 2, 2, history[2:4]},
+		{"last page", 4, 2, history[4:]},
+		{"whole history", 0, 100, history},
+		{"offset out of history", 5, 2, []gonfa.HistoryEntry{}},
+		{"negative offset", -1, 2, []gonfa.HistoryEntry{}},
+		{"zero limit", 0, 0, []gonfa.HistoryEntry{}},
+		{"huge limit", 1, math.MaxInt, history[1:]},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page, total := machine.HistoryPage(tt.offset, tt.limit)
+			assert.Equal(t, 5, total)
+			assert.Equal(t, tt.expected, page)
+		})
+	}
+
+	t.Run("page is a copy", func(t *testing.T) {
+		page, _ := machine.HistoryPage(0, 1)
+		page[0].To = "Changed"
+		assert.Equal(t, gonfa.State("Pong"), machine.History()[0].To)
+	})
 }
 
 func TestStateExtender(t *testing.T) {
 	def := createTestDefinition(t)
 	extender := &testStateExtender{data: "test data"}
 
-	machine, err := New(def, extender)
+	machine, err := New(def, WithExtender(extender))
 	require.NoError(t, err)
 
 	assert.Equal(t, extender, machine.StateExtender())
+
+	machine, err = NewWithExtender(def, extender)
+	require.NoError(t, err)
+	assert.Equal(t, extender, machine.StateExtender())
+
+	// options given after the extender override it
+	other := &testStateExtender{data: "other"}
+	machine, err = NewWithExtender(def, extender, WithExtender(other))
+	require.NoError(t, err)
+	assert.Equal(t, other, machine.StateExtender())
+
+	machine, err = New(def)
+	require.NoError(t, err)
+	assert.Nil(t, machine.StateExtender())
 }
 
 func TestIsInFinalState(t *testing.T) {
@@ -139,7 +291,7 @@ func TestIsInFinalState(t *testing.T) {
 		Build()
 	require.NoError(t, err)
 
-	machine, err := New(def, nil)
+	machine, err := New(def)
 	require.NoError(t, err)
 
 	// Initially not in final state
@@ -151,3 +303,134 @@ func TestIsInFinalState(t *testing.T) {
 	assert.True(t, success)
 	assert.True(t, machine.IsInFinalState())
 }
+
+func TestStateEqual(t *testing.T) {
+	def := createTestDefinition(t)
+	ts := time.Date(2025, 9, 24, 10, 0, 0, 0, time.UTC)
+	storable := &gonfa.Storable{
+		CurrentState: "Middle",
+		History: []gonfa.HistoryEntry{
+			{From: "Start", To: "Middle", On: "ToMiddle", Timestamp: ts},
+		},
+	}
+
+	m1, err := Restore(def, storable, &testStateExtender{data: "one"})
+	require.NoError(t, err)
+	m2, err := Restore(def, storable, &testStateExtender{data: "two"})
+	require.NoError(t, err)
+
+	assert.True(t, m1.StateEqual(m1))
+	assert.True(t, m1.StateEqual(m2), "extenders must not be compared")
+	assert.False(t, m1.StateEqual(nil))
+
+	fresh, err := New(def)
+	require.NoError(t, err)
+	assert.False(t, m1.StateEqual(fresh))
+
+	storable.History[0].Timestamp = ts.Add(time.Second)
+	m3, err := Restore(def, storable, nil)
+	require.NoError(t, err)
+	assert.False(t, m1.StateEqual(m3))
+}
+
+func TestMachineDefinition(t *testing.T) {
+	def := createTestDefinition(t)
+	machine, err := New(def)
+	require.NoError(t, err)
+
+	assert.Same(t, def, machine.Definition())
+}
+
+func TestIsInFinalStateCtx(t *testing.T) {
+	accept := &payloadGuard{fn: func(p gonfa.Payload) bool {
+		signed, _ := gonfa.Get[bool](p, "signed")
+		return signed
+	}}
+
+	def, err := builder.New().
+		InitialState("Draft").
+		FinalStateIf("Signed", accept).
+		AddTransition("Draft", "Signed", "Sign").
+		Build()
+	require.NoError(t, err)
+
+	machine, err := New(def)
+	require.NoError(t, err)
+
+	signed := gonfa.NewPayloadBag().Set("signed", true)
+	assert.False(t, machine.IsInFinalStateCtx(context.Background(), signed))
+
+	success, err := machine.Fire(context.Background(), "Sign", nil)
+	require.NoError(t, err)
+	require.True(t, success)
+
+	assert.True(t, machine.IsInFinalStateCtx(context.Background(), signed))
+	assert.False(t, machine.IsInFinalStateCtx(context.Background(),
+		gonfa.NewPayloadBag().Set("signed", false)))
+
+	// the context-free variant evaluates guards with a nil payload
+	assert.False(t, machine.IsInFinalState())
+}
+
+func TestRestoreValidated(t *testing.T) {
+	def := createTestDefinition(t)
+
+	t.Run("valid state", func(t *testing.T) {
+		storable := &gonfa.Storable{
+			CurrentState: "Middle",
+			History: []gonfa.HistoryEntry{
+				{From: "Start", To: "Middle", On: "ToMiddle", Timestamp: time.Now()},
+			},
+		}
+
+		machine, err := RestoreValidated(def, storable, nil)
+		require.NoError(t, err)
+		assert.Equal(t, gonfa.State("Middle"), machine.CurrentState())
+	})
+
+	t.Run("corrupt history", func(t *testing.T) {
+		storable := &gonfa.Storable{
+			CurrentState: "Middle",
+			History: []gonfa.HistoryEntry{
+				{From: "Start", To: "Middle", On: "ToMiddle"},
+			},
+		}
+
+		machine, err := RestoreValidated(def, storable, nil)
+		assert.Nil(t, machine)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid storable state")
+		assert.Contains(t, err.Error(), "zero timestamp")
+	})
+
+	t.Run("unknown state", func(t *testing.T) {
+		storable := &gonfa.Storable{CurrentState: "Unknown"}
+
+		machine, err := RestoreValidated(def, storable, nil)
+		assert.Nil(t, machine)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "not found in definition")
+	})
+}
+
+func TestAccepts(t *testing.T) {
+	guard := &testGuard{result: false}
+	def, err := builder.New().
+		InitialState("Start").
+		FinalStates("End").
+		AddTransition("Start", "End", "Finish").
+		WithGuards(guard).
+		Build()
+	require.NoError(t, err)
+
+	machine, err := New(def)
+	require.NoError(t, err)
+
+	assert.True(t, machine.Accepts("Finish"))
+	assert.False(t, machine.Accepts("Unknown"))
+	assert.Zero(t, guard.calls, "guards aren't evaluated")
+
+	ok, err := machine.Fire(context.Background(), "Finish", nil)
+	require.NoError(t, err)
+	assert.False(t, ok, "accepted event can still be rejected by guards")
+}
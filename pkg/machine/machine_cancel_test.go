@@ -0,0 +1,161 @@
+package machine
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dr-dobermann/gonfa/pkg/builder"
+	"github.com/dr-dobermann/gonfa/pkg/gonfa"
+)
+
+func TestFireContextCancellation(t *testing.T) {
+	// slow ignores the context, like a blocking call would
+	slow := &funcAction{fn: func(context.Context, gonfa.MachineState) error {
+		time.Sleep(50 * time.Millisecond)
+		return nil
+	}}
+
+	t.Run("deadline during transition actions", func(t *testing.T) {
+		next := &testAction{}
+		onEntry := &testAction{}
+		failed := &testAction{}
+
+		def, err := builder.New().
+			InitialState("Draft").
+			FinalStates("Done").
+			OnEntry("Done", onEntry).
+			AddTransition("Draft", "Done", "Finish").
+			WithActions(slow).
+			WithAfterActions(next).
+			WithFailureHooks(failed).
+			Build()
+		require.NoError(t, err)
+
+		machine, err := New(def)
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithTimeout(context.Background(),
+			5*time.Millisecond)
+		defer cancel()
+
+		success, err := machine.Fire(ctx, "Finish", nil)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.False(t, success)
+
+		assert.Equal(t, gonfa.State("Draft"), machine.CurrentState())
+		assert.Empty(t, machine.History())
+		assert.Zero(t, onEntry.calls)
+		assert.Zero(t, next.calls)
+		assert.Equal(t, 1, failed.calls)
+	})
+
+	t.Run("canceled before guards", func(t *testing.T) {
+		guard := &testGuard{result: true}
+
+		def, err := builder.New().
+			InitialState("Draft").
+			FinalStates("Done").
+			AddTransition("Draft", "Done", "Finish").
+			WithGuards(guard).
+			Build()
+		require.NoError(t, err)
+
+		machine, err := New(def)
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		success, err := machine.Fire(ctx, "Finish", nil)
+		require.ErrorIs(t, err, context.Canceled)
+		assert.False(t, success)
+		assert.Zero(t, guard.calls)
+		assert.Equal(t, gonfa.State("Draft"), machine.CurrentState())
+	})
+
+	t.Run("deadline after state change", func(t *testing.T) {
+		def, err := builder.New().
+			InitialState("Draft").
+			FinalStates("Done").
+			OnEntry("Done", slow, &testAction{}).
+			AddTransition("Draft", "Done", "Finish").
+			Build()
+		require.NoError(t, err)
+
+		machine, err := New(def)
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithTimeout(context.Background(),
+			5*time.Millisecond)
+		defer cancel()
+
+		// the OnEntry phase has already started, so the state is changed
+		_, err = machine.Fire(ctx, "Finish", nil)
+		require.NoError(t, err)
+		assert.Equal(t, gonfa.State("Done"), machine.CurrentState())
+	})
+	t.Run("canceled right after state change", func(t *testing.T) {
+		for _, rollback := range []bool{false, true} {
+			t.Run(fmt.Sprintf("rollback %t", rollback), func(t *testing.T) {
+				onEntry := &testAction{}
+
+				def, err := builder.New().
+					InitialState("Draft").
+					FinalStates("Done").
+					OnEntry("Done", onEntry).
+					AddTransition("Draft", "Done", "Finish").
+					Build()
+				require.NoError(t, err)
+
+				ctx, cancel := context.WithCancel(context.Background())
+				defer cancel()
+
+				machine, err := New(def,
+					WithLogger(&cancelingLogger{
+						phase:  "state changed",
+						cancel: cancel,
+					}),
+					WithEntryFailureRollback(rollback))
+				require.NoError(t, err)
+
+				success, err := machine.Fire(ctx, "Finish", nil)
+				require.ErrorIs(t, err, context.Canceled)
+				assert.False(t, success)
+				assert.Zero(t, onEntry.calls)
+
+				if rollback {
+					assert.Contains(t, err.Error(), "transition rolled back")
+					assert.Equal(t, gonfa.State("Draft"),
+						machine.CurrentState())
+					assert.Empty(t, machine.History())
+
+					return
+				}
+
+				assert.Equal(t, gonfa.State("Done"), machine.CurrentState())
+				assert.Len(t, machine.History(), 1)
+			})
+		}
+	})
+}
+
+// cancelingLogger cancels the context once the given transition phase is
+// logged.
+type cancelingLogger struct {
+	phase  string
+	cancel context.CancelFunc
+}
+
+func (l *cancelingLogger) Debugf(format string, args ...any) {
+	if strings.HasSuffix(fmt.Sprintf(format, args...), l.phase) {
+		l.cancel()
+	}
+}
+
+func (l *cancelingLogger) Errorf(string, ...any) {}
@@ -0,0 +1,62 @@
+package machine
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dr-dobermann/gonfa/pkg/builder"
+	"github.com/dr-dobermann/gonfa/pkg/gonfa"
+)
+
+func TestCanFire(t *testing.T) {
+	isManager := &testGuard{result: false}
+	isAuthor := &testGuard{result: true}
+	save := &testAction{}
+	hook := &testAction{}
+
+	def, err := builder.New().
+		InitialState("Draft").
+		FinalStates("Approved", "Withdrawn").
+		OnExit("Draft", save).
+		AddTransition("Draft", "Approved", "Approve").
+		WithGuards(isManager).
+		AddTransition("Draft", "Approved", "Decide").
+		WithGuards(isManager).
+		AddTransition("Draft", "Withdrawn", "Decide").
+		WithGuards(isAuthor).
+		WithActions(save).
+		AddTransition("Draft", "Withdrawn", "Drop").
+		WithPreconditions(&testGuard{result: false}).
+		WithSuccessHooks(hook).
+		WithFailureHooks(hook).
+		Build()
+	require.NoError(t, err)
+
+	machine, err := New(def, WithRateLimit(1, time.Hour))
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	assert.True(t, machine.CanFire(ctx, "Decide", nil))
+	assert.False(t, machine.CanFire(ctx, "Approve", nil))
+	assert.False(t, machine.CanFire(ctx, "Drop", nil))
+	assert.False(t, machine.CanFire(ctx, "Unknown", nil))
+
+	// nothing was executed or recorded
+	assert.Equal(t, gonfa.State("Draft"), machine.CurrentState())
+	assert.Empty(t, machine.History())
+	assert.Zero(t, save.calls)
+	assert.Zero(t, hook.calls)
+
+	machine.SetTransitionEnabled("Draft", "Withdrawn", "Decide", false)
+	assert.False(t, machine.CanFire(ctx, "Decide", nil))
+	machine.SetTransitionEnabled("Draft", "Withdrawn", "Decide", true)
+
+	// the rate limiter token is still available
+	success, err := machine.Fire(ctx, "Decide", nil)
+	require.NoError(t, err)
+	assert.True(t, success)
+}
@@ -0,0 +1,115 @@
+package machine
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dr-dobermann/gonfa/pkg/builder"
+	"github.com/dr-dobermann/gonfa/pkg/gonfa"
+)
+
+func TestOnActionError(t *testing.T) {
+	actionErr := errors.New("payment declined")
+
+	type fixture struct {
+		machine     *Machine
+		charge      *testAction
+		paidEntry   *testAction
+		failedEntry *testAction
+		failureHook *testAction
+		pendingExit *testAction
+	}
+
+	setup := func(t *testing.T, exitErr error) fixture {
+		f := fixture{
+			charge:      &testAction{name: "charge", err: actionErr},
+			paidEntry:   &testAction{name: "paidEntry"},
+			failedEntry: &testAction{name: "failedEntry"},
+			failureHook: &testAction{name: "failureHook"},
+			pendingExit: &testAction{name: "pendingExit", err: exitErr},
+		}
+
+		def, err := builder.New().
+			InitialState("Pending").
+			FinalStates("Paid", "Failed").
+			OnExit("Pending", f.pendingExit).
+			OnEntry("Paid", f.paidEntry).
+			OnEntry("Failed", f.failedEntry).
+			OnActionError("Pending", "Failed").
+			AddTransition("Pending", "Paid", "Pay").
+			WithActions(f.charge).
+			WithFailureHooks(f.failureHook).
+			Build()
+		require.NoError(t, err)
+
+		f.machine, err = New(def)
+		require.NoError(t, err)
+
+		return f
+	}
+
+	t.Run("transition action fails", func(t *testing.T) {
+		f := setup(t, nil)
+
+		success, err := f.machine.Fire(context.Background(), "Pay", nil)
+		assert.False(t, success)
+		require.ErrorIs(t, err, actionErr)
+
+		assert.Equal(t, gonfa.State("Failed"), f.machine.CurrentState())
+		assert.True(t, f.failedEntry.executed)
+		assert.False(t, f.paidEntry.executed)
+		assert.True(t, f.failureHook.executed)
+
+		history := f.machine.History()
+		require.Len(t, history, 1)
+		assert.Equal(t, gonfa.State("Pending"), history[0].From)
+		assert.Equal(t, gonfa.State("Failed"), history[0].To)
+		assert.Equal(t, gonfa.Event("Pay"), history[0].On)
+	})
+
+	t.Run("OnExit action fails", func(t *testing.T) {
+		f := setup(t, actionErr)
+
+		success, err := f.machine.Fire(context.Background(), "Pay", nil)
+		assert.False(t, success)
+		require.ErrorIs(t, err, actionErr)
+
+		assert.Equal(t, gonfa.State("Failed"), f.machine.CurrentState())
+		assert.False(t, f.charge.executed)
+		assert.Equal(t, 1, f.pendingExit.calls)
+	})
+
+	t.Run("error state OnEntry fails", func(t *testing.T) {
+		f := setup(t, nil)
+		entryErr := errors.New("alert failed")
+		f.failedEntry.err = entryErr
+
+		_, err := f.machine.Fire(context.Background(), "Pay", nil)
+		require.ErrorIs(t, err, actionErr)
+		require.ErrorIs(t, err, entryErr)
+		assert.Equal(t, gonfa.State("Failed"), f.machine.CurrentState())
+	})
+}
+
+func TestOnActionErrorNotSet(t *testing.T) {
+	def, err := builder.New().
+		InitialState("Pending").
+		FinalStates("Paid").
+		AddTransition("Pending", "Paid", "Pay").
+		WithActions(&testAction{err: errors.New("declined")}).
+		Build()
+	require.NoError(t, err)
+
+	machine, err := New(def)
+	require.NoError(t, err)
+
+	success, err := machine.Fire(context.Background(), "Pay", nil)
+	assert.False(t, success)
+	require.Error(t, err)
+	assert.Equal(t, gonfa.State("Pending"), machine.CurrentState())
+	assert.Empty(t, machine.History())
+}
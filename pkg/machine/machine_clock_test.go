@@ -0,0 +1,53 @@
+package machine
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dr-dobermann/gonfa/pkg/builder"
+	"github.com/dr-dobermann/gonfa/pkg/guards"
+)
+
+func TestWithClock(t *testing.T) {
+	promoStart := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	clock := &fakeClock{now: promoStart.Add(-time.Hour), step: 0}
+
+	def, err := builder.New().
+		InitialState("Cart").
+		FinalStates("Ordered").
+		AddTransition("Cart", "Ordered", "Checkout").
+		WithGuards(guards.WithinTimeWindow(promoStart,
+			promoStart.Add(24*time.Hour))).
+		Build()
+	require.NoError(t, err)
+
+	machine, err := New(def, WithClock(clock), WithClock(nil))
+	require.NoError(t, err)
+	assert.Equal(t, clock.now, machine.Now())
+
+	ctx := context.Background()
+
+	// guards read the machine's clock
+	success, err := machine.Fire(ctx, "Checkout", nil)
+	require.NoError(t, err)
+	assert.False(t, success)
+
+	clock.now = promoStart.Add(time.Hour)
+
+	success, err = machine.Fire(ctx, "Checkout", nil)
+	require.NoError(t, err)
+	require.True(t, success)
+	assert.Equal(t, clock.now, machine.History()[0].Timestamp)
+}
+
+func TestDefaultClock(t *testing.T) {
+	machine, err := New(createTestDefinition(t))
+	require.NoError(t, err)
+
+	before := time.Now()
+	assert.False(t, machine.Now().Before(before))
+}
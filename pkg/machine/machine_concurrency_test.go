@@ -21,7 +21,7 @@ func TestConcurrentAccess(t *testing.T) {
 		Build()
 	require.NoError(t, err)
 
-	machine, err := New(def, nil)
+	machine, err := New(def)
 	require.NoError(t, err)
 
 	const numGoroutines = 10
@@ -72,7 +72,7 @@ func TestConcurrentAccess(t *testing.T) {
 
 func TestConcurrentMarshal(t *testing.T) {
 	def := createTestDefinition(t)
-	machine, err := New(def, nil)
+	machine, err := New(def)
 	require.NoError(t, err)
 
 	const numGoroutines = 10
@@ -114,7 +114,7 @@ func TestConcurrentFire(t *testing.T) {
 		Build()
 	require.NoError(t, err)
 
-	machine, err := New(def, nil)
+	machine, err := New(def)
 	require.NoError(t, err)
 
 	const numGoroutines = 5
@@ -151,7 +151,7 @@ func TestConcurrentStateExtender(t *testing.T) {
 	def := createTestDefinition(t)
 	extender := &testStateExtender{data: "test"}
 
-	machine, err := New(def, extender)
+	machine, err := New(def, WithExtender(extender))
 	require.NoError(t, err)
 
 	const numGoroutines = 10
@@ -186,3 +186,74 @@ func TestConcurrentStateExtender(t *testing.T) {
 		require.NoError(t, err)
 	}
 }
+
+func TestFireIf(t *testing.T) {
+	def := createTestDefinition(t)
+
+	t.Run("condition holds", func(t *testing.T) {
+		machine, err := New(def)
+		require.NoError(t, err)
+
+		success, err := machine.FireIf(context.Background(), "ToMiddle", nil,
+			func(s gonfa.MachineState) bool {
+				return s.CurrentState() == "Start"
+			})
+		require.NoError(t, err)
+		assert.True(t, success)
+		assert.Equal(t, gonfa.State("Middle"), machine.CurrentState())
+	})
+
+	t.Run("condition fails", func(t *testing.T) {
+		failureHook := &testAction{}
+		def, err := builder.New().
+			InitialState("Start").
+			FinalStates("End").
+			AddTransition("Start", "End", "ToEnd").
+			WithFailureHooks(failureHook).
+			Build()
+		require.NoError(t, err)
+
+		machine, err := New(def)
+		require.NoError(t, err)
+
+		success, err := machine.FireIf(context.Background(), "ToEnd", nil,
+			func(gonfa.MachineState) bool { return false })
+		require.NoError(t, err)
+		assert.False(t, success)
+		assert.Equal(t, gonfa.State("Start"), machine.CurrentState())
+		assert.False(t, failureHook.executed)
+	})
+
+	t.Run("only one concurrent caller wins", func(t *testing.T) {
+		machine, err := New(def)
+		require.NoError(t, err)
+
+		const numGoroutines = 20
+		var (
+			wg   sync.WaitGroup
+			mu   sync.Mutex
+			wins int
+		)
+
+		for range numGoroutines {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				success, err := machine.FireIf(context.Background(), "ToMiddle", nil,
+					func(s gonfa.MachineState) bool {
+						return s.CurrentState() == "Start"
+					})
+				assert.NoError(t, err)
+				if success {
+					mu.Lock()
+					wins++
+					mu.Unlock()
+				}
+			}()
+		}
+		wg.Wait()
+
+		assert.Equal(t, 1, wins)
+		assert.Len(t, machine.History(), 1)
+	})
+}
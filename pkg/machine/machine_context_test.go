@@ -0,0 +1,66 @@
+package machine
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dr-dobermann/gonfa/pkg/builder"
+	"github.com/dr-dobermann/gonfa/pkg/gonfa"
+)
+
+type tenantKey struct{}
+
+func TestContextValues(t *testing.T) {
+	var seen []any
+	record := &funcAction{fn: func(ctx context.Context, _ gonfa.MachineState) error {
+		seen = append(seen, ctx.Value(tenantKey{}))
+		return nil
+	}}
+	hasTenant := &tenantGuard{key: tenantKey{}}
+
+	def, err := builder.New().
+		InitialState("Draft").
+		FinalStates("Done").
+		AddTransition("Draft", "Review", "Submit").
+		WithGuards(hasTenant).
+		WithActions(record).
+		AddTransition("Review", "Done", "Approve").
+		WithSuccessHooks(record).
+		WithContextValues(map[any]any{tenantKey{}: "default"}).
+		Build()
+	require.NoError(t, err)
+
+	machine, err := New(def)
+	require.NoError(t, err)
+
+	success, err := machine.Fire(context.Background(), "Submit", nil)
+	require.NoError(t, err)
+	require.True(t, success)
+
+	// caller's values win over the defaults
+	ctx := context.WithValue(context.Background(), tenantKey{}, "caller")
+	success, err = machine.Fire(ctx, "Approve", nil)
+	require.NoError(t, err)
+	require.True(t, success)
+
+	assert.Equal(t, []any{"default", "default", "caller"}, seen)
+	assert.Equal(t, []any{"default"}, hasTenant.seen)
+}
+
+// tenantGuard passes if the context has a value under key.
+type tenantGuard struct {
+	key  any
+	seen []any
+}
+
+func (g *tenantGuard) Check(
+	ctx context.Context,
+	state gonfa.MachineState,
+	payload gonfa.Payload,
+) bool {
+	g.seen = append(g.seen, ctx.Value(g.key))
+	return ctx.Value(g.key) != nil
+}
@@ -0,0 +1,111 @@
+package machine
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dr-dobermann/gonfa/pkg/builder"
+	"github.com/dr-dobermann/gonfa/pkg/gonfa"
+)
+
+// readinessGuard defers the transition until the data is ready.
+type readinessGuard struct {
+	ready bool
+	pass  bool
+}
+
+func (g *readinessGuard) Check(
+	ctx context.Context,
+	state gonfa.MachineState,
+	payload gonfa.Payload,
+) bool {
+	return g.ready && g.pass
+}
+
+func (g *readinessGuard) CheckDefer(
+	ctx context.Context,
+	state gonfa.MachineState,
+	payload gonfa.Payload,
+) (bool, bool) {
+	return g.pass, !g.ready
+}
+
+func TestDeferringGuard(t *testing.T) {
+	ctx := context.Background()
+
+	create := func(
+		t *testing.T,
+		guards []gonfa.Guard,
+		failure gonfa.Action,
+		opts ...Option,
+	) *Machine {
+		def, err := builder.New().
+			InitialState("Waiting").
+			FinalStates("Processed").
+			AddTransition("Waiting", "Processed", "Process").
+			WithGuards(guards...).
+			WithFailureHooks(failure).
+			Build()
+		require.NoError(t, err)
+
+		m, err := New(def, opts...)
+		require.NoError(t, err)
+
+		return m
+	}
+
+	t.Run("deferred and retried", func(t *testing.T) {
+		guard := &readinessGuard{pass: true}
+		failure := &testAction{}
+		m := create(t, []gonfa.Guard{guard}, failure)
+
+		ok, err := m.Fire(ctx, "Process", "order-1")
+		assert.False(t, ok)
+		require.ErrorIs(t, err, gonfa.ErrEventDeferred)
+		assert.Zero(t, failure.calls, "a deferral isn't a failure")
+		assert.Equal(t, []gonfa.Event{"Process"}, m.DeferredEvents())
+
+		// Still not ready: the event stays in the queue
+		fired, err := m.RetryDeferred(ctx)
+		require.NoError(t, err)
+		assert.Zero(t, fired)
+		assert.Equal(t, []gonfa.Event{"Process"}, m.DeferredEvents())
+
+		guard.ready = true
+		fired, err = m.RetryDeferred(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 1, fired)
+		assert.Empty(t, m.DeferredEvents())
+		assert.Equal(t, gonfa.State("Processed"), m.CurrentState())
+	})
+
+	t.Run("rejected on retry", func(t *testing.T) {
+		guard := &readinessGuard{}
+		failure := &testAction{}
+		m := create(t, []gonfa.Guard{guard}, failure)
+
+		_, err := m.Fire(ctx, "Process", nil)
+		require.ErrorIs(t, err, gonfa.ErrEventDeferred)
+
+		guard.ready = true
+		fired, err := m.RetryDeferred(ctx)
+		require.NoError(t, err)
+		assert.Zero(t, fired)
+		assert.Empty(t, m.DeferredEvents())
+		assert.Equal(t, 1, failure.calls)
+		assert.Equal(t, gonfa.State("Waiting"), m.CurrentState())
+	})
+
+	t.Run("parallel guards", func(t *testing.T) {
+		m := create(t, []gonfa.Guard{
+			&testGuard{result: true},
+			&readinessGuard{pass: true},
+		}, &testAction{}, WithParallelGuards())
+
+		_, err := m.Fire(ctx, "Process", nil)
+		require.ErrorIs(t, err, gonfa.ErrEventDeferred)
+	})
+}
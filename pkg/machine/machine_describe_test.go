@@ -0,0 +1,86 @@
+package machine
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dr-dobermann/gonfa/pkg/builder"
+	"github.com/dr-dobermann/gonfa/pkg/gonfa"
+)
+
+func TestDescribeCurrent(t *testing.T) {
+	def, err := builder.New().
+		InitialState("Draft").
+		FinalStates("Approved", "Rejected").
+		OnEntry("Review", &testAction{}, &testAction{}).
+		OnExit("Review", &testAction{}).
+		AddTransition("Draft", "Review", "Submit").
+		AddTransition("Review", "Rejected", "Reject").
+		AddTransition("Review", "Approved", "Approve").
+		AddTransition("Review", "Draft", "Approve").
+		WithGuards(&testGuard{result: false}).
+		Build()
+	require.NoError(t, err)
+
+	machine, err := New(def)
+	require.NoError(t, err)
+
+	info := machine.DescribeCurrent()
+	assert.Equal(t, gonfa.State("Draft"), info.State)
+	assert.False(t, info.IsFinal)
+	assert.Equal(t, []gonfa.Event{"Submit"}, info.Events)
+	assert.True(t, info.EnteredAt.IsZero())
+
+	before := time.Now()
+	success, err := machine.Fire(context.Background(), "Submit", nil)
+	require.NoError(t, err)
+	require.True(t, success)
+
+	info = machine.DescribeCurrent()
+	assert.Equal(t, gonfa.State("Review"), info.State)
+	assert.Equal(t, []gonfa.Event{"Approve", "Reject"}, info.Events)
+	assert.Equal(t, 2, info.EntryActions)
+	assert.Equal(t, 1, info.ExitActions)
+	assert.False(t, info.EnteredAt.Before(before))
+
+	success, err = machine.Fire(context.Background(), "Approve", nil)
+	require.NoError(t, err)
+	require.True(t, success)
+
+	info = machine.DescribeCurrent()
+	assert.True(t, info.IsFinal)
+	assert.Empty(t, info.Events)
+}
+
+func TestDescribeCurrentConsistency(t *testing.T) {
+	def, err := builder.New().
+		InitialState("Draft").
+		FinalStates("Approved").
+		FinalStateIf("Signed", &testGuard{result: false}).
+		AddTransition("Draft", "Signed", "Sign").
+		AddTransition("Draft", "Approved", "Approve").
+		AddTransition("Draft", "Approved", "Skip").
+		WithDisabled().
+		Build()
+	require.NoError(t, err)
+
+	machine, err := New(def)
+	require.NoError(t, err)
+
+	info := machine.DescribeCurrent()
+	assert.Equal(t, []gonfa.Event{"Approve", "Sign"}, info.Events)
+	assert.Equal(t, machine.AvailableEvents(), info.Events)
+
+	success, err := machine.Fire(context.Background(), "Sign", nil)
+	require.NoError(t, err)
+	require.True(t, success)
+
+	info = machine.DescribeCurrent()
+	assert.False(t, info.IsFinal, "AcceptIf guard rejects")
+	assert.Equal(t, machine.IsInFinalState(), info.IsFinal)
+	assert.Equal(t, machine.Snapshot().IsFinal, info.IsFinal)
+}
@@ -0,0 +1,78 @@
+package machine
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dr-dobermann/gonfa/pkg/builder"
+	"github.com/dr-dobermann/gonfa/pkg/gonfa"
+)
+
+func TestSetTransitionEnabled(t *testing.T) {
+	guard := &testGuard{result: true}
+	failed := &testAction{name: "failed"}
+
+	def, err := builder.New().
+		InitialState("Draft").
+		FinalStates("Done").
+		AddTransition("Draft", "Done", "Approve").
+		WithGuards(guard).
+		AddTransition("Draft", "Done", "Skip").
+		WithDisabled().
+		WithFailureHooks(failed).
+		Build()
+	require.NoError(t, err)
+
+	ctx := context.Background()
+
+	t.Run("runtime toggle", func(t *testing.T) {
+		machine, err := New(def)
+		require.NoError(t, err)
+
+		machine.SetTransitionEnabled("Draft", "Done", "Approve", false)
+		assert.False(t, machine.Accepts("Approve"))
+
+		success, err := machine.Fire(ctx, "Approve", nil)
+		require.NoError(t, err)
+		assert.False(t, success)
+		assert.Zero(t, guard.calls, "guards of a disabled transition run")
+		assert.True(t, failed.executed)
+		assert.Equal(t, gonfa.State("Draft"), machine.CurrentState())
+
+		machine.SetTransitionEnabled("Draft", "Done", "Approve", true)
+		assert.True(t, machine.Accepts("Approve"))
+
+		success, err = machine.Fire(ctx, "Approve", nil)
+		require.NoError(t, err)
+		assert.True(t, success)
+	})
+
+	t.Run("disabled in definition", func(t *testing.T) {
+		machine, err := New(def)
+		require.NoError(t, err)
+
+		assert.False(t, machine.Accepts("Skip"))
+		success, err := machine.Fire(ctx, "Skip", nil)
+		require.NoError(t, err)
+		assert.False(t, success)
+
+		machine.SetTransitionEnabled("Draft", "Done", "Skip", true)
+		success, err = machine.Fire(ctx, "Skip", nil)
+		require.NoError(t, err)
+		assert.True(t, success)
+	})
+
+	t.Run("other machines aren't affected", func(t *testing.T) {
+		m1, err := New(def)
+		require.NoError(t, err)
+		m2, err := New(def)
+		require.NoError(t, err)
+
+		m1.SetTransitionEnabled("Draft", "Done", "Approve", false)
+		assert.False(t, m1.Accepts("Approve"))
+		assert.True(t, m2.Accepts("Approve"))
+	})
+}
@@ -0,0 +1,117 @@
+package machine
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dr-dobermann/gonfa/pkg/builder"
+	"github.com/dr-dobermann/gonfa/pkg/gonfa"
+)
+
+// lookupGuard fails to evaluate the transition while err is set.
+type lookupGuard struct {
+	pass bool
+	err  error
+}
+
+func (g *lookupGuard) Check(
+	ctx context.Context,
+	state gonfa.MachineState,
+	payload gonfa.Payload,
+) bool {
+	return g.pass
+}
+
+func (g *lookupGuard) CheckErr(
+	ctx context.Context,
+	state gonfa.MachineState,
+	payload gonfa.Payload,
+) (bool, error) {
+	return g.pass, g.err
+}
+
+func TestErrGuard(t *testing.T) {
+	ctx := context.Background()
+	errLookup := errors.New("lookup failed")
+
+	create := func(
+		t *testing.T,
+		guard gonfa.Guard,
+		failure gonfa.Action,
+		opts ...Option,
+	) *Machine {
+		// the fallback transition is taken only if the guard rejects the
+		// first one
+		def, err := builder.New().
+			InitialState("Start").
+			FinalStates("Approved", "Manual").
+			AddTransition("Start", "Approved", "Check").
+			WithGuards(guard, &testGuard{result: true}).
+			AddTransition("Start", "Manual", "Check").
+			WithFailureHooks(failure).
+			Build()
+		require.NoError(t, err)
+
+		m, err := New(def, opts...)
+		require.NoError(t, err)
+
+		return m
+	}
+
+	t.Run("plain guard", func(t *testing.T) {
+		m := create(t, &testGuard{result: false}, &testAction{})
+
+		ok, err := m.Fire(ctx, "Check", nil)
+		require.NoError(t, err)
+		assert.True(t, ok)
+		assert.Equal(t, gonfa.State("Manual"), m.CurrentState())
+	})
+
+	t.Run("passed", func(t *testing.T) {
+		m := create(t, &lookupGuard{pass: true}, &testAction{})
+
+		ok, err := m.Fire(ctx, "Check", nil)
+		require.NoError(t, err)
+		assert.True(t, ok)
+		assert.Equal(t, gonfa.State("Approved"), m.CurrentState())
+	})
+
+	t.Run("rejected", func(t *testing.T) {
+		m := create(t, &lookupGuard{pass: false}, &testAction{})
+
+		ok, err := m.Fire(ctx, "Check", nil)
+		require.NoError(t, err)
+		assert.True(t, ok)
+		assert.Equal(t, gonfa.State("Manual"), m.CurrentState())
+	})
+
+	for name, opts := range map[string][]Option{
+		"failed":          nil,
+		"failed parallel": {WithParallelGuards()},
+	} {
+		t.Run(name, func(t *testing.T) {
+			failure := &testAction{}
+			m := create(t,
+				&lookupGuard{pass: true, err: errLookup}, failure, opts...)
+
+			ok, err := m.Fire(ctx, "Check", nil)
+			assert.False(t, ok)
+			require.ErrorIs(t, err, errLookup)
+			assert.ErrorContains(t, err,
+				"guard #0 '*machine.lookupGuard' failed")
+
+			var te *gonfa.TransitionError
+			require.ErrorAs(t, err, &te)
+			assert.Equal(t, gonfa.State("Start"), te.From)
+
+			// the fallback transition isn't attempted
+			assert.Equal(t, gonfa.State("Start"), m.CurrentState())
+			assert.Empty(t, m.History())
+			assert.Equal(t, 1, failure.calls)
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package machine
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dr-dobermann/gonfa/pkg/builder"
+	"github.com/dr-dobermann/gonfa/pkg/gonfa"
+)
+
+func TestTransitionErrors(t *testing.T) {
+	errCharge := errors.New("card declined")
+
+	def, err := builder.New().
+		InitialState("Draft").
+		FinalStates("Approved", "Paid").
+		AddTransition("Draft", "Approved", "Approve").
+		WithGuards(&testGuard{result: false}).
+		AddTransition("Draft", "Paid", "Pay").
+		WithActions(&testAction{err: errCharge}).
+		Build()
+	require.NoError(t, err)
+
+	ctx := context.Background()
+
+	t.Run("action error", func(t *testing.T) {
+		machine, err := New(def)
+		require.NoError(t, err)
+
+		success, err := machine.Fire(ctx, "Pay", nil)
+		assert.False(t, success)
+		require.ErrorIs(t, err, errCharge)
+		assert.EqualError(t, err, "transition action failed: card declined")
+
+		var te *gonfa.TransitionError
+		require.ErrorAs(t, err, &te)
+		assert.Equal(t, gonfa.State("Draft"), te.From)
+		assert.Equal(t, gonfa.Event("Pay"), te.Event)
+		assert.NotErrorIs(t, err, gonfa.ErrGuardRejected)
+	})
+
+	t.Run("rejections aren't errors by default", func(t *testing.T) {
+		machine, err := New(def)
+		require.NoError(t, err)
+
+		success, err := machine.Fire(ctx, "Approve", nil)
+		require.NoError(t, err)
+		assert.False(t, success)
+	})
+
+	t.Run("rejection errors", func(t *testing.T) {
+		machine, err := New(def, WithRejectionErrors())
+		require.NoError(t, err)
+
+		success, err := machine.Fire(ctx, "Approve", nil)
+		assert.False(t, success)
+		require.ErrorIs(t, err, gonfa.ErrGuardRejected)
+		assert.Contains(t, err.Error(),
+			"Draft->Approved on Approve blocked by guard #0")
+
+		success, err = machine.Fire(ctx, "Archive", nil)
+		assert.False(t, success)
+		require.ErrorIs(t, err, gonfa.ErrNoTransition)
+		assert.EqualError(t, err,
+			"no transition for event 'Archive' in state 'Draft'")
+
+		var te *gonfa.TransitionError
+		require.ErrorAs(t, err, &te)
+		assert.Equal(t, gonfa.Event("Archive"), te.Event)
+	})
+}
@@ -0,0 +1,111 @@
+package machine
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dr-dobermann/gonfa/pkg/builder"
+	"github.com/dr-dobermann/gonfa/pkg/registry"
+)
+
+func TestExecutionTrace(t *testing.T) {
+	isManager := &testGuard{result: false}
+	isAuthor := &testGuard{result: true}
+	save := &testAction{}
+	notify := &testAction{}
+	failing := &testAction{err: errors.New("boom")}
+
+	reg := registry.New()
+	require.NoError(t, reg.RegisterGuard("isManager", isManager))
+	require.NoError(t, reg.RegisterAction("save", save))
+
+	def, err := builder.New().
+		InitialState("Draft").
+		FinalStates("Approved").
+		OnExit("Draft", save).
+		OnEntry("Withdrawn", notify).
+		AddTransition("Draft", "Approved", "Decide").
+		WithGuards(isManager).
+		AddTransition("Draft", "Withdrawn", "Decide").
+		WithGuards(isAuthor).
+		WithActions(save).
+		AddTransition("Withdrawn", "Approved", "Reopen").
+		WithActions(failing).
+		WithSuccessHooks(notify).
+		WithFailureHooks(notify).
+		Build()
+	require.NoError(t, err)
+
+	steps := func(trace []TraceStep) []string {
+		s := make([]string, len(trace))
+		for i, step := range trace {
+			s[i] = step.String()
+		}
+		return s
+	}
+
+	t.Run("disabled by default", func(t *testing.T) {
+		machine, err := New(def)
+		require.NoError(t, err)
+
+		_, err = machine.Fire(context.Background(), "Decide", nil)
+		require.NoError(t, err)
+		assert.Nil(t, machine.LastTrace())
+	})
+
+	t.Run("steps of the last event", func(t *testing.T) {
+		machine, err := New(def,
+			WithExecutionTrace(), WithRegistry(reg))
+		require.NoError(t, err)
+
+		success, err := machine.Fire(context.Background(), "Decide", nil)
+		require.NoError(t, err)
+		require.True(t, success)
+
+		assert.Equal(t, []string{
+			"guard isManager: rejected",
+			"guard *machine.testGuard: passed",
+			"onExit save: passed",
+			"action save: passed",
+			"stateChange Draft->Withdrawn on Decide",
+			"onEntry *machine.testAction: passed",
+			"hook *machine.testAction: passed",
+		}, steps(machine.LastTrace()))
+
+		success, err = machine.Fire(context.Background(), "Reopen", nil)
+		require.Error(t, err)
+		require.False(t, success)
+
+		trace := machine.LastTrace()
+		require.Len(t, trace, 2)
+		assert.Equal(t, PhaseAction, trace[0].Phase)
+		assert.False(t, trace[0].Passed)
+		assert.EqualError(t, trace[0].Err, "boom")
+		assert.Equal(t, "hook *machine.testAction: passed", trace[1].String())
+	})
+
+	t.Run("trace is capped", func(t *testing.T) {
+		machine, err := New(def, WithExecutionTrace())
+		require.NoError(t, err)
+
+		machine.mu.Lock()
+		for range maxTraceSteps + 1 {
+			machine.traceStep(TraceStep{Phase: PhaseHook})
+		}
+		machine.mu.Unlock()
+
+		assert.Len(t, machine.LastTrace(), maxTraceSteps)
+	})
+}
+
+func TestTraceStepString(t *testing.T) {
+	assert.Equal(t, "action save: failed: boom", TraceStep{
+		Phase: PhaseAction, Name: "save", Err: errors.New("boom"),
+	}.String())
+	assert.Equal(t, "guard g: rejected",
+		TraceStep{Phase: PhaseGuard, Name: "g"}.String())
+}
@@ -0,0 +1,96 @@
+package machine
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dr-dobermann/gonfa/pkg/builder"
+	"github.com/dr-dobermann/gonfa/pkg/gonfa"
+)
+
+func TestFireExplain(t *testing.T) {
+	ctx := context.Background()
+
+	def, err := builder.New().
+		InitialState("Start").
+		FinalStates("A", "B", "C").
+		AddTransition("Start", "A", "Go").
+		WithGuards(&testGuard{result: true}, &testGuard{result: false}).
+		AddTransition("Start", "B", "Go").
+		WithGuards(&testGuard{result: false}).
+		AddTransition("Start", "C", "Go").
+		WithGuards(&testGuard{result: true}).
+		Build()
+	require.NoError(t, err)
+
+	t.Run("rejected candidates", func(t *testing.T) {
+		m, err := New(def)
+		require.NoError(t, err)
+
+		success, diag, err := m.FireExplain(ctx, "Go", nil)
+		require.NoError(t, err)
+		assert.True(t, success)
+		assert.Equal(t, gonfa.State("C"), m.CurrentState())
+
+		assert.Equal(t, gonfa.State("Start"), diag.From)
+		assert.Equal(t, gonfa.Event("Go"), diag.Event)
+		assert.Equal(t, []gonfa.CandidateDiagnostic{
+			{To: "A", Index: 0, GuardIndex: 1, Guard: "*machine.testGuard"},
+			{To: "B", Index: 1, GuardIndex: 0, Guard: "*machine.testGuard"},
+			{To: "C", Index: 2, GuardIndex: -1, Committed: true},
+		}, diag.Candidates)
+
+		assert.Equal(t,
+			"Start->A on Go (#0): rejected by guard #1 '*machine.testGuard'\n"+
+				"Start->B on Go (#1): rejected by guard #0 '*machine.testGuard'\n"+
+				"Start->C on Go (#2): committed",
+			diag.String())
+	})
+
+	t.Run("no transition", func(t *testing.T) {
+		m, err := New(def)
+		require.NoError(t, err)
+
+		success, diag, err := m.FireExplain(ctx, "Unknown", nil)
+		require.NoError(t, err)
+		assert.False(t, success)
+		assert.Empty(t, diag.Candidates)
+		assert.Equal(t,
+			"no transition defined for event 'Unknown' in state 'Start'",
+			diag.String())
+	})
+
+	t.Run("failed candidate", func(t *testing.T) {
+		def, err := builder.New().
+			InitialState("Start").
+			FinalStates("End").
+			AddTransition("Start", "End", "Go").
+			WithActions(&testAction{err: errors.New("boom")}).
+			Build()
+		require.NoError(t, err)
+
+		m, err := New(def)
+		require.NoError(t, err)
+
+		success, diag, err := m.FireExplain(ctx, "Go", nil)
+		require.Error(t, err)
+		assert.False(t, success)
+		assert.Equal(t, []gonfa.CandidateDiagnostic{
+			{To: "End", GuardIndex: -1},
+		}, diag.Candidates)
+		assert.Equal(t, "Start->End on Go (#0): failed", diag.String())
+	})
+
+	t.Run("Fire isn't affected", func(t *testing.T) {
+		m, err := New(def)
+		require.NoError(t, err)
+
+		_, _, err = m.FireExplain(ctx, "Unknown", nil)
+		require.NoError(t, err)
+		assert.Nil(t, m.diagnostic)
+	})
+}
@@ -0,0 +1,326 @@
+package machine
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dr-dobermann/gonfa/pkg/builder"
+	"github.com/dr-dobermann/gonfa/pkg/gonfa"
+)
+
+func createPingPongMachine(t *testing.T, opts ...Option) *Machine {
+	def, err := builder.New().
+		InitialState("Ping").
+		FinalStates("End").
+		AddTransition("Ping", "Pong", "Hit").
+		AddTransition("Pong", "Ping", "Hit").
+		AddTransition("Pong", "End", "Stop").
+		Build()
+	require.NoError(t, err)
+
+	machine, err := New(def, opts...)
+	require.NoError(t, err)
+
+	return machine
+}
+
+func TestHistoryEviction(t *testing.T) {
+	t.Run("evicted entries are passed to callback", func(t *testing.T) {
+		var evicted []gonfa.HistoryEntry
+		var machine *Machine
+		machine = createPingPongMachine(t,
+			WithHistoryEviction(2, func(e gonfa.HistoryEntry) {
+				// reading the machine from the callback must not deadlock
+				_ = machine.CurrentState()
+				evicted = append(evicted, e)
+			}))
+
+		for range 5 {
+			success, err := machine.Fire(context.Background(), "Hit", nil)
+			require.NoError(t, err)
+			require.True(t, success)
+		}
+
+		history := machine.History()
+		require.Len(t, history, 2)
+		assert.Equal(t, gonfa.State("Ping"), history[0].To)
+		assert.Equal(t, gonfa.State("Pong"), history[1].To)
+
+		require.Len(t, evicted, 3)
+		assert.Equal(t, gonfa.State("Pong"), evicted[0].To)
+		assert.Equal(t, gonfa.State("Ping"), evicted[1].To)
+		assert.Equal(t, gonfa.State("Pong"), evicted[2].To)
+	})
+
+	t.Run("restored history is trimmed", func(t *testing.T) {
+		def := createPingPongMachine(t).definition
+		now := time.Now()
+		storable := &gonfa.Storable{
+			CurrentState: "Ping",
+			History: []gonfa.HistoryEntry{
+				{From: "Ping", To: "Pong", On: "Hit", Timestamp: now},
+				{From: "Pong", To: "Ping", On: "Hit", Timestamp: now},
+				{From: "Ping", To: "Pong", On: "Hit", Timestamp: now},
+				{From: "Pong", To: "Ping", On: "Hit", Timestamp: now},
+			},
+		}
+
+		var evicted int
+		machine, err := Restore(def, storable, nil,
+			WithHistoryEviction(1, func(gonfa.HistoryEntry) { evicted++ }))
+		require.NoError(t, err)
+
+		assert.Len(t, machine.History(), 1)
+		assert.Equal(t, 3, evicted)
+	})
+
+	t.Run("non-positive cap means unbounded", func(t *testing.T) {
+		machine := createPingPongMachine(t, WithHistoryEviction(0, nil))
+		for range 5 {
+			_, err := machine.Fire(context.Background(), "Hit", nil)
+			require.NoError(t, err)
+		}
+
+		assert.Len(t, machine.History(), 5)
+	})
+}
+
+func TestWithMaxHistory(t *testing.T) {
+	def, err := builder.New().
+		InitialState("Ping").
+		FinalStates("Done").
+		AddTransition("Ping", "Pong", "Hit").
+		AddTransition("Pong", "Ping", "Hit").
+		AddTransition("Ping", "Done", "Stop").
+		Build()
+	require.NoError(t, err)
+
+	var evicted []gonfa.HistoryEntry
+	machine, err := New(def,
+		WithHistoryEviction(10, func(e gonfa.HistoryEntry) {
+			evicted = append(evicted, e)
+		}),
+		// keeps the eviction callback
+		WithMaxHistory(2))
+	require.NoError(t, err)
+
+	for range 3 {
+		success, err := machine.Fire(context.Background(), "Hit", nil)
+		require.NoError(t, err)
+		require.True(t, success)
+	}
+
+	history := machine.History()
+	require.Len(t, history, 2)
+	assert.Equal(t, gonfa.State("Ping"), history[0].To)
+	assert.Equal(t, gonfa.State("Pong"), history[1].To)
+	require.Len(t, evicted, 1)
+	assert.Equal(t, gonfa.State("Pong"), evicted[0].To)
+}
+
+func TestMaxHistoryMarshal(t *testing.T) {
+	machine := createPingPongMachine(t, WithMaxHistory(3))
+
+	for range 10 {
+		success, err := machine.Fire(context.Background(), "Hit", nil)
+		require.NoError(t, err)
+		require.True(t, success)
+	}
+
+	history := machine.History()
+	require.Len(t, history, 3)
+	// the newest entries survive: the 10th transition leads back to Ping
+	assert.Equal(t, gonfa.State("Ping"), history[2].To)
+	assert.Equal(t, gonfa.State("Pong"), history[1].To)
+
+	storable, err := machine.Marshal()
+	require.NoError(t, err)
+	assert.Equal(t, history, storable.History)
+}
+
+func TestOnSelfTransition(t *testing.T) {
+	def, err := builder.New().
+		InitialState("Idle").
+		FinalStates("Done").
+		AddTransition("Idle", "Idle", "Refresh").
+		AddTransition("Idle", "Done", "Finish").
+		Build()
+	require.NoError(t, err)
+
+	var (
+		machine *Machine
+		self    []gonfa.HistoryEntry
+	)
+	machine, err = New(def,
+		WithOnSelfTransition(func(e gonfa.HistoryEntry) {
+			// reading the machine from the callback must not deadlock
+			_ = machine.CurrentState()
+			self = append(self, e)
+		}))
+	require.NoError(t, err)
+
+	for _, event := range []gonfa.Event{"Refresh", "Refresh", "Finish"} {
+		success, err := machine.Fire(context.Background(), event, nil)
+		require.NoError(t, err)
+		require.True(t, success)
+	}
+
+	require.Len(t, self, 2)
+	for _, e := range self {
+		assert.Equal(t, gonfa.State("Idle"), e.From)
+		assert.Equal(t, gonfa.State("Idle"), e.To)
+		assert.Equal(t, gonfa.Event("Refresh"), e.On)
+	}
+	assert.Len(t, machine.History(), 3)
+}
+
+func TestVisitCount(t *testing.T) {
+	ctx := context.Background()
+
+	createReviewMachine := func(
+		t *testing.T,
+		state *gonfa.Storable,
+	) (*Machine, *[]int) {
+		var seen []int
+		reminder := &stateGuard{fn: func(s gonfa.MachineState) bool {
+			seen = append(seen, s.VisitCount("Review"))
+			return true
+		}}
+
+		def, err := builder.New().
+			InitialState("Draft").
+			FinalStates("Approved").
+			AddTransition("Draft", "Review", "Submit").
+			AddTransition("Review", "Draft", "Reject").
+			AddTransition("Review", "Approved", "Approve").
+			WithGuards(reminder).
+			Build()
+		require.NoError(t, err)
+
+		var m *Machine
+		if state == nil {
+			m, err = New(def)
+		} else {
+			m, err = Restore(def, state, nil)
+		}
+		require.NoError(t, err)
+
+		return m, &seen
+	}
+
+	t.Run("new machine", func(t *testing.T) {
+		m, seen := createReviewMachine(t, nil)
+		assert.Zero(t, m.VisitCount("Draft"), "initial state isn't a visit")
+
+		for _, e := range []gonfa.Event{
+			"Submit", "Reject", "Submit", "Reject", "Submit", "Approve",
+		} {
+			ok, err := m.Fire(ctx, e, nil)
+			require.NoError(t, err)
+			require.True(t, ok)
+		}
+
+		assert.Equal(t, 3, m.VisitCount("Review"))
+		assert.Equal(t, 2, m.VisitCount("Draft"))
+		assert.Equal(t, 1, m.VisitCount("Approved"))
+		assert.Zero(t, m.VisitCount("Unknown"))
+		assert.Equal(t, []int{3}, *seen)
+	})
+
+	t.Run("restored machine", func(t *testing.T) {
+		now := time.Now()
+		m, seen := createReviewMachine(t, &gonfa.Storable{
+			CurrentState: "Review",
+			History: []gonfa.HistoryEntry{
+				{From: "Draft", To: "Review", On: "Submit", Timestamp: now},
+				{From: "Review", To: "Draft", On: "Reject", Timestamp: now},
+				{From: "Draft", To: "Review", On: "Submit", Timestamp: now},
+			},
+		})
+
+		assert.Equal(t, 2, m.VisitCount("Review"))
+
+		ok, err := m.Fire(ctx, "Approve", nil)
+		require.NoError(t, err)
+		require.True(t, ok)
+		assert.Equal(t, []int{2}, *seen)
+	})
+
+	t.Run("read by OnEntry action", func(t *testing.T) {
+		var reminders []int
+		reminder := &funcAction{fn: func(
+			_ context.Context,
+			s gonfa.MachineState,
+		) error {
+			// send the reminder only on the 3rd visit
+			if n := s.VisitCount("Review"); n == 3 {
+				reminders = append(reminders, n)
+			}
+
+			return nil
+		}}
+
+		def, err := builder.New().
+			InitialState("Draft").
+			FinalStates("Approved").
+			AddTransition("Draft", "Review", "Submit").
+			AddTransition("Review", "Draft", "Reject").
+			AddTransition("Review", "Approved", "Approve").
+			OnEntry("Review", reminder).
+			Build()
+		require.NoError(t, err)
+
+		m, err := New(def)
+		require.NoError(t, err)
+
+		for _, e := range []gonfa.Event{
+			"Submit", "Reject", "Submit", "Reject", "Submit",
+		} {
+			ok, err := m.Fire(ctx, e, nil)
+			require.NoError(t, err)
+			require.True(t, ok)
+		}
+
+		assert.Equal(t, []int{3}, reminders)
+	})
+}
+
+func TestHistoryLabels(t *testing.T) {
+	def, err := builder.New().
+		InitialState("Draft").
+		FinalStates("Approved").
+		AddTransition("Draft", "Review", "Submit").
+		AddTransition("Review", "Approved", "Approve").
+		WithLabel("Approved by manager").
+		Build()
+	require.NoError(t, err)
+
+	machine, err := New(def)
+	require.NoError(t, err)
+
+	for _, e := range []gonfa.Event{"Submit", "Approve"} {
+		success, err := machine.Fire(context.Background(), e, nil)
+		require.NoError(t, err)
+		require.True(t, success)
+	}
+
+	history := machine.History()
+	require.Len(t, history, 2)
+	assert.Empty(t, history[0].Label)
+	assert.Equal(t, gonfa.Event("Approve"), history[1].On)
+	assert.Equal(t, "Approved by manager", history[1].Label)
+
+	// the raw events are kept, so the history still can be replayed
+	replayed, err := New(def)
+	require.NoError(t, err)
+	for _, h := range history {
+		success, err := replayed.Fire(context.Background(), h.On, nil)
+		require.NoError(t, err)
+		require.True(t, success)
+	}
+	assert.Empty(t, gonfa.DiffHistory(history, replayed.History()))
+}
@@ -0,0 +1,99 @@
+package machine
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dr-dobermann/gonfa/pkg/builder"
+	"github.com/dr-dobermann/gonfa/pkg/gonfa"
+)
+
+func TestAddListener(t *testing.T) {
+	failing := &testAction{err: errors.New("boom")}
+
+	def, err := builder.New().
+		InitialState("Draft").
+		FinalStates("Approved").
+		AddTransition("Draft", "Review", "Submit").
+		AddTransition("Review", "Approved", "Approve").
+		AddTransition("Review", "Draft", "Reject").
+		WithActions(failing).
+		Build()
+	require.NoError(t, err)
+
+	machine, err := New(def)
+	require.NoError(t, err)
+
+	var first, second []gonfa.FireEvent
+	machine.AddListener(func(e gonfa.FireEvent) {
+		// the lock is released, so the machine can be read
+		assert.NotEmpty(t, machine.CurrentState())
+		first = append(first, e)
+	})
+	machine.AddListener(func(e gonfa.FireEvent) {
+		second = append(second, e)
+	})
+	machine.AddListener(nil)
+
+	ctx := context.Background()
+
+	_, err = machine.Fire(ctx, "Submit", nil)
+	require.NoError(t, err)
+
+	_, err = machine.Fire(ctx, "Unknown", nil)
+	require.NoError(t, err)
+
+	_, err = machine.Fire(ctx, "Reject", nil)
+	require.Error(t, err)
+
+	require.Len(t, first, 3)
+	assert.Equal(t, first, second)
+
+	assert.Equal(t, gonfa.FireEvent{
+		Event: "Submit", From: "Draft", To: "Review", Success: true,
+	}, first[0])
+	assert.Equal(t, gonfa.FireEvent{Event: "Unknown", From: "Review"}, first[1])
+
+	assert.Equal(t, gonfa.Event("Reject"), first[2].Event)
+	assert.Equal(t, gonfa.State("Review"), first[2].From)
+	assert.Empty(t, first[2].To)
+	assert.False(t, first[2].Success)
+	assert.ErrorIs(t, first[2].Err, failing.err)
+}
+
+func TestAddListenerFireIf(t *testing.T) {
+	def, err := builder.New().
+		InitialState("Draft").
+		FinalStates("Review").
+		AddTransition("Draft", "Review", "Submit").
+		Build()
+	require.NoError(t, err)
+
+	machine, err := New(def)
+	require.NoError(t, err)
+
+	var events []gonfa.FireEvent
+	machine.AddListener(func(e gonfa.FireEvent) {
+		events = append(events, e)
+	})
+
+	ctx := context.Background()
+
+	// a rejected condition isn't a Fire attempt
+	success, err := machine.FireIf(ctx, "Submit", nil,
+		func(gonfa.MachineState) bool { return false })
+	require.NoError(t, err)
+	require.False(t, success)
+	assert.Empty(t, events)
+
+	success, err = machine.FireIf(ctx, "Submit", nil,
+		func(gonfa.MachineState) bool { return true })
+	require.NoError(t, err)
+	require.True(t, success)
+	require.Len(t, events, 1)
+	assert.True(t, events[0].Success)
+}
@@ -0,0 +1,128 @@
+package machine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dr-dobermann/gonfa/pkg/builder"
+)
+
+// testLogger collects the logged messages.
+type testLogger struct {
+	debug []string
+	errs  []string
+}
+
+func (l *testLogger) Debugf(format string, args ...any) {
+	l.debug = append(l.debug, fmt.Sprintf(format, args...))
+}
+
+func (l *testLogger) Errorf(format string, args ...any) {
+	l.errs = append(l.errs, fmt.Sprintf(format, args...))
+}
+
+func TestWithLogger(t *testing.T) {
+	failing := &testAction{err: errors.New("boom")}
+
+	def, err := builder.New().
+		InitialState("Draft").
+		FinalStates("Approved").
+		OnExit("Draft", &testAction{}).
+		AddTransition("Draft", "Review", "Submit").
+		WithGuards(&testGuard{result: true}).
+		WithActions(&testAction{}).
+		AddTransition("Review", "Approved", "Approve").
+		WithGuards(&testGuard{result: false}).
+		AddTransition("Review", "Draft", "Reject").
+		WithActions(failing).
+		Build()
+	require.NoError(t, err)
+
+	logger := &testLogger{}
+	machine, err := New(def, WithLogger(logger))
+	require.NoError(t, err)
+
+	ctx := context.Background()
+
+	success, err := machine.Fire(ctx, "Submit", nil)
+	require.NoError(t, err)
+	require.True(t, success)
+
+	prefix := "event 'Submit' from 'Draft' to 'Review': "
+	assert.Equal(t, []string{
+		prefix + "guards checked",
+		prefix + "OnExit actions run",
+		prefix + "transition actions run",
+		prefix + "state changed",
+		prefix + "OnEntry actions run",
+		prefix + "hooks run",
+	}, logger.debug)
+	assert.Empty(t, logger.errs)
+
+	logger.debug = nil
+
+	success, err = machine.Fire(ctx, "Approve", nil)
+	require.NoError(t, err)
+	require.False(t, success)
+	assert.Equal(t, []string{
+		"event 'Approve' from 'Review' to 'Approved': guard #0 rejected",
+		"event 'Approve' in 'Review': no transition, failure hooks run",
+	}, logger.debug)
+
+	_, err = machine.Fire(ctx, "Reject", nil)
+	require.Error(t, err)
+	require.Len(t, logger.errs, 1)
+	assert.Contains(t, logger.errs[0], "event 'Reject' in 'Review' failed: ")
+	assert.Contains(t, logger.errs[0], "boom")
+}
+
+func TestWithLoggerFastPath(t *testing.T) {
+	fire := func(t *testing.T, b *builder.Builder) []string {
+		def, err := b.Build()
+		require.NoError(t, err)
+
+		logger := &testLogger{}
+		machine, err := New(def, WithLogger(logger))
+		require.NoError(t, err)
+
+		success, err := machine.Fire(context.Background(), "Submit", nil)
+		require.NoError(t, err)
+		require.True(t, success)
+
+		return logger.debug
+	}
+
+	trivial := fire(t, builder.New().
+		InitialState("Draft").
+		FinalStates("Review").
+		AddTransition("Draft", "Review", "Submit"))
+	regular := fire(t, builder.New().
+		InitialState("Draft").
+		FinalStates("Review").
+		AddTransition("Draft", "Review", "Submit").
+		WithGuards(&testGuard{result: true}))
+
+	assert.Equal(t, regular, trivial)
+}
+
+func TestWithLoggerDefault(t *testing.T) {
+	def, err := builder.New().
+		InitialState("Draft").
+		FinalStates("Review").
+		AddTransition("Draft", "Review", "Submit").
+		Build()
+	require.NoError(t, err)
+
+	// a nil logger keeps the default no-op one
+	machine, err := New(def, WithLogger(nil))
+	require.NoError(t, err)
+
+	success, err := machine.Fire(context.Background(), "Submit", nil)
+	require.NoError(t, err)
+	assert.True(t, success)
+}
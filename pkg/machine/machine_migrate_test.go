@@ -0,0 +1,113 @@
+package machine
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dr-dobermann/gonfa/pkg/builder"
+	"github.com/dr-dobermann/gonfa/pkg/gonfa"
+)
+
+func TestMigrateTo(t *testing.T) {
+	ctx := context.Background()
+
+	v1, err := builder.New().
+		InitialState("Draft").
+		FinalStates("Approved").
+		AddTransition("Draft", "Review", "Submit").
+		AddTransition("Review", "Approved", "Approve").
+		Build()
+	require.NoError(t, err)
+
+	// v2 adds a legal check between review and approval
+	v2, err := builder.New().
+		InitialState("Draft").
+		FinalStates("Approved").
+		AddTransition("Draft", "Review", "Submit").
+		AddTransition("Review", "Legal", "Approve").
+		AddTransition("Legal", "Approved", "Approve").
+		Build()
+	require.NoError(t, err)
+
+	// v3 drops the Review state
+	v3, err := builder.New().
+		InitialState("Draft").
+		FinalStates("Approved").
+		AddTransition("Draft", "Approved", "Approve").
+		Build()
+	require.NoError(t, err)
+
+	newMachine := func(t *testing.T, opts ...Option) *Machine {
+		m, err := New(v1, opts...)
+		require.NoError(t, err)
+
+		ok, err := m.Fire(ctx, "Submit", nil)
+		require.NoError(t, err)
+		require.True(t, ok)
+
+		return m
+	}
+
+	t.Run("compatible definition", func(t *testing.T) {
+		m := newMachine(t)
+
+		require.NoError(t, m.MigrateTo(v2))
+		assert.Same(t, v2, m.Definition())
+
+		ok, err := m.Fire(ctx, "Approve", nil)
+		require.NoError(t, err)
+		assert.True(t, ok)
+		assert.Equal(t, gonfa.State("Legal"), m.CurrentState())
+		assert.Len(t, m.History(), 2)
+	})
+
+	t.Run("missing current state", func(t *testing.T) {
+		m := newMachine(t)
+
+		err := m.MigrateTo(v3)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "current state 'Review' not found")
+		assert.Same(t, v1, m.Definition())
+	})
+
+	t.Run("missing history state", func(t *testing.T) {
+		m, err := New(v2)
+		require.NoError(t, err)
+		for _, e := range []gonfa.Event{"Submit", "Approve", "Approve"} {
+			_, err := m.Fire(ctx, e, nil)
+			require.NoError(t, err)
+		}
+
+		err = m.MigrateTo(v1)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(),
+			"state 'Legal' of history entry 1 not found")
+		assert.Same(t, v2, m.Definition())
+	})
+
+	t.Run("options check", func(t *testing.T) {
+		withCancel, err := builder.New().
+			InitialState("Draft").
+			FinalStates("Approved", "Cancelled").
+			AddTransition("Draft", "Review", "Submit").
+			AddTransition("Review", "Approved", "Approve").
+			AddTransition("Review", "Cancelled", "Cancel").
+			Build()
+		require.NoError(t, err)
+
+		m, err := New(withCancel, WithAbortState("Cancelled"))
+		require.NoError(t, err)
+
+		err = m.MigrateTo(v1)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "abort state 'Cancelled' not found")
+		assert.Same(t, withCancel, m.Definition())
+	})
+
+	t.Run("nil definition", func(t *testing.T) {
+		assert.Error(t, newMachine(t).MigrateTo(nil))
+	})
+}
@@ -0,0 +1,216 @@
+package machine
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dr-dobermann/gonfa/pkg/builder"
+	"github.com/dr-dobermann/gonfa/pkg/gonfa"
+)
+
+// ctxGuard delegates Check to fn passing the context.
+type ctxGuard struct {
+	fn func(ctx context.Context) bool
+}
+
+func (g *ctxGuard) Check(
+	ctx context.Context,
+	state gonfa.MachineState,
+	payload gonfa.Payload,
+) bool {
+	return g.fn(ctx)
+}
+
+// ctxErrGuard delegates CheckErr to fn passing the context.
+type ctxErrGuard struct {
+	fn func(ctx context.Context) (bool, error)
+}
+
+func (g *ctxErrGuard) Check(
+	ctx context.Context,
+	state gonfa.MachineState,
+	payload gonfa.Payload,
+) bool {
+	pass, err := g.fn(ctx)
+	return pass && err == nil
+}
+
+func (g *ctxErrGuard) CheckErr(
+	ctx context.Context,
+	state gonfa.MachineState,
+	payload gonfa.Payload,
+) (bool, error) {
+	return g.fn(ctx)
+}
+
+// sleepGuard passes after the delay.
+func sleepGuard(delay time.Duration) gonfa.Guard {
+	return &ctxGuard{fn: func(ctx context.Context) bool {
+		select {
+		case <-time.After(delay):
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}}
+}
+
+func createGuardedMachine(
+	t testing.TB,
+	guards []gonfa.Guard,
+	opts ...Option,
+) *Machine {
+	def, err := builder.New().
+		InitialState("Start").
+		FinalStates("End").
+		AddTransition("Start", "End", "Finish").
+		WithGuards(guards...).
+		Build()
+	require.NoError(t, err)
+
+	m, err := New(def, opts...)
+	require.NoError(t, err)
+
+	return m
+}
+
+func TestWithParallelGuards(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("guards run concurrently", func(t *testing.T) {
+		const n = 3
+
+		var barrier sync.WaitGroup
+		barrier.Add(n)
+
+		guards := make([]gonfa.Guard, n)
+		for i := range guards {
+			guards[i] = &ctxGuard{fn: func(context.Context) bool {
+				barrier.Done()
+
+				// every guard waits until all of them are started
+				done := make(chan struct{})
+				go func() {
+					barrier.Wait()
+					close(done)
+				}()
+
+				select {
+				case <-done:
+					return true
+				case <-time.After(5 * time.Second):
+					return false
+				}
+			}}
+		}
+
+		m := createGuardedMachine(t, guards, WithParallelGuards())
+
+		ok, err := m.Fire(ctx, "Finish", nil)
+		require.NoError(t, err)
+		assert.True(t, ok)
+	})
+
+	t.Run("rejection cancels other guards", func(t *testing.T) {
+		var canceled atomic.Bool
+		slow := &ctxGuard{fn: func(ctx context.Context) bool {
+			select {
+			case <-ctx.Done():
+				canceled.Store(true)
+				return false
+			case <-time.After(5 * time.Second):
+				return true
+			}
+		}}
+
+		var traced []bool
+		m := createGuardedMachine(t,
+			[]gonfa.Guard{slow, &testGuard{result: false}},
+			WithParallelGuards(),
+			WithGuardTrace(func(_ string, _ gonfa.State, _ gonfa.Event, r bool) {
+				traced = append(traced, r)
+			}))
+
+		ok, err := m.Fire(ctx, "Finish", nil)
+		require.NoError(t, err)
+		assert.False(t, ok)
+		assert.True(t, canceled.Load())
+		assert.Equal(t, []bool{false, false}, traced)
+		assert.Equal(t, gonfa.State("Start"), m.CurrentState())
+	})
+
+	t.Run("canceled guard error isn't a failure", func(t *testing.T) {
+		// waits for the cancellation and reports it as an error
+		waiting := &ctxErrGuard{fn: func(ctx context.Context) (bool, error) {
+			<-ctx.Done()
+			return false, ctx.Err()
+		}}
+
+		m := createGuardedMachine(t,
+			[]gonfa.Guard{waiting, &testGuard{result: false}},
+			WithParallelGuards())
+
+		ok, err := m.Fire(ctx, "Finish", nil)
+		require.NoError(t, err)
+		assert.False(t, ok)
+	})
+
+	t.Run("deferral isn't lost", func(t *testing.T) {
+		m := createGuardedMachine(t,
+			[]gonfa.Guard{
+				sleepGuard(5 * time.Second),
+				&readinessGuard{pass: true},
+			},
+			WithParallelGuards())
+
+		ok, err := m.Fire(ctx, "Finish", nil)
+		assert.False(t, ok)
+		require.ErrorIs(t, err, gonfa.ErrEventDeferred)
+		assert.Equal(t, []gonfa.Event{"Finish"}, m.DeferredEvents())
+	})
+
+	t.Run("single guard", func(t *testing.T) {
+		guard := &testGuard{result: true}
+		m := createGuardedMachine(t, []gonfa.Guard{guard}, WithParallelGuards())
+
+		ok, err := m.Fire(ctx, "Finish", nil)
+		require.NoError(t, err)
+		assert.True(t, ok)
+		assert.Equal(t, 1, guard.calls)
+	})
+}
+
+func BenchmarkSlowGuards(b *testing.B) {
+	guards := []gonfa.Guard{
+		sleepGuard(time.Millisecond),
+		sleepGuard(time.Millisecond),
+		sleepGuard(time.Millisecond),
+		sleepGuard(time.Millisecond),
+	}
+
+	bench := func(b *testing.B, opts ...Option) {
+		m := createGuardedMachine(b, guards, opts...)
+		storable, err := m.Marshal()
+		require.NoError(b, err)
+
+		for b.Loop() {
+			m, err := Restore(m.Definition(), storable, nil, opts...)
+			if err != nil {
+				b.Fatal(err)
+			}
+
+			if ok, err := m.Fire(context.Background(), "Finish", nil); !ok || err != nil {
+				b.Fatal("transition failed", err)
+			}
+		}
+	}
+
+	b.Run("sequential", func(b *testing.B) { bench(b) })
+	b.Run("parallel", func(b *testing.B) { bench(b, WithParallelGuards()) })
+}
@@ -0,0 +1,178 @@
+package machine
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dr-dobermann/gonfa/pkg/builder"
+	"github.com/dr-dobermann/gonfa/pkg/definition"
+	"github.com/dr-dobermann/gonfa/pkg/gonfa"
+)
+
+// gateGuard blocks until released.
+type gateGuard struct {
+	entered chan struct{}
+	release chan struct{}
+}
+
+func (g *gateGuard) Check(
+	_ context.Context,
+	_ gonfa.MachineState,
+	_ gonfa.Payload,
+) bool {
+	g.entered <- struct{}{}
+	<-g.release
+
+	return true
+}
+
+func createQueueDefinition(t *testing.T, guard gonfa.Guard) *definition.Definition {
+	b := builder.New().
+		InitialState("Draft").
+		FinalStates("Approved").
+		AddTransition("Draft", "Review", "Submit")
+	if guard != nil {
+		b = b.WithGuards(guard)
+	}
+
+	def, err := b.AddTransition("Review", "Approved", "Approve").Build()
+	require.NoError(t, err)
+
+	return def
+}
+
+func TestFireAsync(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("events are fired in order", func(t *testing.T) {
+		machine, err := New(createQueueDefinition(t, nil), WithAsyncQueue(4))
+		require.NoError(t, err)
+		defer machine.Close()
+
+		submit := machine.FireAsync(ctx, "Submit", nil)
+		approve := machine.FireAsync(ctx, "Approve", nil)
+
+		outcome := <-submit
+		require.NoError(t, outcome.Err)
+		require.NotNil(t, outcome.Result)
+		assert.Equal(t, gonfa.State("Review"), outcome.Result.To)
+
+		outcome = <-approve
+		require.NoError(t, outcome.Err)
+		require.NotNil(t, outcome.Result)
+		assert.Equal(t, gonfa.State("Approved"), outcome.Result.To)
+
+		// the channel is closed after the outcome
+		_, ok := <-approve
+		assert.False(t, ok)
+	})
+
+	t.Run("submitting doesn't wait for guards", func(t *testing.T) {
+		gate := &gateGuard{
+			entered: make(chan struct{}),
+			release: make(chan struct{}),
+		}
+
+		machine, err := New(createQueueDefinition(t, gate), WithAsyncQueue(0))
+		require.NoError(t, err)
+		defer machine.Close()
+
+		submit := machine.FireAsync(ctx, "Submit", nil)
+		<-gate.entered
+
+		// the worker is busy, so an unbuffered queue can't take the event
+		canceled, cancel := context.WithCancel(ctx)
+		cancel()
+		outcome := <-machine.FireAsync(canceled, "Approve", nil)
+		assert.ErrorIs(t, outcome.Err, context.Canceled)
+
+		close(gate.release)
+		outcome = <-submit
+		require.NoError(t, outcome.Err)
+		assert.NotNil(t, outcome.Result)
+	})
+
+	t.Run("close", func(t *testing.T) {
+		machine, err := New(createQueueDefinition(t, nil), WithAsyncQueue(4))
+		require.NoError(t, err)
+
+		submit := machine.FireAsync(ctx, "Submit", nil)
+		require.NoError(t, machine.Close())
+		require.NoError(t, machine.Close())
+
+		// submitted events are fired before the worker stops
+		outcome := <-submit
+		require.NoError(t, outcome.Err)
+		assert.NotNil(t, outcome.Result)
+
+		outcome = <-machine.FireAsync(ctx, "Approve", nil)
+		assert.ErrorIs(t, outcome.Err, gonfa.ErrMachineClosed)
+		assert.Equal(t, gonfa.State("Review"), machine.CurrentState())
+	})
+
+	t.Run("submitting from the worker", func(t *testing.T) {
+		for _, tt := range []struct {
+			name    string
+			size    int
+			wantErr error
+		}{
+			{name: "unbuffered queue", size: 0, wantErr: gonfa.ErrQueueFull},
+			{name: "buffered queue", size: 1},
+		} {
+			t.Run(tt.name, func(t *testing.T) {
+				var (
+					machine *Machine
+					approve <-chan gonfa.FireOutcome
+				)
+				submitter := &funcAction{fn: func(
+					ctx context.Context,
+					_ gonfa.MachineState,
+				) error {
+					approve = machine.FireAsync(ctx, "Approve", nil)
+					return nil
+				}}
+
+				def, err := builder.New().
+					InitialState("Draft").
+					FinalStates("Approved").
+					AddTransition("Draft", "Review", "Submit").
+					WithActions(submitter).
+					AddTransition("Review", "Approved", "Approve").
+					Build()
+				require.NoError(t, err)
+
+				machine, err = New(def, WithAsyncQueue(tt.size))
+				require.NoError(t, err)
+				defer machine.Close()
+
+				outcome := <-machine.FireAsync(ctx, "Submit", nil)
+				require.NoError(t, outcome.Err)
+
+				outcome = <-approve
+				if tt.wantErr != nil {
+					assert.ErrorIs(t, outcome.Err, tt.wantErr)
+					assert.Equal(t, gonfa.State("Review"),
+						machine.CurrentState())
+
+					return
+				}
+
+				require.NoError(t, outcome.Err)
+				assert.Equal(t, gonfa.State("Approved"), machine.CurrentState())
+			})
+		}
+	})
+
+	t.Run("queue disabled", func(t *testing.T) {
+		machine, err := New(createQueueDefinition(t, nil))
+		require.NoError(t, err)
+
+		outcome := <-machine.FireAsync(ctx, "Submit", nil)
+		assert.Error(t, outcome.Err)
+		assert.Nil(t, outcome.Result)
+		assert.NoError(t, machine.Close())
+	})
+}
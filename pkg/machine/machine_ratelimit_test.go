@@ -0,0 +1,106 @@
+package machine
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dr-dobermann/gonfa/pkg/builder"
+	"github.com/dr-dobermann/gonfa/pkg/gonfa"
+)
+
+func TestTokenBucket(t *testing.T) {
+	now := time.Now()
+	tb := NewTokenBucket(2, time.Second)
+	tb.now = func() time.Time { return now }
+
+	assert.True(t, tb.Allow())
+	assert.True(t, tb.Allow())
+	assert.False(t, tb.Allow(), "burst is exhausted")
+
+	now = now.Add(500 * time.Millisecond)
+	assert.True(t, tb.Allow(), "one token is refilled")
+	assert.False(t, tb.Allow())
+
+	now = now.Add(time.Hour)
+	assert.True(t, tb.Allow())
+	assert.True(t, tb.Allow())
+	assert.False(t, tb.Allow(), "refill is capped by capacity")
+
+	t.Run("invalid rate", func(t *testing.T) {
+		assert.False(t, NewTokenBucket(0, time.Second).Allow())
+		assert.False(t, NewTokenBucket(1, 0).Allow())
+	})
+}
+
+type countingLimiter struct {
+	allowed int
+}
+
+func (l *countingLimiter) Allow() bool {
+	if l.allowed == 0 {
+		return false
+	}
+	l.allowed--
+
+	return true
+}
+
+func TestWithRateLimit(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("rate limited fire", func(t *testing.T) {
+		failure := &testAction{name: "failure"}
+		def, err := builder.New().
+			InitialState("Ping").
+			AddTransition("Ping", "Pong", "Hit").
+			AddTransition("Pong", "Ping", "Hit").
+			WithFailureHooks(failure).
+			Build()
+		require.NoError(t, err)
+
+		m, err := New(def, WithRateLimit(1, time.Hour))
+		require.NoError(t, err)
+
+		ok, err := m.Fire(ctx, "Hit", nil)
+		require.NoError(t, err)
+		assert.True(t, ok)
+
+		ok, err = m.Fire(ctx, "Hit", nil)
+		assert.False(t, ok)
+		assert.True(t, errors.Is(err, gonfa.ErrRateLimited))
+		assert.Equal(t, gonfa.State("Pong"), m.CurrentState())
+		assert.False(t, failure.executed, "hooks aren't called")
+	})
+
+	t.Run("shared limiter", func(t *testing.T) {
+		limiter := &countingLimiter{allowed: 1}
+		m1 := createPingPongMachine(t, WithRateLimiter(limiter))
+		m2 := createPingPongMachine(t, WithRateLimiter(limiter))
+
+		ok, err := m1.Fire(ctx, "Hit", nil)
+		require.NoError(t, err)
+		assert.True(t, ok)
+
+		_, err = m2.Fire(ctx, "Hit", nil)
+		assert.ErrorIs(t, err, gonfa.ErrRateLimited)
+	})
+
+	t.Run("FireIf with false condition doesn't take a token", func(t *testing.T) {
+		limiter := &countingLimiter{allowed: 1}
+		m := createPingPongMachine(t, WithRateLimiter(limiter))
+
+		ok, err := m.FireIf(ctx, "Hit", nil,
+			func(gonfa.MachineState) bool { return false })
+		require.NoError(t, err)
+		assert.False(t, ok)
+
+		ok, err = m.Fire(ctx, "Hit", nil)
+		require.NoError(t, err)
+		assert.True(t, ok)
+	})
+}
@@ -0,0 +1,110 @@
+package machine
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dr-dobermann/gonfa/pkg/builder"
+	"github.com/dr-dobermann/gonfa/pkg/definition"
+	"github.com/dr-dobermann/gonfa/pkg/gonfa"
+)
+
+func TestGuardRejectionsInFailureHooks(t *testing.T) {
+	var got []GuardRejection
+	hook := &funcAction{fn: func(ctx context.Context, _ gonfa.MachineState) error {
+		got = GuardRejections(ctx)
+		return nil
+	}}
+
+	passing := &testGuard{result: true}
+	rejecting := &testGuard{result: false}
+
+	def, err := builder.New().
+		InitialState("Draft").
+		FinalStates("Approved", "Rejected").
+		AddTransition("Draft", "Approved", "Decide").
+		WithGuards(passing, rejecting).
+		AddTransition("Draft", "Rejected", "Decide").
+		WithGuards(rejecting).
+		WithFailureHooks(hook).
+		Build()
+	require.NoError(t, err)
+
+	machine, err := New(def)
+	require.NoError(t, err)
+
+	t.Run("all guards rejected", func(t *testing.T) {
+		success, err := machine.Fire(context.Background(), "Decide", nil)
+		require.NoError(t, err)
+		assert.False(t, success)
+
+		require.Len(t, got, 2)
+		assert.Equal(t, gonfa.State("Approved"), got[0].Transition.To)
+		assert.Equal(t, 1, got[0].GuardIndex)
+		assert.Same(t, rejecting, got[0].Guard)
+		assert.Equal(t, gonfa.State("Rejected"), got[1].Transition.To)
+		assert.Equal(t, 0, got[1].GuardIndex)
+		assert.Contains(t, got[0].String(),
+			"Draft->Approved on Decide blocked by guard #1")
+	})
+
+	t.Run("no transition for event", func(t *testing.T) {
+		got = []GuardRejection{{}}
+		success, err := machine.Fire(context.Background(), "Unknown", nil)
+		require.NoError(t, err)
+		assert.False(t, success)
+		assert.Nil(t, got)
+	})
+}
+
+func TestNamedGuardsAndActions(t *testing.T) {
+	var got []GuardRejection
+	hook := &funcAction{fn: func(ctx context.Context, _ gonfa.MachineState) error {
+		got = GuardRejections(ctx)
+		return nil
+	}}
+
+	isManager := &testGuard{result: false}
+	charge := &testAction{err: errors.New("card declined")}
+
+	def, err := definition.New("Draft", []gonfa.State{"Approved", "Paid"},
+		map[gonfa.State]definition.StateConfig{
+			"Draft": {}, "Approved": {}, "Paid": {},
+		},
+		[]definition.Transition{
+			{From: "Draft", To: "Approved", On: "Approve",
+				Name:       "Draft->Approved",
+				Guards:     []gonfa.Guard{isManager},
+				GuardNames: []string{"isManager"}},
+			{From: "Draft", To: "Paid", On: "Pay",
+				Actions:     []gonfa.Action{charge},
+				ActionNames: []string{"billing:charge"}},
+		},
+		definition.Hooks{OnFailure: []gonfa.Action{hook}})
+	require.NoError(t, err)
+
+	var traced []string
+	machine, err := New(def, WithGuardTrace(
+		func(name string, _ gonfa.State, _ gonfa.Event, _ bool) {
+			traced = append(traced, name)
+		}))
+	require.NoError(t, err)
+
+	success, err := machine.Fire(context.Background(), "Approve", nil)
+	require.NoError(t, err)
+	assert.False(t, success)
+
+	require.Len(t, got, 1)
+	assert.Equal(t, "guard 'isManager' failed on transition Draft->Approved",
+		got[0].String())
+	assert.Equal(t, []string{"isManager"}, traced)
+
+	_, err = machine.Fire(context.Background(), "Pay", nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "transition action 'billing:charge' "+
+		"failed on transition Draft->Paid on Pay: card declined")
+}
@@ -0,0 +1,67 @@
+package machine
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dr-dobermann/gonfa/pkg/builder"
+	"github.com/dr-dobermann/gonfa/pkg/gonfa"
+)
+
+func TestFireResult(t *testing.T) {
+	isManager := &testGuard{result: false}
+	isAuthor := &testGuard{result: true}
+
+	def, err := builder.New().
+		InitialState("Draft").
+		FinalStates("Approved", "Withdrawn").
+		AddTransition("Draft", "Approved", "Decide").
+		WithGuards(isManager).
+		AddTransition("Draft", "Withdrawn", "Decide").
+		WithGuards(isAuthor).
+		AddTransition("Draft", "Draft", "Edit").
+		Build()
+	require.NoError(t, err)
+
+	t.Run("chosen transition is reported", func(t *testing.T) {
+		machine, err := New(def)
+		require.NoError(t, err)
+
+		result, err := machine.FireResult(context.Background(), "Decide", nil)
+		require.NoError(t, err)
+		require.NotNil(t, result)
+		assert.Equal(t, gonfa.TransitionResult{
+			From: "Draft", To: "Withdrawn", On: "Decide", Index: 1,
+		}, *result)
+
+		candidates := def.GetTransitions("Draft", "Decide")
+		assert.Equal(t, result.To, candidates[result.Index].To)
+	})
+
+	t.Run("trivial transition", func(t *testing.T) {
+		machine, err := New(def)
+		require.NoError(t, err)
+
+		result, err := machine.FireResult(context.Background(), "Edit", nil)
+		require.NoError(t, err)
+		require.NotNil(t, result)
+		assert.Equal(t, gonfa.State("Draft"), result.To)
+		assert.Equal(t, 0, result.Index)
+	})
+
+	t.Run("no transition", func(t *testing.T) {
+		machine, err := New(def)
+		require.NoError(t, err)
+
+		result, err := machine.FireResult(context.Background(), "Unknown", nil)
+		require.NoError(t, err)
+		assert.Nil(t, result)
+
+		success, err := machine.Fire(context.Background(), "Unknown", nil)
+		require.NoError(t, err)
+		assert.False(t, success)
+	})
+}
@@ -0,0 +1,157 @@
+package machine
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dr-dobermann/gonfa/pkg/builder"
+	"github.com/dr-dobermann/gonfa/pkg/gonfa"
+)
+
+// createSagaMachine creates a booking saga Start -> Flight -> Hotel -> Car
+// whose transitions record their compensations in log. The Car booking
+// fails with carErr.
+func createSagaMachine(
+	t *testing.T,
+	log *[]string,
+	carErr error,
+) *Machine {
+	compensate := func(name string) gonfa.Action {
+		return &payloadAction{fn: func(payload gonfa.Payload) {
+			*log = append(*log, name+":"+payload.(string))
+		}}
+	}
+
+	def, err := builder.New().
+		InitialState("Start").
+		FinalStates("Car").
+		AddTransition("Start", "Flight", "BookFlight").
+		WithCompensation(compensate("cancelFlight")).
+		AddTransition("Flight", "Hotel", "BookHotel").
+		WithCompensation(compensate("cancelHotel"), compensate("refundHotel")).
+		AddTransition("Hotel", "Car", "BookCar").
+		WithActions(&testAction{name: "bookCar", err: carErr}).
+		WithCompensation(compensate("cancelCar")).
+		Build()
+	require.NoError(t, err)
+
+	m, err := New(def)
+	require.NoError(t, err)
+
+	return m
+}
+
+func TestSaga(t *testing.T) {
+	ctx := context.Background()
+	carErr := errors.New("no cars left")
+
+	fireAll := func(t *testing.T, m *Machine) error {
+		for _, e := range []gonfa.Event{"BookFlight", "BookHotel"} {
+			ok, err := m.Fire(ctx, e, string(e))
+			require.NoError(t, err)
+			require.True(t, ok)
+		}
+
+		_, err := m.Fire(ctx, "BookCar", "BookCar")
+		return err
+	}
+
+	t.Run("failed transition aborts the saga", func(t *testing.T) {
+		var log []string
+		m := createSagaMachine(t, &log, carErr)
+
+		require.NoError(t, m.BeginSaga())
+		err := fireAll(t, m)
+		require.Error(t, err)
+		assert.ErrorIs(t, err, carErr)
+
+		assert.Equal(t, []string{
+			"cancelHotel:BookHotel",
+			"refundHotel:BookHotel",
+			"cancelFlight:BookFlight",
+		}, log)
+		assert.False(t, m.InSaga())
+		// state isn't rolled back
+		assert.Equal(t, gonfa.State("Hotel"), m.CurrentState())
+	})
+
+	t.Run("committed saga isn't compensated", func(t *testing.T) {
+		var log []string
+		m := createSagaMachine(t, &log, nil)
+
+		require.NoError(t, m.BeginSaga())
+		require.NoError(t, fireAll(t, m))
+		require.NoError(t, m.CommitSaga())
+
+		assert.Empty(t, log)
+		assert.Error(t, m.AbortSaga(ctx))
+	})
+
+	t.Run("explicit abort", func(t *testing.T) {
+		var log []string
+		m := createSagaMachine(t, &log, nil)
+
+		_, err := m.Fire(ctx, "BookFlight", "BookFlight")
+		require.NoError(t, err)
+
+		// transitions before the saga aren't compensated
+		require.NoError(t, m.BeginSaga())
+		_, err = m.Fire(ctx, "BookHotel", "BookHotel")
+		require.NoError(t, err)
+		require.NoError(t, m.AbortSaga(ctx))
+
+		assert.Equal(t,
+			[]string{"cancelHotel:BookHotel", "refundHotel:BookHotel"}, log)
+	})
+
+	t.Run("failure without saga", func(t *testing.T) {
+		var log []string
+		m := createSagaMachine(t, &log, carErr)
+
+		assert.Error(t, fireAll(t, m))
+		assert.Empty(t, log)
+	})
+
+	t.Run("bracket errors", func(t *testing.T) {
+		var log []string
+		m := createSagaMachine(t, &log, nil)
+
+		assert.Error(t, m.CommitSaga())
+		assert.Error(t, m.AbortSaga(ctx))
+		require.NoError(t, m.BeginSaga())
+		assert.Error(t, m.BeginSaga())
+		assert.True(t, m.InSaga())
+	})
+
+	t.Run("compensation errors are joined", func(t *testing.T) {
+		compErr := errors.New("compensation failed")
+		second := &testAction{name: "second"}
+
+		def, err := builder.New().
+			InitialState("A").
+			FinalStates("C").
+			AddTransition("A", "B", "Go").
+			WithCompensation(second).
+			AddTransition("B", "C", "Go").
+			WithCompensation(&testAction{name: "first", err: compErr}).
+			Build()
+		require.NoError(t, err)
+
+		m, err := New(def)
+		require.NoError(t, err)
+
+		require.NoError(t, m.BeginSaga())
+		_, err = m.Fire(ctx, "Go", nil)
+		require.NoError(t, err)
+		_, err = m.Fire(ctx, "Go", nil)
+		require.NoError(t, err)
+
+		err = m.AbortSaga(ctx)
+		assert.ErrorIs(t, err, compErr)
+		assert.True(t, second.executed, "all compensations run")
+	})
+}
@@ -0,0 +1,110 @@
+package machine
+
+import (
+	"context"
+	"math/rand/v2"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dr-dobermann/gonfa/pkg/builder"
+	"github.com/dr-dobermann/gonfa/pkg/definition"
+	"github.com/dr-dobermann/gonfa/pkg/gonfa"
+	"github.com/dr-dobermann/gonfa/pkg/registry"
+)
+
+func TestFirstMatchSelection(t *testing.T) {
+	def, err := builder.New().
+		InitialState("Start").
+		FinalStates("A", "B").
+		AddTransition("Start", "A", "Go").
+		AddTransition("Start", "B", "Go").
+		Build()
+	require.NoError(t, err)
+
+	machine, err := New(def, WithSelectionStrategy(FirstMatch()))
+	require.NoError(t, err)
+
+	success, err := machine.Fire(context.Background(), "Go", nil)
+	require.NoError(t, err)
+	assert.True(t, success)
+	assert.Equal(t, gonfa.State("A"), machine.CurrentState())
+}
+
+func TestRandomWeightedSelection(t *testing.T) {
+	t.Run("zero weight is never selected", func(t *testing.T) {
+		transitions := []definition.Transition{
+			{From: "Start", To: "A", On: "Go", Weight: 0},
+			{From: "Start", To: "B", On: "Go", Weight: 1},
+		}
+
+		s := RandomWeighted(rand.New(rand.NewPCG(1, 2)))
+		for range 100 {
+			ordered := s.Order(transitions)
+			require.Len(t, ordered, 1)
+			assert.Equal(t, gonfa.State("B"), ordered[0].To)
+		}
+	})
+
+	t.Run("all zero weights keep declaration order", func(t *testing.T) {
+		transitions := []definition.Transition{
+			{From: "Start", To: "A", On: "Go"},
+			{From: "Start", To: "B", On: "Go"},
+		}
+
+		ordered := RandomWeighted(nil).Order(transitions)
+		assert.Equal(t, transitions, ordered)
+	})
+
+	t.Run("distribution follows weights", func(t *testing.T) {
+		transitions := []definition.Transition{
+			{From: "Start", To: "A", On: "Go", Weight: 1},
+			{From: "Start", To: "B", On: "Go", Weight: 3},
+		}
+
+		s := RandomWeighted(rand.New(rand.NewPCG(42, 42)))
+		counts := map[gonfa.State]int{}
+		for range 4000 {
+			ordered := s.Order(transitions)
+			require.Len(t, ordered, 2)
+			counts[ordered[0].To]++
+		}
+
+		assert.InDelta(t, 1000, counts["A"], 150)
+		assert.InDelta(t, 3000, counts["B"], 150)
+	})
+}
+
+func TestRandomWeightedWithLoadedDefinition(t *testing.T) {
+	yamlData := `
+initialState: Start
+finalStates: [Fast, Slow]
+states:
+  Start: {}
+  Fast: {}
+  Slow: {}
+transitions:
+  - from: Start
+    to: Fast
+    on: Go
+    weight: 0.001
+  - from: Start
+    to: Slow
+    on: Go
+    weight: 1000
+`
+	def, err := definition.LoadDefinition(
+		strings.NewReader(yamlData), registry.New())
+	require.NoError(t, err)
+
+	machine, err := New(def,
+		WithSelectionStrategy(RandomWeighted(rand.New(rand.NewPCG(7, 7)))))
+	require.NoError(t, err)
+
+	success, err := machine.Fire(context.Background(), "Go", nil)
+	require.NoError(t, err)
+	assert.True(t, success)
+	assert.Equal(t, gonfa.State("Slow"), machine.CurrentState())
+}
@@ -13,7 +13,7 @@ import (
 
 func TestMarshal(t *testing.T) {
 	def := createTestDefinition(t)
-	machine, err := New(def, nil)
+	machine, err := New(def)
 	require.NoError(t, err)
 
 	// Test initial state
@@ -38,7 +38,7 @@ func TestMarshal(t *testing.T) {
 
 func TestMarshalImmutable(t *testing.T) {
 	def := createTestDefinition(t)
-	machine, err := New(def, nil)
+	machine, err := New(def)
 	require.NoError(t, err)
 
 	// Get initial storable
@@ -68,7 +68,7 @@ func TestRestoreFromMarshal(t *testing.T) {
 	extender := &testStateExtender{data: "test"}
 
 	// Create machine and make transitions
-	machine1, err := New(def, extender)
+	machine1, err := New(def, WithExtender(extender))
 	require.NoError(t, err)
 
 	success, err := machine1.Fire(context.Background(), "ToMiddle", nil)
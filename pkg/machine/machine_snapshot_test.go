@@ -0,0 +1,61 @@
+package machine
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dr-dobermann/gonfa/pkg/gonfa"
+)
+
+func TestSnapshot(t *testing.T) {
+	machine, err := New(createTestDefinition(t))
+	require.NoError(t, err)
+
+	assert.Equal(t, gonfa.Snapshot{CurrentState: "Start"}, machine.Snapshot())
+
+	ctx := context.Background()
+	for _, event := range []gonfa.Event{"ToMiddle", "ToEnd"} {
+		success, err := machine.Fire(ctx, event, nil)
+		require.NoError(t, err)
+		require.True(t, success)
+	}
+
+	s := machine.Snapshot()
+	assert.Equal(t, gonfa.State("End"), s.CurrentState)
+	assert.True(t, s.IsFinal)
+	assert.Equal(t, 2, s.HistoryLen)
+	require.NotNil(t, s.LastTransition)
+	assert.Equal(t, machine.History()[1], *s.LastTransition)
+
+	// the entry is a copy
+	s.LastTransition.To = "Changed"
+	assert.Equal(t, gonfa.State("End"), machine.History()[1].To)
+}
+
+func TestSnapshotConsistency(t *testing.T) {
+	machine := createPingPongMachine(t)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for range 200 {
+			_, _ = machine.Fire(context.Background(), "Hit", nil)
+		}
+	}()
+
+	for range 200 {
+		s := machine.Snapshot()
+		if s.LastTransition == nil {
+			assert.Equal(t, gonfa.State("Ping"), s.CurrentState)
+			continue
+		}
+		assert.Equal(t, s.LastTransition.To, s.CurrentState)
+	}
+
+	wg.Wait()
+}
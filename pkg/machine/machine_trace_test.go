@@ -0,0 +1,57 @@
+package machine
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dr-dobermann/gonfa/pkg/builder"
+	"github.com/dr-dobermann/gonfa/pkg/gonfa"
+	"github.com/dr-dobermann/gonfa/pkg/registry"
+)
+
+func TestGuardTrace(t *testing.T) {
+	isManager := &testGuard{result: false}
+	isOwner := &testGuard{result: true}
+	skipped := &testGuard{result: true}
+
+	reg := registry.New()
+	require.NoError(t, reg.RegisterGuard("isManager", isManager))
+
+	def, err := builder.New().
+		InitialState("Review").
+		FinalStates("Approved", "Returned").
+		AddTransition("Review", "Approved", "Approve").
+		WithGuards(isManager, skipped).
+		AddTransition("Review", "Returned", "Approve").
+		WithGuards(isOwner).
+		Build()
+	require.NoError(t, err)
+
+	type traced struct {
+		name   string
+		from   gonfa.State
+		event  gonfa.Event
+		result bool
+	}
+	var trace []traced
+
+	machine, err := New(def,
+		WithRegistry(reg),
+		WithGuardTrace(func(name string, from gonfa.State, event gonfa.Event, result bool) {
+			trace = append(trace, traced{name, from, event, result})
+		}))
+	require.NoError(t, err)
+
+	success, err := machine.Fire(context.Background(), "Approve", nil)
+	require.NoError(t, err)
+	assert.True(t, success)
+
+	assert.Equal(t, []traced{
+		{"isManager", "Review", "Approve", false},
+		{"*machine.testGuard", "Review", "Approve", true},
+	}, trace)
+	assert.Zero(t, skipped.calls, "short-circuiting must be preserved")
+}
@@ -0,0 +1,125 @@
+package machine
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dr-dobermann/gonfa/pkg/builder"
+	"github.com/dr-dobermann/gonfa/pkg/gonfa"
+)
+
+func TestFireTransaction(t *testing.T) {
+	ctx := context.Background()
+
+	create := func(
+		t *testing.T,
+		approve gonfa.Guard,
+		publish gonfa.Action,
+		undo gonfa.Action,
+	) *Machine {
+		def, err := builder.New().
+			InitialState("Draft").
+			FinalStates("Published").
+			AddTransition("Draft", "Review", "Submit").
+			WithCompensation(undo).
+			AddTransition("Review", "Approved", "Approve").
+			WithGuards(approve).
+			AddTransition("Approved", "Published", "Publish").
+			WithActions(publish).
+			Build()
+		require.NoError(t, err)
+
+		m, err := New(def)
+		require.NoError(t, err)
+
+		return m
+	}
+
+	events := []EventWithPayload{
+		{Event: "Submit"},
+		{Event: "Approve"},
+		{Event: "Publish", Payload: "v1"},
+	}
+
+	t.Run("commit", func(t *testing.T) {
+		undo := &testAction{}
+		m := create(t, &testGuard{result: true}, &testAction{}, undo)
+
+		require.NoError(t, m.FireTransaction(ctx, events))
+
+		assert.Equal(t, gonfa.State("Published"), m.CurrentState())
+		assert.Len(t, m.History(), 3)
+		assert.Zero(t, undo.calls)
+		assert.False(t, m.InSaga())
+	})
+
+	t.Run("no transition", func(t *testing.T) {
+		undo := &testAction{}
+		m := create(t, &testGuard{result: false}, &testAction{}, undo)
+
+		err := m.FireTransaction(ctx, events)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(),
+			"transaction rolled back at event 1 'Approve': no transition")
+
+		assert.Equal(t, gonfa.State("Draft"), m.CurrentState())
+		assert.Empty(t, m.History())
+		assert.Equal(t, 1, undo.calls)
+		assert.False(t, m.InSaga())
+	})
+
+	t.Run("failed action", func(t *testing.T) {
+		publishErr := errors.New("storage unavailable")
+		undo := &testAction{}
+		m := create(t, &testGuard{result: true},
+			&testAction{err: publishErr}, undo)
+
+		require.NoError(t, m.FireTransaction(ctx, events[:1]))
+
+		err := m.FireTransaction(ctx, events[1:])
+		require.ErrorIs(t, err, publishErr)
+
+		assert.Equal(t, gonfa.State("Review"), m.CurrentState())
+		assert.Len(t, m.History(), 1)
+		assert.Zero(t, undo.calls,
+			"transitions of committed transactions aren't compensated")
+	})
+
+	t.Run("active saga", func(t *testing.T) {
+		m := create(t, &testGuard{result: true}, &testAction{}, &testAction{})
+		require.NoError(t, m.BeginSaga())
+
+		err := m.FireTransaction(ctx, events)
+		require.Error(t, err)
+		assert.Equal(t, gonfa.State("Draft"), m.CurrentState())
+	})
+
+	t.Run("evicted entries of rolled back transaction", func(t *testing.T) {
+		var evicted []gonfa.HistoryEntry
+
+		def, err := builder.New().
+			InitialState("A").
+			FinalStates("C").
+			AddTransition("A", "B", "Next").
+			AddTransition("B", "C", "Next").
+			Build()
+		require.NoError(t, err)
+
+		m, err := New(def, WithHistoryEviction(1,
+			func(e gonfa.HistoryEntry) { evicted = append(evicted, e) }))
+		require.NoError(t, err)
+
+		err = m.FireTransaction(ctx, []EventWithPayload{
+			{Event: "Next"}, {Event: "Next"}, {Event: "Next"},
+		})
+		require.Error(t, err)
+
+		assert.Equal(t, gonfa.State("A"), m.CurrentState())
+		assert.Empty(t, m.History())
+		assert.Empty(t, evicted)
+	})
+}
@@ -3,6 +3,8 @@ package machine
 import (
 	"context"
 	"errors"
+	"maps"
+	"slices"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -14,7 +16,7 @@ import (
 
 func TestFireTransition(t *testing.T) {
 	def := createTestDefinition(t)
-	machine, err := New(def, nil)
+	machine, err := New(def)
 	require.NoError(t, err)
 
 	// Test successful transition
@@ -32,7 +34,7 @@ func TestFireTransition(t *testing.T) {
 
 func TestFireInvalidEvent(t *testing.T) {
 	def := createTestDefinition(t)
-	machine, err := New(def, nil)
+	machine, err := New(def)
 	require.NoError(t, err)
 
 	// Test invalid event
@@ -55,7 +57,7 @@ func TestFireWithGuards(t *testing.T) {
 		Build()
 	require.NoError(t, err)
 
-	machine, err := New(def, nil)
+	machine, err := New(def)
 	require.NoError(t, err)
 
 	// Test successful transition with guard
@@ -78,7 +80,7 @@ func TestFireWithFailingGuard(t *testing.T) {
 		Build()
 	require.NoError(t, err)
 
-	machine, err := New(def, nil)
+	machine, err := New(def)
 	require.NoError(t, err)
 
 	// Test failed transition due to guard
@@ -100,7 +102,7 @@ func TestFireWithFailingAction(t *testing.T) {
 		Build()
 	require.NoError(t, err)
 
-	machine, err := New(def, nil)
+	machine, err := New(def)
 	require.NoError(t, err)
 
 	// Test failed transition due to action
@@ -124,7 +126,7 @@ func TestFireWithStateActions(t *testing.T) {
 		Build()
 	require.NoError(t, err)
 
-	machine, err := New(def, nil)
+	machine, err := New(def)
 	require.NoError(t, err)
 
 	// Test transition with state actions
@@ -149,7 +151,7 @@ func TestFireWithHooks(t *testing.T) {
 		Build()
 	require.NoError(t, err)
 
-	machine, err := New(def, nil)
+	machine, err := New(def)
 	require.NoError(t, err)
 
 	// Test successful transition with hooks
@@ -170,3 +172,437 @@ func TestFireWithHooks(t *testing.T) {
 	assert.False(t, successHook.executed)
 	assert.True(t, failureHook.executed)
 }
+
+func TestFireWithAfterActions(t *testing.T) {
+	var order []string
+	record := func(name string, err error) gonfa.Action {
+		return &funcAction{fn: func(context.Context, gonfa.MachineState) error {
+			order = append(order, name)
+			return err
+		}}
+	}
+
+	def, err := builder.New().
+		InitialState("Start").
+		FinalStates("End").
+		OnEntry("End", record("entry", nil)).
+		AddTransition("Start", "End", "ToEnd").
+		WithActions(record("action", nil)).
+		WithAfterActions(
+			record("after1", errors.New("notification failed")),
+			record("after2", nil)).
+		WithSuccessHooks(record("success", nil)).
+		Build()
+	require.NoError(t, err)
+
+	machine, err := New(def)
+	require.NoError(t, err)
+
+	success, err := machine.Fire(context.Background(), "ToEnd", nil)
+	assert.True(t, success)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "after action failed: notification failed")
+
+	// the failed after action doesn't revert the committed transition
+	assert.Equal(t, gonfa.State("End"), machine.CurrentState())
+	assert.Len(t, machine.History(), 1)
+	assert.Equal(t,
+		[]string{"action", "entry", "after1", "after2", "success"},
+		order)
+}
+
+func TestFireWithPreconditions(t *testing.T) {
+	failureHook := &testAction{name: "failureHook"}
+
+	t.Run("violated precondition is an error", func(t *testing.T) {
+		guard := &testGuard{result: true}
+		def, err := builder.New().
+			InitialState("Start").
+			FinalStates("End").
+			AddTransition("Start", "End", "ToEnd").
+			WithPreconditions(&testGuard{result: false}).
+			WithGuards(guard).
+			WithFailureHooks(failureHook).
+			Build()
+		require.NoError(t, err)
+
+		machine, err := New(def)
+		require.NoError(t, err)
+
+		success, err := machine.Fire(context.Background(), "ToEnd", nil)
+		assert.False(t, success)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "precondition #0")
+		assert.Equal(t, gonfa.State("Start"), machine.CurrentState())
+		assert.Zero(t, guard.calls, "guards must not run after a violation")
+		assert.True(t, failureHook.executed)
+	})
+
+	t.Run("satisfied precondition", func(t *testing.T) {
+		def, err := builder.New().
+			InitialState("Start").
+			FinalStates("End").
+			AddTransition("Start", "End", "ToEnd").
+			WithPreconditions(&testGuard{result: true}).
+			Build()
+		require.NoError(t, err)
+
+		machine, err := New(def)
+		require.NoError(t, err)
+
+		success, err := machine.Fire(context.Background(), "ToEnd", nil)
+		require.NoError(t, err)
+		assert.True(t, success)
+	})
+}
+
+func TestFireWithBag(t *testing.T) {
+	var comment string
+	action := &payloadAction{fn: func(p gonfa.Payload) {
+		comment, _ = gonfa.Get[string](p, "comment")
+	}}
+
+	def, err := builder.New().
+		InitialState("Start").
+		FinalStates("End").
+		AddTransition("Start", "End", "ToEnd").
+		WithActions(action).
+		Build()
+	require.NoError(t, err)
+
+	machine, err := New(def)
+	require.NoError(t, err)
+
+	success, err := machine.FireWithBag(context.Background(), "ToEnd",
+		gonfa.NewPayloadBag().Set("userID", 7).Set("comment", "approved"))
+	require.NoError(t, err)
+	assert.True(t, success)
+	assert.Equal(t, "approved", comment)
+}
+
+func TestFireWithEventActions(t *testing.T) {
+	var order []string
+	record := func(name string, err error) gonfa.Action {
+		return &funcAction{fn: func(context.Context, gonfa.MachineState) error {
+			order = append(order, name)
+			return err
+		}}
+	}
+
+	def, err := builder.New().
+		InitialState("Draft").
+		FinalStates("Approved").
+		OnEntry("Review", record("entry", nil)).
+		OnEvent("Approve", record("logApprove", errors.New("log is down"))).
+		AddTransition("Draft", "Review", "Submit").
+		AddTransition("Review", "Approved", "Approve").
+		WithActions(record("action", nil)).
+		WithAfterActions(record("after", nil)).
+		WithSuccessHooks(record("success", nil)).
+		Build()
+	require.NoError(t, err)
+
+	machine, err := New(def)
+	require.NoError(t, err)
+
+	success, err := machine.Fire(context.Background(), "Submit", nil)
+	require.NoError(t, err)
+	assert.True(t, success)
+	assert.Equal(t, []string{"entry", "success"}, order)
+
+	order = nil
+	success, err = machine.Fire(context.Background(), "Approve", nil)
+	assert.True(t, success)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "event action failed: log is down")
+	assert.Equal(t, gonfa.State("Approved"), machine.CurrentState())
+	assert.Equal(t,
+		[]string{"action", "after", "logApprove", "success"},
+		order)
+}
+
+func TestFireWithGuardedStateActions(t *testing.T) {
+	entryAllowed := &testGuard{result: true}
+	entryDenied := &testGuard{result: false}
+	exitGuard := &testGuard{result: true}
+
+	runEntry := &testAction{name: "runEntry"}
+	skipEntry := &testAction{name: "skipEntry"}
+	runExit := &testAction{name: "runExit"}
+
+	def, err := builder.New().
+		InitialState("Start").
+		FinalStates("End").
+		OnEntryIf("End", entryAllowed, runEntry).
+		OnEntryIf("End", entryDenied, skipEntry).
+		OnExitIf("Start", exitGuard, runExit).
+		AddTransition("Start", "End", "ToEnd").
+		Build()
+	require.NoError(t, err)
+
+	machine, err := New(def)
+	require.NoError(t, err)
+
+	success, err := machine.Fire(context.Background(), "ToEnd", nil)
+	require.NoError(t, err)
+	assert.True(t, success)
+
+	assert.True(t, runEntry.executed)
+	assert.False(t, skipEntry.executed)
+	assert.True(t, runExit.executed)
+	assert.Equal(t, 1, exitGuard.calls)
+}
+
+func TestWithUnknownEventError(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("unknown event", func(t *testing.T) {
+		machine := createPingPongMachine(t, WithUnknownEventError())
+
+		ok, err := machine.Fire(ctx, "Hti", nil)
+		assert.False(t, ok)
+		assert.ErrorIs(t, err, gonfa.ErrUnknownEvent)
+		assert.Contains(t, err.Error(), "'Hti'")
+	})
+
+	t.Run("known event invalid in current state", func(t *testing.T) {
+		machine := createPingPongMachine(t, WithUnknownEventError())
+
+		ok, err := machine.Fire(ctx, "Stop", nil)
+		assert.NoError(t, err)
+		assert.False(t, ok)
+	})
+
+	t.Run("default behavior", func(t *testing.T) {
+		machine := createPingPongMachine(t)
+
+		ok, err := machine.Fire(ctx, "Hti", nil)
+		assert.NoError(t, err)
+		assert.False(t, ok)
+	})
+}
+
+func TestSwitchTransition(t *testing.T) {
+	ctx := context.Background()
+
+	create := func(t *testing.T, routes map[string]gonfa.State) *Machine {
+		def, err := builder.New().
+			InitialState("Triage").
+			FinalStates(slices.Collect(maps.Values(routes))...).
+			AddSwitchTransition("Triage", "Route", routes,
+				func(p gonfa.Payload) string {
+					kind, _ := gonfa.Get[string](p, "kind")
+					return kind
+				}).
+			Build()
+		require.NoError(t, err)
+
+		m, err := New(def)
+		require.NoError(t, err)
+
+		return m
+	}
+
+	routes := map[string]gonfa.State{
+		"invoice": "Billing",
+		"refund":  "Billing",
+		"bug":     "Support",
+	}
+
+	for kind, target := range map[string]gonfa.State{
+		"invoice": "Billing",
+		"refund":  "Billing",
+		"bug":     "Support",
+	} {
+		t.Run(kind, func(t *testing.T) {
+			m := create(t, routes)
+
+			ok, err := m.FireWithBag(ctx, "Route",
+				gonfa.NewPayloadBag().Set("kind", kind))
+			require.NoError(t, err)
+			assert.True(t, ok)
+			assert.Equal(t, target, m.CurrentState())
+		})
+	}
+
+	t.Run("unmatched key", func(t *testing.T) {
+		m := create(t, routes)
+
+		ok, err := m.FireWithBag(ctx, "Route",
+			gonfa.NewPayloadBag().Set("kind", "feedback"))
+		require.NoError(t, err)
+		assert.False(t, ok)
+		assert.Equal(t, gonfa.State("Triage"), m.CurrentState())
+	})
+
+	t.Run("default route", func(t *testing.T) {
+		withDefault := map[string]gonfa.State{
+			"bug":                "Support",
+			builder.DefaultRoute: "Manual",
+		}
+
+		m := create(t, withDefault)
+		ok, err := m.Fire(ctx, "Route", nil)
+		require.NoError(t, err)
+		assert.True(t, ok)
+		assert.Equal(t, gonfa.State("Manual"), m.CurrentState())
+
+		m = create(t, withDefault)
+		ok, err = m.FireWithBag(ctx, "Route",
+			gonfa.NewPayloadBag().Set("kind", "bug"))
+		require.NoError(t, err)
+		assert.True(t, ok)
+		assert.Equal(t, gonfa.State("Support"), m.CurrentState())
+	})
+}
+
+func TestInternalTransition(t *testing.T) {
+	onExit := &testAction{}
+	onEntry := &testAction{}
+	refresh := &testAction{}
+
+	def, err := builder.New().
+		InitialState("Editing").
+		FinalStates("Done").
+		OnExit("Editing", onExit).
+		OnEntry("Editing", onEntry).
+		AddTransition("Editing", "Editing", "Autosave").
+		WithActions(refresh).
+		WithInternal().
+		AddTransition("Editing", "Editing", "Reload").
+		AddTransition("Editing", "Done", "Finish").
+		WithInternal().
+		Build()
+	require.NoError(t, err)
+
+	machine, err := New(def)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+
+	success, err := machine.Fire(ctx, "Autosave", nil)
+	require.NoError(t, err)
+	require.True(t, success)
+	assert.Equal(t, 1, refresh.calls)
+	assert.Zero(t, onExit.calls)
+	assert.Zero(t, onEntry.calls)
+
+	// a regular self-transition leaves and re-enters the state
+	success, err = machine.Fire(ctx, "Reload", nil)
+	require.NoError(t, err)
+	require.True(t, success)
+	assert.Equal(t, 1, onExit.calls)
+	assert.Equal(t, 1, onEntry.calls)
+
+	// Internal has no effect on transitions to other states
+	success, err = machine.Fire(ctx, "Finish", nil)
+	require.NoError(t, err)
+	require.True(t, success)
+	assert.Equal(t, 2, onExit.calls)
+
+	history := machine.History()
+	require.Len(t, history, 3)
+	assert.Equal(t, gonfa.Event("Autosave"), history[0].On)
+	assert.Equal(t, gonfa.State("Editing"), history[0].To)
+}
+
+func TestTransitionPriority(t *testing.T) {
+	fastTrack := &testGuard{result: true}
+
+	def, err := builder.New().
+		InitialState("Review").
+		FinalStates("Approved", "Expedited").
+		AddTransition("Review", "Approved", "Approve").
+		AddTransition("Review", "Expedited", "Approve").
+		WithGuards(fastTrack).
+		WithPriority(10).
+		Build()
+	require.NoError(t, err)
+
+	ctx := context.Background()
+
+	// the fast path is preferred regardless of the declaration order
+	machine, err := New(def)
+	require.NoError(t, err)
+
+	result, err := machine.FireResult(ctx, "Approve", nil)
+	require.NoError(t, err)
+	require.NotNil(t, result)
+	assert.Equal(t, gonfa.State("Expedited"), result.To)
+	assert.Equal(t, 0, result.Index)
+
+	// and the default one is the fallback
+	fastTrack.result = false
+
+	machine, err = New(def)
+	require.NoError(t, err)
+
+	result, err = machine.FireResult(ctx, "Approve", nil)
+	require.NoError(t, err)
+	require.NotNil(t, result)
+	assert.Equal(t, gonfa.State("Approved"), result.To)
+	assert.Equal(t, 1, result.Index)
+}
+
+func TestEntryFailureRollback(t *testing.T) {
+	entryErr := errors.New("entry failed")
+	onExit := &testAction{}
+
+	def, err := builder.New().
+		InitialState("Draft").
+		FinalStates("Done").
+		OnExit("Draft", onExit).
+		OnEntry("Review", &testAction{err: entryErr}).
+		OnEntry("Draft", &testAction{err: entryErr}).
+		AddTransition("Draft", "Review", "Submit").
+		AddTransition("Review", "Done", "Approve").
+		AddTransition("Draft", "Draft", "Reload").
+		AddTransition("Draft", "Done", "Discard").
+		Build()
+	require.NoError(t, err)
+
+	ctx := context.Background()
+
+	t.Run("disabled", func(t *testing.T) {
+		machine, err := New(def, WithEntryFailureRollback(false))
+		require.NoError(t, err)
+
+		success, err := machine.Fire(ctx, "Submit", nil)
+		require.ErrorIs(t, err, entryErr)
+		assert.False(t, success)
+		assert.Equal(t, gonfa.State("Review"), machine.CurrentState())
+		assert.Len(t, machine.History(), 1)
+	})
+
+	t.Run("enabled", func(t *testing.T) {
+		var self []gonfa.HistoryEntry
+		machine, err := New(def,
+			WithEntryFailureRollback(true),
+			WithOnSelfTransition(func(e gonfa.HistoryEntry) {
+				self = append(self, e)
+			}))
+		require.NoError(t, err)
+
+		exits := onExit.calls
+
+		success, err := machine.Fire(ctx, "Submit", nil)
+		require.ErrorIs(t, err, entryErr)
+		assert.Contains(t, err.Error(), "transition rolled back")
+		assert.False(t, success)
+		assert.Equal(t, gonfa.State("Draft"), machine.CurrentState())
+		assert.Empty(t, machine.History())
+		// OnExit actions aren't undone
+		assert.Equal(t, exits+1, onExit.calls)
+
+		// a rolled back self-transition isn't reported
+		_, err = machine.Fire(ctx, "Reload", nil)
+		require.ErrorIs(t, err, entryErr)
+		assert.Empty(t, machine.History())
+		assert.Empty(t, self)
+
+		success, err = machine.Fire(ctx, "Discard", nil)
+		require.NoError(t, err)
+		assert.True(t, success)
+		assert.Len(t, machine.History(), 1)
+	})
+}
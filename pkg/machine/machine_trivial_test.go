@@ -0,0 +1,91 @@
+package machine
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dr-dobermann/gonfa/pkg/builder"
+	"github.com/dr-dobermann/gonfa/pkg/gonfa"
+)
+
+func TestFireTrivialTransition(t *testing.T) {
+	after := &testAction{name: "after"}
+	onEvent := &testAction{name: "onEvent"}
+	success := &testAction{name: "success"}
+
+	def, err := builder.New().
+		InitialState("Idle").
+		FinalStates("Done").
+		AddTransition("Idle", "Idle", "Ping").
+		AddTransition("Idle", "Done", "Finish").
+		WithAfterActions(after).
+		OnEvent("Finish", onEvent).
+		WithSuccessHooks(success).
+		Build()
+	require.NoError(t, err)
+
+	for _, tr := range def.Transitions() {
+		require.True(t, tr.Trivial())
+	}
+
+	var self int
+	machine, err := New(def,
+		WithOnSelfTransition(func(gonfa.HistoryEntry) { self++ }))
+	require.NoError(t, err)
+
+	ctx := context.Background()
+
+	ok, err := machine.Fire(ctx, "Ping", nil)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	ok, err = machine.Fire(ctx, "Finish", nil)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	assert.Equal(t, gonfa.State("Done"), machine.CurrentState())
+	assert.Equal(t, 1, self)
+	assert.Equal(t, 1, after.calls)
+	assert.Equal(t, 1, onEvent.calls)
+	assert.Equal(t, 2, success.calls)
+
+	history := machine.History()
+	require.Len(t, history, 2)
+	assert.Equal(t, gonfa.HistoryEntry{
+		From: "Idle", To: "Done", On: "Finish", Timestamp: history[1].Timestamp,
+	}, history[1])
+	assert.False(t, history[1].Timestamp.IsZero())
+}
+
+func BenchmarkFireTrivial(b *testing.B) {
+	bench := func(b *testing.B, guards ...gonfa.Guard) {
+		def, err := builder.New().
+			InitialState("Ping").
+			FinalStates("End").
+			AddTransition("Ping", "Pong", "Hit").
+			WithGuards(guards...).
+			AddTransition("Pong", "Ping", "Hit").
+			WithGuards(guards...).
+			AddTransition("Pong", "End", "Stop").
+			Build()
+		require.NoError(b, err)
+
+		m, err := New(def, WithHistoryEviction(1, nil))
+		require.NoError(b, err)
+
+		ctx := context.Background()
+		for b.Loop() {
+			if ok, err := m.Fire(ctx, "Hit", nil); !ok || err != nil {
+				b.Fatal("transition failed", err)
+			}
+		}
+	}
+
+	b.Run("trivial", func(b *testing.B) { bench(b) })
+	b.Run("guarded", func(b *testing.B) {
+		bench(b, &testGuard{result: true})
+	})
+}
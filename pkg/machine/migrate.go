@@ -0,0 +1,76 @@
+package machine
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/dr-dobermann/gonfa/pkg/definition"
+	"github.com/dr-dobermann/gonfa/pkg/gonfa"
+)
+
+// MigrateTo rebinds the machine to a new version of its definition, so
+// in-flight machines can adopt an upgraded workflow without a restart.
+//
+// The new definition is compatible if it has the current state, every
+// state mentioned in the history and the pending async actions (see
+// ResumeAsync), and satisfies the machine options (e.g. has the abort
+// state). Transitions may differ freely. On incompatibility
+// MigrateTo returns an error and the machine stays on the old definition.
+// The state and history are kept as is.
+func (m *Machine) MigrateTo(newDef *definition.Definition) error {
+	if newDef == nil {
+		return fmt.Errorf("definition cannot be nil")
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	err := checkCompatible(newDef, m.currentState, m.history,
+		slices.Concat(m.pending, m.suspended))
+	if err != nil {
+		return fmt.Errorf("incompatible definition: %w", err)
+	}
+
+	oldDef := m.definition
+	m.definition = newDef
+
+	if err := m.validateOptions(); err != nil {
+		m.definition = oldDef
+		return fmt.Errorf("incompatible definition: %w", err)
+	}
+
+	return nil
+}
+
+// checkCompatible checks that the machine state, history and pending async
+// actions can be bound to the definition.
+func checkCompatible(
+	def *definition.Definition,
+	current gonfa.State,
+	history []gonfa.HistoryEntry,
+	pending []gonfa.PendingAction,
+) error {
+	states := def.States()
+
+	if _, ok := states[current]; !ok {
+		return fmt.Errorf("current state '%s' not found in definition", current)
+	}
+
+	for i, h := range history {
+		for _, s := range []gonfa.State{h.From, h.To} {
+			if _, ok := states[s]; !ok {
+				return fmt.Errorf(
+					"state '%s' of history entry %d not found in definition",
+					s, i)
+			}
+		}
+	}
+
+	for _, p := range pending {
+		if _, err := asyncAction(def, p); err != nil {
+			return fmt.Errorf("invalid pending async action: %w", err)
+		}
+	}
+
+	return nil
+}
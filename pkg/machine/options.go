@@ -0,0 +1,189 @@
+package machine
+
+import (
+	"time"
+
+	"github.com/dr-dobermann/gonfa/pkg/gonfa"
+	"github.com/dr-dobermann/gonfa/pkg/registry"
+)
+
+// Option configures optional behavior of a Machine.
+// Options are applied by New and Restore in the order they are given.
+type Option func(*Machine)
+
+// WithExtender attaches a user-defined business object to the machine as
+// its state extender, available to guards and actions through the machine
+// (see gonfa.MachineState.StateExtender).
+func WithExtender(extender gonfa.StateExtender) Option {
+	return func(m *Machine) {
+		m.stateExtender = extender
+	}
+}
+
+// WithSelectionStrategy sets the strategy which orders competing
+// transitions (same source state and event) before they are attempted.
+// By default transitions are attempted in declaration order.
+func WithSelectionStrategy(s SelectionStrategy) Option {
+	return func(m *Machine) {
+		if s != nil {
+			m.selection = s
+		}
+	}
+}
+
+// WithHistoryEviction caps the machine history at maxLen entries. When a new
+// entry exceeds the cap, the oldest entries are removed and passed to
+// onEvict (e.g. to archive them in a cold store). If onEvict is nil the
+// entries are just dropped. maxLen <= 0 means unbounded history.
+//
+// Evicted entries are delivered oldest first, in the order they were
+// evicted, even when events are fired concurrently. The callback runs after
+// the machine lock is released, so it may read the machine, but it must not
+// fire events on the same machine. Entries exceeding the cap in a restored
+// history are evicted by Restore.
+func WithHistoryEviction(maxLen int, onEvict func(gonfa.HistoryEntry)) Option {
+	return func(m *Machine) {
+		m.maxHistory = maxLen
+		m.onEvict = onEvict
+	}
+}
+
+// WithMaxHistory caps the machine history at maxLen entries, dropping the
+// oldest ones, so the history works as a ring of bounded size and Marshal
+// stores only the most recent entries. Use WithHistoryEviction to be
+// notified of the dropped entries. maxLen <= 0 means unbounded history,
+// which is the default.
+func WithMaxHistory(maxLen int) Option {
+	return func(m *Machine) {
+		m.maxHistory = maxLen
+	}
+}
+
+// WithRegistry sets the registry used to resolve guard and action names
+// in diagnostics (e.g. guard traces). It doesn't affect the machine's
+// behavior.
+func WithRegistry(r *registry.Registry) Option {
+	return func(m *Machine) {
+		m.registry = r
+	}
+}
+
+// GuardTraceFunc receives the result of a single guard evaluation.
+type GuardTraceFunc func(
+	guardName string,
+	from gonfa.State,
+	event gonfa.Event,
+	result bool,
+)
+
+// WithGuardTrace sets a function called for every guard evaluated during
+// Fire, right after the guard returns. The guard name is taken from the
+// transition's GuardNames if set, otherwise it's resolved with the registry
+// set by WithRegistry, falling back to the guard's type (%T).
+// Tracing doesn't change the evaluation order or short-circuiting: guards
+// skipped after a rejection aren't reported.
+// The function is called under the machine lock and must not call the
+// machine's methods which take the lock.
+func WithGuardTrace(fn GuardTraceFunc) Option {
+	return func(m *Machine) {
+		m.guardTrace = fn
+	}
+}
+
+// WithRateLimit limits the machine to events per period using a token
+// bucket (see NewTokenBucket), so bursts of up to events are allowed. When the limit is
+// exceeded, Fire returns gonfa.ErrRateLimited without calling any guards,
+// actions or hooks. Use WithRateLimiter to share a limiter between machines.
+func WithRateLimit(events int, period time.Duration) Option {
+	return WithRateLimiter(NewTokenBucket(events, period))
+}
+
+// WithRateLimiter sets the limiter consulted by Fire before each event.
+// The limiter may be shared between machines or implemented by the user.
+func WithRateLimiter(l RateLimiter) Option {
+	return func(m *Machine) {
+		m.limiter = l
+	}
+}
+
+// WithAbortState sets the state Abort moves the machine to. New and Restore
+// fail if the state isn't in the definition.
+func WithAbortState(s gonfa.State) Option {
+	return func(m *Machine) {
+		m.abortState = s
+	}
+}
+
+// WithUnknownEventError makes Fire return gonfa.ErrUnknownEvent for an event
+// which isn't used in any transition of the definition (see
+// definition.Definition.AllEvents), without calling guards, actions or
+// hooks. It distinguishes a misspelled event from an event which is just
+// not valid in the current state; the latter still makes Fire return
+// (false, nil).
+func WithUnknownEventError() Option {
+	return func(m *Machine) {
+		m.unknownEventErr = true
+	}
+}
+
+// WithRejectionErrors makes Fire return a *gonfa.TransitionError when no
+// transition was committed because the current state has no enabled
+// transition on the event (gonfa.ErrNoTransition) or guards rejected all
+// candidates (gonfa.ErrGuardRejected), instead of (false, nil). The
+// OnFailure hooks are called as usual. Deferred events still return
+// gonfa.ErrEventDeferred.
+func WithRejectionErrors() Option {
+	return func(m *Machine) {
+		m.rejectionErrs = true
+	}
+}
+
+// WithEntryFailureRollback sets whether a failed OnEntry action of the
+// target state rolls the transition back: the machine returns to the source
+// state and the transition's history entry is removed before Fire returns
+// the error. By default the machine stays in the target state. The same
+// applies to a context canceled between the state change and the OnEntry
+// actions.
+//
+// Only the state change is undone: the OnExit actions of the source state
+// and the transition actions have already run and aren't re-invoked or
+// compensated, and the OnEntry actions which succeeded aren't undone
+// either. History entries evicted by the transition (see
+// WithHistoryEviction) aren't restored.
+func WithEntryFailureRollback(rollback bool) Option {
+	return func(m *Machine) {
+		m.entryRollback = rollback
+	}
+}
+
+// WithParallelGuards makes Fire evaluate the guards of a transition
+// concurrently instead of one by one. Once a guard rejects, defers or fails
+// to evaluate the transition, the context passed to the other guards is
+// canceled, so slow guards should respect it. Fire still waits for all the
+// guards to return, and the first guard which didn't pass decides the
+// result: the others are ignored since they may have just seen the
+// canceled context.
+//
+// Guards must be safe for concurrent use in this mode. The guard trace
+// (see WithGuardTrace) reports every evaluated guard in declaration order
+// after all of them return.
+func WithParallelGuards() Option {
+	return func(m *Machine) {
+		m.parallelGuards = true
+	}
+}
+
+// WithOnSelfTransition sets a function called for every committed
+// transition whose source and target states are the same (e.g. a "refresh"
+// on an idempotent event), so such transitions can be observed separately
+// from the ones making progress. The function gets the history entry of
+// the transition.
+//
+// Like the eviction callback (see WithHistoryEviction), the function runs
+// after the machine lock is released, in the order of the transitions, and
+// must not fire events on the same machine.
+func WithOnSelfTransition(fn func(gonfa.HistoryEntry)) Option {
+	return func(m *Machine) {
+		m.onSelf = fn
+	}
+}
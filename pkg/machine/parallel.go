@@ -0,0 +1,71 @@
+package machine
+
+import (
+	"context"
+	"sync"
+
+	"github.com/dr-dobermann/gonfa/pkg/definition"
+	"github.com/dr-dobermann/gonfa/pkg/gonfa"
+)
+
+// checkGuardsParallel evaluates the transition's guards concurrently.
+// The first guard which rejects, defers or fails to evaluate the transition
+// cancels the context of the remaining guards and decides the result: the
+// other guards which don't pass could just see the canceled context, so
+// they are ignored. Returns the index of the deciding guard (with whether
+// it deferred the transition and its error) or -1 if all guards passed.
+func (m *Machine) checkGuardsParallel(
+	ctx context.Context,
+	transition definition.Transition,
+	payload gonfa.Payload,
+) (int, bool, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		results  = make([]bool, len(transition.Guards))
+		deferred = make([]bool, len(transition.Guards))
+		errs     = make([]error, len(transition.Guards))
+		decider  = -1
+		state    = lockedState{m}
+	)
+
+	for i, guard := range transition.Guards {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			results[i], deferred[i], errs[i] = checkGuard(
+				ctx, guard, state, payload)
+			if results[i] {
+				return
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			if decider < 0 {
+				decider = i
+				cancel()
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	for i, passed := range results {
+		if m.guardTrace != nil {
+			m.guardTrace(m.transitionGuardName(transition, i),
+				transition.From, transition.On, passed)
+		}
+		m.traceGuard(transition, i, passed)
+	}
+
+	if decider < 0 {
+		return -1, false, nil
+	}
+
+	return decider, deferred[decider], errs[decider]
+}
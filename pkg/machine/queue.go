@@ -0,0 +1,137 @@
+package machine
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/dr-dobermann/gonfa/pkg/gonfa"
+)
+
+// queuedEvent is an event submitted by FireAsync.
+type queuedEvent struct {
+	ctx     context.Context
+	event   gonfa.Event
+	payload gonfa.Payload
+	outcome chan gonfa.FireOutcome
+}
+
+// queueWorkerKey is the context key marking the events fired by the async
+// queue worker of the machine stored under it.
+type queueWorkerKey struct{}
+
+// WithAsyncQueue enables FireAsync: the machine starts a worker goroutine
+// firing the submitted events one by one in the order they were submitted.
+// Up to bufferSize events wait in the queue; FireAsync blocks while it's
+// full. Call Close to stop the worker. Fire can still be used along with
+// FireAsync.
+func WithAsyncQueue(bufferSize int) Option {
+	return func(m *Machine) {
+		m.queueSize = max(bufferSize, 0)
+		m.queueEnabled = true
+	}
+}
+
+// startQueue starts the worker of the async queue if it's enabled.
+func (m *Machine) startQueue() {
+	if !m.queueEnabled {
+		return
+	}
+
+	m.queue = make(chan queuedEvent, m.queueSize)
+	m.queueDone = make(chan struct{})
+
+	go func() {
+		defer close(m.queueDone)
+
+		for e := range m.queue {
+			ctx := context.WithValue(e.ctx, queueWorkerKey{}, m)
+			result, err := m.FireResult(ctx, e.event, e.payload)
+			e.outcome <- gonfa.FireOutcome{Result: result, Err: err}
+			close(e.outcome)
+		}
+	}()
+}
+
+// FireAsync submits the event to the async queue (see WithAsyncQueue) and
+// returns a channel receiving the outcome once the worker fired it. The
+// channel is closed after the outcome is sent. FireAsync doesn't wait for
+// guards and actions, but it blocks while the queue is full; if ctx is done
+// meanwhile, the outcome is ctx's error.
+//
+// The outcome is an error if the machine has no async queue, and
+// gonfa.ErrMachineClosed if it was closed.
+//
+// Guards, actions and hooks of an event fired by the worker may submit
+// events with the context they received, but they must not wait for the
+// outcome, since the worker fires events one at a time. Such calls never
+// block: if the queue is full (an unbuffered queue always is), the outcome
+// is gonfa.ErrQueueFull. Submitting from the worker with an unrelated
+// context blocks forever once the queue is full.
+func (m *Machine) FireAsync(
+	ctx context.Context,
+	event gonfa.Event,
+	payload gonfa.Payload,
+) <-chan gonfa.FireOutcome {
+	outcome := make(chan gonfa.FireOutcome, 1)
+
+	reject := func(err error) <-chan gonfa.FireOutcome {
+		outcome <- gonfa.FireOutcome{Err: err}
+		close(outcome)
+
+		return outcome
+	}
+
+	if !m.queueEnabled {
+		return reject(fmt.Errorf("async queue isn't enabled"))
+	}
+
+	m.queueMu.RLock()
+	defer m.queueMu.RUnlock()
+
+	if m.queueClosed {
+		return reject(gonfa.ErrMachineClosed)
+	}
+
+	e := queuedEvent{ctx, event, payload, outcome}
+
+	// The worker can't drain the queue while it waits for it
+	if ctx.Value(queueWorkerKey{}) == m {
+		select {
+		case m.queue <- e:
+			return outcome
+
+		default:
+			return reject(fmt.Errorf("%w: event '%s'",
+				gonfa.ErrQueueFull, event))
+		}
+	}
+
+	select {
+	case m.queue <- e:
+		return outcome
+
+	case <-ctx.Done():
+		return reject(ctx.Err())
+	}
+}
+
+// Close stops the async queue worker (see WithAsyncQueue) after it fires the
+// events already submitted, and waits for it. Events submitted afterwards
+// get gonfa.ErrMachineClosed. Close may be called several times and does
+// nothing if the machine has no async queue.
+func (m *Machine) Close() error {
+	if !m.queueEnabled {
+		return nil
+	}
+
+	m.queueMu.Lock()
+	if !m.queueClosed {
+		m.queueClosed = true
+		close(m.queue)
+	}
+	m.queueMu.Unlock()
+
+	<-m.queueDone
+
+	return nil
+}
@@ -0,0 +1,61 @@
+package machine
+
+import (
+	"sync"
+	"time"
+)
+
+// RateLimiter limits the rate of events processed by machines.
+// Allow is called once per fired event and must be safe for concurrent use
+// if the limiter is shared between machines.
+type RateLimiter interface {
+	// Allow reports whether one more event may be processed now.
+	Allow() bool
+}
+
+// TokenBucket is a RateLimiter allowing bursts of up to capacity events and
+// refilling at the rate of capacity events per period.
+type TokenBucket struct {
+	mu       sync.Mutex
+	capacity float64
+	rate     float64 // tokens per nanosecond
+	tokens   float64
+	last     time.Time
+	now      func() time.Time
+}
+
+// NewTokenBucket creates a full TokenBucket allowing events per period.
+// Non-positive events or period produce a limiter which rejects every event.
+func NewTokenBucket(events int, period time.Duration) *TokenBucket {
+	tb := &TokenBucket{now: time.Now}
+
+	if events > 0 && period > 0 {
+		tb.capacity = float64(events)
+		tb.rate = float64(events) / float64(period)
+	}
+
+	tb.tokens = tb.capacity
+	tb.last = tb.now()
+
+	return tb
+}
+
+// Allow takes a token from the bucket if there is any.
+func (tb *TokenBucket) Allow() bool {
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+
+	now := tb.now()
+	if elapsed := now.Sub(tb.last); elapsed > 0 {
+		tb.tokens = min(tb.capacity, tb.tokens+float64(elapsed)*tb.rate)
+	}
+	tb.last = now
+
+	if tb.tokens < 1 {
+		return false
+	}
+
+	tb.tokens--
+
+	return true
+}
@@ -0,0 +1,91 @@
+package machine
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/dr-dobermann/gonfa/pkg/definition"
+	"github.com/dr-dobermann/gonfa/pkg/gonfa"
+)
+
+// GuardRejection describes a guard which blocked a candidate transition
+// during Fire.
+type GuardRejection struct {
+	Transition definition.Transition // Rejected transition
+	GuardIndex int                   // Index of the guard in Transition.Guards
+	Guard      gonfa.Guard           // Guard which returned false
+}
+
+// String returns a human-readable description of the rejection. The guard
+// is named after Transition.GuardNames if the definition provides them
+// (e.g. it was loaded from YAML).
+func (r GuardRejection) String() string {
+	if r.GuardIndex >= 0 && r.GuardIndex < len(r.Transition.GuardNames) {
+		return fmt.Sprintf("guard '%s' failed on transition %s",
+			r.Transition.GuardNames[r.GuardIndex],
+			transitionName(r.Transition))
+	}
+
+	return fmt.Sprintf("%s->%s on %s blocked by guard #%d (%T)",
+		r.Transition.From, r.Transition.To, r.Transition.On,
+		r.GuardIndex, r.Guard)
+}
+
+// guardRejectionsKey is the context key under which Fire passes the guard
+// rejections to the OnFailure hooks.
+type guardRejectionsKey struct{}
+
+// withGuardRejections returns ctx carrying the given rejections.
+func withGuardRejections(
+	ctx context.Context,
+	rejections []GuardRejection,
+) context.Context {
+	if len(rejections) == 0 {
+		return ctx
+	}
+
+	return context.WithValue(ctx, guardRejectionsKey{}, rejections)
+}
+
+// GuardRejections returns the guard rejections Fire collected before calling
+// the OnFailure hooks, in the order the transitions were attempted.
+// The rejections are stored in the hooks' context under an unexported key,
+// so this function is the only way to read them. Returns nil if no guard
+// rejected a transition (e.g. there was no transition for the event).
+func GuardRejections(ctx context.Context) []GuardRejection {
+	rejections, _ := ctx.Value(guardRejectionsKey{}).([]GuardRejection)
+
+	return rejections
+}
+
+// transitionName returns the transition's Name or, if it isn't set,
+// describes the transition the same way.
+func transitionName(t definition.Transition) string {
+	if t.Name != "" {
+		return t.Name
+	}
+
+	return fmt.Sprintf("%s->%s on %s", t.From, t.To, t.On)
+}
+
+// transitionGuardName returns the name of the transition's i-th guard from
+// the definition if it's set, otherwise falls back to guardName.
+func (m *Machine) transitionGuardName(t definition.Transition, i int) string {
+	if i < len(t.GuardNames) {
+		return t.GuardNames[i]
+	}
+
+	return m.guardName(t.Guards[i])
+}
+
+// guardName returns the registered name of the guard or its type name if
+// the guard isn't found in the machine's registry.
+func (m *Machine) guardName(g gonfa.Guard) string {
+	if m.registry != nil {
+		if name, ok := m.registry.NameOf(g); ok {
+			return name
+		}
+	}
+
+	return fmt.Sprintf("%T", g)
+}
@@ -0,0 +1,127 @@
+package machine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/dr-dobermann/gonfa/pkg/definition"
+	"github.com/dr-dobermann/gonfa/pkg/gonfa"
+)
+
+// sagaStep is a transition committed within the active saga.
+type sagaStep struct {
+	transition definition.Transition
+	payload    gonfa.Payload
+}
+
+// BeginSaga starts a saga: a logical unit of transitions which is either
+// committed as a whole by CommitSaga or compensated by AbortSaga.
+//
+// While the saga is active, every committed transition having
+// compensations (see builder.Builder.WithCompensation) is recorded. If a
+// later Fire fails with an error (a failed action or a violated
+// precondition), the saga is aborted automatically before the failure
+// hooks are called. A rejected event (Fire returns false, nil) doesn't
+// abort the saga.
+//
+// Sagas only compensate side effects: aborting doesn't change the current
+// state or history of the machine. The saga isn't a part of the machine
+// state, so it isn't persisted by Marshal.
+//
+// Returns an error if a saga is already active.
+func (m *Machine) BeginSaga() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.inSaga {
+		return fmt.Errorf("saga is already active")
+	}
+
+	m.inSaga = true
+	m.sagaSteps = nil
+
+	return nil
+}
+
+// CommitSaga ends the active saga keeping all its transitions.
+// Recorded compensations are discarded.
+func (m *Machine) CommitSaga() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !m.inSaga {
+		return fmt.Errorf("no active saga")
+	}
+
+	m.endSaga()
+
+	return nil
+}
+
+// AbortSaga ends the active saga running the compensations of its committed
+// transitions in reverse order of the transitions. Compensations of a
+// single transition run in declaration order and get the payload the
+// transition was fired with. All compensations run even if some of them
+// fail; their errors are joined.
+func (m *Machine) AbortSaga(ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !m.inSaga {
+		return fmt.Errorf("no active saga")
+	}
+
+	return m.abortSaga(ctx)
+}
+
+// InSaga checks if the machine has an active saga.
+func (m *Machine) InSaga() bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.inSaga
+}
+
+// recordSagaStep remembers the committed transition if a saga is active and
+// the transition has compensations. Must be called under the write lock.
+func (m *Machine) recordSagaStep(
+	transition definition.Transition,
+	payload gonfa.Payload,
+) {
+	if !m.inSaga || len(transition.Compensations) == 0 {
+		return
+	}
+
+	m.sagaSteps = append(m.sagaSteps,
+		sagaStep{transition: transition, payload: payload})
+}
+
+// abortSaga runs the compensations and ends the saga.
+// Must be called under the write lock.
+func (m *Machine) abortSaga(ctx context.Context) error {
+	steps := m.sagaSteps
+	m.endSaga()
+
+	var errs []error
+	for i := len(steps) - 1; i >= 0; i-- {
+		step := steps[i]
+		for _, action := range step.transition.Compensations {
+			if err := action.Execute(ctx, lockedState{m}, step.payload); err != nil {
+				errs = append(errs, fmt.Errorf(
+					"compensation of transition from '%s' to '%s' "+
+						"on event '%s' failed: %w",
+					step.transition.From, step.transition.To,
+					step.transition.On, err))
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// endSaga deactivates the saga. Must be called under the write lock.
+func (m *Machine) endSaga() {
+	m.inSaga = false
+	m.sagaSteps = nil
+}
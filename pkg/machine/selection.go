@@ -0,0 +1,95 @@
+package machine
+
+import (
+	"math/rand/v2"
+	"sync"
+
+	"github.com/dr-dobermann/gonfa/pkg/definition"
+)
+
+// SelectionStrategy decides the order in which the machine attempts
+// transitions competing for the same source state and event.
+// Fire attempts the returned transitions one by one until the first one
+// passes its guards. Transitions omitted from the result are not attempted.
+type SelectionStrategy interface {
+	Order(candidates []definition.Transition) []definition.Transition
+}
+
+// FirstMatch returns the default strategy which keeps the declaration order
+// of transitions.
+func FirstMatch() SelectionStrategy {
+	return firstMatch{}
+}
+
+type firstMatch struct{}
+
+func (firstMatch) Order(
+	candidates []definition.Transition,
+) []definition.Transition {
+	return candidates
+}
+
+// RandomWeighted returns a strategy which orders candidates randomly with
+// the probability of a transition being attempted first proportional to its
+// Weight. Zero-weight transitions are never attempted unless none of the
+// candidates has a positive weight, in which case the declaration order is
+// kept. Definitions don't mix zero and positive weights of competing
+// transitions (see definition.New), so only custom candidates lists can
+// drop a transition.
+//
+// If r is nil the global math/rand/v2 generator is used. The strategy is
+// safe to share between machines.
+func RandomWeighted(r *rand.Rand) SelectionStrategy {
+	return &randomWeighted{rnd: r}
+}
+
+type randomWeighted struct {
+	mu  sync.Mutex
+	rnd *rand.Rand
+}
+
+func (rw *randomWeighted) float64() float64 {
+	if rw.rnd == nil {
+		return rand.Float64()
+	}
+
+	rw.mu.Lock()
+	defer rw.mu.Unlock()
+
+	return rw.rnd.Float64()
+}
+
+func (rw *randomWeighted) Order(
+	candidates []definition.Transition,
+) []definition.Transition {
+	pool := make([]definition.Transition, 0, len(candidates))
+	total := 0.0
+	for _, t := range candidates {
+		if t.Weight > 0 {
+			pool = append(pool, t)
+			total += t.Weight
+		}
+	}
+
+	if len(pool) == 0 {
+		return candidates
+	}
+
+	result := make([]definition.Transition, 0, len(pool))
+	for len(pool) > 0 {
+		point := rw.float64() * total
+		i := 0
+		for ; i < len(pool)-1; i++ {
+			point -= pool[i].Weight
+			if point < 0 {
+				break
+			}
+		}
+
+		result = append(result, pool[i])
+		total -= pool[i].Weight
+		pool = append(pool[:i], pool[i+1:]...)
+	}
+
+	return result
+}
@@ -0,0 +1,39 @@
+package machine
+
+import (
+	"context"
+	"time"
+
+	"github.com/dr-dobermann/gonfa/pkg/gonfa"
+)
+
+// lockedState is a gonfa.MachineState view of a machine whose lock is
+// already held by the caller. Its methods read the machine without locking,
+// so callbacks invoked under the lock can use them without deadlocks.
+type lockedState struct {
+	m *Machine
+}
+
+func (s lockedState) CurrentState() gonfa.State {
+	return s.m.currentState
+}
+
+func (s lockedState) History() []gonfa.HistoryEntry {
+	return append([]gonfa.HistoryEntry{}, s.m.history...)
+}
+
+func (s lockedState) IsInFinalState() bool {
+	return s.m.inFinalState(context.Background(), nil)
+}
+
+func (s lockedState) StateExtender() gonfa.StateExtender {
+	return s.m.stateExtender
+}
+
+func (s lockedState) VisitCount(state gonfa.State) int {
+	return visitCount(s.m.history, state)
+}
+
+func (s lockedState) Now() time.Time {
+	return s.m.clock.Now()
+}
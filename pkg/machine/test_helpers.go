@@ -2,10 +2,22 @@ package machine
 
 import (
 	"context"
+	"time"
 
 	"github.com/dr-dobermann/gonfa/pkg/gonfa"
 )
 
+// fakeClock advances by step on every reading.
+type fakeClock struct {
+	now  time.Time
+	step time.Duration
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.now = c.now.Add(c.step)
+	return c.now
+}
+
 // Test implementations
 type testStateExtender struct {
 	data string
@@ -41,3 +53,56 @@ func (a *testAction) Execute(
 	a.executed = true
 	return a.err
 }
+
+// funcAction delegates Execute to fn.
+type funcAction struct {
+	fn func(ctx context.Context, state gonfa.MachineState) error
+}
+
+func (a *funcAction) Execute(
+	ctx context.Context,
+	state gonfa.MachineState,
+	payload gonfa.Payload,
+) error {
+	return a.fn(ctx, state)
+}
+
+// payloadAction passes the payload to fn.
+type payloadAction struct {
+	fn func(payload gonfa.Payload)
+}
+
+func (a *payloadAction) Execute(
+	ctx context.Context,
+	state gonfa.MachineState,
+	payload gonfa.Payload,
+) error {
+	a.fn(payload)
+	return nil
+}
+
+// payloadGuard delegates Check to fn.
+type payloadGuard struct {
+	fn func(payload gonfa.Payload) bool
+}
+
+func (g *payloadGuard) Check(
+	ctx context.Context,
+	state gonfa.MachineState,
+	payload gonfa.Payload,
+) bool {
+	return g.fn(payload)
+}
+
+// stateGuard passes the machine state to fn.
+type stateGuard struct {
+	fn func(state gonfa.MachineState) bool
+}
+
+func (g *stateGuard) Check(
+	ctx context.Context,
+	state gonfa.MachineState,
+	payload gonfa.Payload,
+) bool {
+	return g.fn(state)
+}
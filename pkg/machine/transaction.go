@@ -0,0 +1,98 @@
+package machine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/dr-dobermann/gonfa/pkg/gonfa"
+)
+
+// EventWithPayload is an event fired along with its payload.
+type EventWithPayload struct {
+	Event   gonfa.Event
+	Payload gonfa.Payload
+}
+
+// checkpoint is the machine state saved before a transaction.
+type checkpoint struct {
+	state    gonfa.State
+	history  []gonfa.HistoryEntry
+	evicted  int
+	self     int
+	deferred int
+}
+
+// FireTransaction fires the events in order as an all-or-nothing unit. If
+// any event fails with an error (including errors Fire reports along with a
+// committed transition) or makes no transition, the machine is rolled back
+// to the state and history it had before the transaction, and the error
+// names the failed event. No eviction or self-transition callbacks are
+// called for the entries of a rolled back transaction.
+//
+// The transaction runs as a saga (see BeginSaga), so on rollback the
+// compensations of the committed transitions are run in reverse order.
+// Other side effects of actions and hooks are NOT rolled back, and async
+// actions started by the transaction aren't canceled. Returns an error if a
+// saga is already active.
+func (m *Machine) FireTransaction(
+	ctx context.Context,
+	events []EventWithPayload,
+) error {
+	m.mu.Lock()
+	defer m.unlockAndNotify()
+
+	if m.inSaga {
+		return fmt.Errorf("transaction can't run within an active saga")
+	}
+
+	cp := checkpoint{
+		state:    m.currentState,
+		history:  slices.Clone(m.history),
+		evicted:  len(m.evicted),
+		self:     len(m.selfTransitions),
+		deferred: len(m.deferred),
+	}
+
+	m.inSaga = true
+	m.sagaSteps = nil
+
+	for i, e := range events {
+		result, err := m.fire(ctx, e.Event, e.Payload)
+		if result != nil && err == nil {
+			continue
+		}
+
+		if err == nil {
+			err = gonfa.ErrNoTransition
+		}
+
+		// A failed transition has already aborted the saga
+		if m.inSaga {
+			if sagaErr := m.abortSaga(ctx); sagaErr != nil {
+				err = errors.Join(err,
+					fmt.Errorf("saga abort failed: %w", sagaErr))
+			}
+		}
+
+		m.rollback(cp)
+
+		return fmt.Errorf("transaction rolled back at event %d '%s': %w",
+			i, e.Event, err)
+	}
+
+	m.endSaga()
+
+	return nil
+}
+
+// rollback restores the machine state saved in cp.
+// Must be called under the write lock.
+func (m *Machine) rollback(cp checkpoint) {
+	m.currentState = cp.state
+	m.history = cp.history
+	m.evicted = m.evicted[:cp.evicted]
+	m.selfTransitions = m.selfTransitions[:cp.self]
+	m.deferred = m.deferred[:cp.deferred]
+}
@@ -0,0 +1,93 @@
+package registry
+
+import (
+	"github.com/dr-dobermann/gonfa/pkg/gonfa"
+)
+
+// Resolver looks guards and actions up by name. It's the part of Registry
+// used by the definition loader, so other lookup strategies (e.g. Chain)
+// can be used in its place.
+type Resolver interface {
+	// GetGuard returns the guard registered under the name.
+	GetGuard(name string) (gonfa.Guard, bool)
+	// GetAction returns the action registered under the name.
+	GetAction(name string) (gonfa.Action, bool)
+	// ListGuards returns the names of all guards.
+	ListGuards() []string
+	// ListActions returns the names of all actions.
+	ListActions() []string
+}
+
+var _ Resolver = (*Registry)(nil)
+
+// chain is a Resolver trying several resolvers in order.
+type chain []Resolver
+
+// Chain returns a Resolver which looks a name up in the resolvers in the
+// given order; the first match wins. It lets an app-specific registry
+// override a base registry of common objects:
+//
+//	res := registry.Chain(appRegistry, baseRegistry)
+//
+// Nil resolvers are skipped.
+func Chain(resolvers ...Resolver) Resolver {
+	c := make(chain, 0, len(resolvers))
+	for _, r := range resolvers {
+		if r != nil {
+			c = append(c, r)
+		}
+	}
+
+	return c
+}
+
+// GetGuard returns the guard from the first resolver having the name.
+func (c chain) GetGuard(name string) (gonfa.Guard, bool) {
+	for _, r := range c {
+		if g, ok := r.GetGuard(name); ok {
+			return g, true
+		}
+	}
+
+	return nil, false
+}
+
+// GetAction returns the action from the first resolver having the name.
+func (c chain) GetAction(name string) (gonfa.Action, bool) {
+	for _, r := range c {
+		if a, ok := r.GetAction(name); ok {
+			return a, true
+		}
+	}
+
+	return nil, false
+}
+
+// ListGuards returns the unique guard names of all resolvers.
+func (c chain) ListGuards() []string {
+	return c.list(Resolver.ListGuards)
+}
+
+// ListActions returns the unique action names of all resolvers.
+func (c chain) ListActions() []string {
+	return c.list(Resolver.ListActions)
+}
+
+// list collects unique names returned by list for every resolver.
+func (c chain) list(list func(Resolver) []string) []string {
+	var (
+		names []string
+		seen  = make(map[string]struct{})
+	)
+
+	for _, r := range c {
+		for _, name := range list(r) {
+			if _, ok := seen[name]; !ok {
+				seen[name] = struct{}{}
+				names = append(names, name)
+			}
+		}
+	}
+
+	return names
+}
@@ -0,0 +1,100 @@
+package registry
+
+import (
+	"cmp"
+	"slices"
+)
+
+// Manifest is a serializable list of the names registered in a Registry,
+// used to distribute a set of workflow building blocks. The implementations
+// can't be serialized, so the importing side has to register its own ones
+// under the listed names.
+type Manifest struct {
+	Guards  []ManifestEntry `json:"guards"`
+	Actions []ManifestEntry `json:"actions"`
+}
+
+// ManifestEntry is a registered name along with its metadata, if any.
+type ManifestEntry struct {
+	Name string `json:"name"`
+	Meta *Meta  `json:"meta,omitempty"`
+}
+
+// Export returns the manifest of the registry with the entries sorted by
+// name. The metadata is the one Describe returns.
+func (r *Registry) Export() Manifest {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return Manifest{
+		Guards:  manifestEntries(r.guards, r.guardMeta),
+		Actions: manifestEntries(r.actions, r.actionMeta),
+	}
+}
+
+// manifestEntries returns the sorted manifest entries of the objects.
+func manifestEntries[T any](
+	objects map[string]T,
+	metas map[string]Meta,
+) []ManifestEntry {
+	entries := make([]ManifestEntry, 0, len(objects))
+	for name, obj := range objects {
+		entry := ManifestEntry{Name: name}
+		if meta, ok := describe(obj, metas, name); ok {
+			entry.Meta = &meta
+		}
+		entries = append(entries, entry)
+	}
+
+	slices.SortFunc(entries, func(a, b ManifestEntry) int {
+		return cmp.Compare(a.Name, b.Name)
+	})
+
+	return entries
+}
+
+// ImportNames returns the sorted distinct names listed in the manifest,
+// i.e. the names the importing side has to register.
+func (m Manifest) ImportNames() []string {
+	names := make([]string, 0, len(m.Guards)+len(m.Actions))
+	for _, e := range slices.Concat(m.Guards, m.Actions) {
+		names = append(names, e.Name)
+	}
+
+	slices.Sort(names)
+
+	return slices.Compact(names)
+}
+
+// NameRequirer is implemented by objects referring to registered names,
+// e.g. a *definition.Definition loaded from YAML.
+type NameRequirer interface {
+	RequiredNames() (guards []string, actions []string)
+}
+
+// MissingFor returns the sorted distinct names d requires which aren't
+// registered in the registry with the required kind (a guard name must be
+// registered as a guard, an action name as an action). It verifies the
+// registry is complete for a workflow before running it.
+func (r *Registry) MissingFor(d NameRequirer) []string {
+	guards, actions := d.RequiredNames()
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var missing []string
+	for _, name := range guards {
+		if _, ok := r.guards[name]; !ok {
+			missing = append(missing, name)
+		}
+	}
+	for _, name := range actions {
+		if _, ok := r.actions[name]; !ok {
+			missing = append(missing, name)
+		}
+	}
+
+	slices.Sort(missing)
+
+	return slices.Compact(missing)
+}
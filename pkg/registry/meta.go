@@ -0,0 +1,85 @@
+package registry
+
+import "github.com/dr-dobermann/gonfa/pkg/gonfa"
+
+// Meta describes a registered guard or action, e.g. for admin UIs listing
+// the building blocks available to workflow authors.
+type Meta struct {
+	Description string `json:"description,omitempty"`
+	Category    string `json:"category,omitempty"`
+	Version     string `json:"version,omitempty"`
+}
+
+// Describable is an optional interface of guards and actions which describe
+// themselves. Its metadata is used by Describe for objects registered
+// without explicit metadata.
+type Describable interface {
+	Meta() Meta
+}
+
+// RegisterGuardWithMeta registers a guard like RegisterGuard along with its
+// metadata. The metadata takes precedence over the guard's own one (see
+// Describable).
+func (r *Registry) RegisterGuardWithMeta(
+	name string,
+	guard gonfa.Guard,
+	meta Meta,
+) error {
+	return r.registerGuard(name, guard, &meta)
+}
+
+// RegisterActionWithMeta registers an action like RegisterAction along with
+// its metadata. The metadata takes precedence over the action's own one
+// (see Describable).
+func (r *Registry) RegisterActionWithMeta(
+	name string,
+	action gonfa.Action,
+	meta Meta,
+) error {
+	return r.registerAction(name, action, &meta)
+}
+
+// Describe returns the metadata of the guard or action registered under the
+// name: the one given at registration or, failing that, the one provided
+// by the object itself if it implements Describable. Guards are looked up
+// before actions, so use DescribeAction for an action sharing its name with
+// a guard.
+// Returns false if the name isn't registered or has no metadata.
+func (r *Registry) Describe(name string) (Meta, bool) {
+	if meta, ok := r.DescribeGuard(name); ok {
+		return meta, true
+	}
+
+	return r.DescribeAction(name)
+}
+
+// DescribeGuard returns the metadata of the guard registered under the name.
+// Returns false if there is no such guard or it has no metadata.
+func (r *Registry) DescribeGuard(name string) (Meta, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return describe(r.guards[name], r.guardMeta, name)
+}
+
+// DescribeAction returns the metadata of the action registered under the
+// name. Returns false if there is no such action or it has no metadata.
+func (r *Registry) DescribeAction(name string) (Meta, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return describe(r.actions[name], r.actionMeta, name)
+}
+
+// describe returns the metadata of the registered object obj.
+func describe(obj any, metas map[string]Meta, name string) (Meta, bool) {
+	if meta, ok := metas[name]; ok {
+		return meta, true
+	}
+
+	if d, ok := obj.(Describable); ok {
+		return d.Meta(), true
+	}
+
+	return Meta{}, false
+}
@@ -14,6 +14,7 @@ package registry
 
 import (
 	"fmt"
+	"reflect"
 	"sync"
 
 	"github.com/dr-dobermann/gonfa/pkg/gonfa"
@@ -26,19 +27,34 @@ type Registry struct {
 	mu      sync.RWMutex
 	guards  map[string]gonfa.Guard
 	actions map[string]gonfa.Action
+
+	// metadata given at registration
+	guardMeta  map[string]Meta
+	actionMeta map[string]Meta
 }
 
 // New creates a new Registry instance.
 func New() *Registry {
 	return &Registry{
-		guards:  make(map[string]gonfa.Guard),
-		actions: make(map[string]gonfa.Action),
+		guards:     make(map[string]gonfa.Guard),
+		actions:    make(map[string]gonfa.Action),
+		guardMeta:  make(map[string]Meta),
+		actionMeta: make(map[string]Meta),
 	}
 }
 
 // RegisterGuard registers a guard object under a unique name.
 // Returns an error if the name is already registered.
 func (r *Registry) RegisterGuard(name string, guard gonfa.Guard) error {
+	return r.registerGuard(name, guard, nil)
+}
+
+// registerGuard registers the guard along with its metadata, if any.
+func (r *Registry) registerGuard(
+	name string,
+	guard gonfa.Guard,
+	meta *Meta,
+) error {
 	if name == "" {
 		return fmt.Errorf("guard name cannot be empty")
 	}
@@ -54,6 +70,9 @@ func (r *Registry) RegisterGuard(name string, guard gonfa.Guard) error {
 	}
 
 	r.guards[name] = guard
+	if meta != nil {
+		r.guardMeta[name] = *meta
+	}
 	return nil
 }
 
@@ -62,6 +81,15 @@ func (r *Registry) RegisterGuard(name string, guard gonfa.Guard) error {
 func (r *Registry) RegisterAction(
 	name string,
 	action gonfa.Action,
+) error {
+	return r.registerAction(name, action, nil)
+}
+
+// registerAction registers the action along with its metadata, if any.
+func (r *Registry) registerAction(
+	name string,
+	action gonfa.Action,
+	meta *Meta,
 ) error {
 	if name == "" {
 		return fmt.Errorf("action name cannot be empty")
@@ -79,6 +107,9 @@ func (r *Registry) RegisterAction(
 	}
 
 	r.actions[name] = action
+	if meta != nil {
+		r.actionMeta[name] = *meta
+	}
 	return nil
 }
 
@@ -102,6 +133,51 @@ func (r *Registry) GetAction(name string) (gonfa.Action, bool) {
 	return action, exists
 }
 
+// NameOf returns the name the guard instance is registered under. If it's
+// registered under several names, the lexicographically smallest one is
+// returned, so the result is stable.
+// Returns an empty string and false if the instance isn't registered.
+func (r *Registry) NameOf(guard gonfa.Guard) (string, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return nameOf(r.guards, guard)
+}
+
+// NameOfAction returns the name the action instance is registered under,
+// the lexicographically smallest one if there are several (see NameOf).
+// Returns an empty string and false if the instance isn't registered.
+func (r *Registry) NameOfAction(action gonfa.Action) (string, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return nameOf(r.actions, action)
+}
+
+// nameOf returns the smallest name obj is stored under in objects.
+func nameOf[T any](objects map[string]T, obj any) (string, bool) {
+	found := false
+	name := ""
+	for n, o := range objects {
+		if sameObject(o, obj) && (!found || n < name) {
+			name, found = n, true
+		}
+	}
+
+	return name, found
+}
+
+// sameObject checks if a and b hold the same comparable value (for pointer
+// implementations, the same instance). Non-comparable values never match.
+func sameObject(a, b any) bool {
+	if a == nil || b == nil {
+		return false
+	}
+
+	ta := reflect.TypeOf(a)
+	return ta == reflect.TypeOf(b) && ta.Comparable() && a == b
+}
+
 // ListGuards returns a slice of all registered guard names.
 func (r *Registry) ListGuards() []string {
 	r.mu.RLock()
@@ -104,3 +104,55 @@ func TestGetAction(t *testing.T) {
 	assert.True(t, exists)
 	assert.Equal(t, action, retrievedAction)
 }
+
+func TestNameOf(t *testing.T) {
+	reg := New()
+	guard := &testGuard{result: true}
+	action := &testAction{}
+
+	require.NoError(t, reg.RegisterGuard("isManager", guard))
+	require.NoError(t, reg.RegisterAction("notify", action))
+
+	t.Run("registered instances", func(t *testing.T) {
+		name, ok := reg.NameOf(guard)
+		assert.True(t, ok)
+		assert.Equal(t, "isManager", name)
+
+		name, ok = reg.NameOfAction(action)
+		assert.True(t, ok)
+		assert.Equal(t, "notify", name)
+	})
+
+	t.Run("unregistered instances", func(t *testing.T) {
+		_, ok := reg.NameOf(&testGuard{result: true})
+		assert.False(t, ok)
+
+		_, ok = reg.NameOfAction(&testAction{})
+		assert.False(t, ok)
+
+		_, ok = reg.NameOf(nil)
+		assert.False(t, ok)
+	})
+
+	t.Run("several names", func(t *testing.T) {
+		reg := New()
+		guard := &testGuard{result: true}
+		action := &testAction{}
+
+		for _, name := range []string{"canEdit", "allowed", "isOwner"} {
+			require.NoError(t, reg.RegisterGuard(name, guard))
+			require.NoError(t, reg.RegisterAction(name, action))
+		}
+
+		// the smallest name is returned every time
+		for range 20 {
+			name, ok := reg.NameOf(guard)
+			require.True(t, ok)
+			require.Equal(t, "allowed", name)
+
+			name, ok = reg.NameOfAction(action)
+			require.True(t, ok)
+			require.Equal(t, "allowed", name)
+		}
+	})
+}
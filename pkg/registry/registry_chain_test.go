@@ -0,0 +1,59 @@
+package registry
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChain(t *testing.T) {
+	base := New()
+	app := New()
+
+	baseGuard := &testGuard{result: true}
+	appGuard := &testGuard{result: false}
+	baseAction := &testAction{}
+
+	require.NoError(t, base.RegisterGuard("isAdmin", baseGuard))
+	require.NoError(t, base.RegisterGuard("isOwner", baseGuard))
+	require.NoError(t, base.RegisterAction("notify", baseAction))
+	require.NoError(t, app.RegisterGuard("isAdmin", appGuard))
+
+	res := Chain(app, nil, base)
+
+	t.Run("first match wins", func(t *testing.T) {
+		g, ok := res.GetGuard("isAdmin")
+		require.True(t, ok)
+		assert.Same(t, appGuard, g)
+	})
+
+	t.Run("fallback", func(t *testing.T) {
+		g, ok := res.GetGuard("isOwner")
+		require.True(t, ok)
+		assert.Same(t, baseGuard, g)
+
+		a, ok := res.GetAction("notify")
+		require.True(t, ok)
+		assert.Same(t, baseAction, a)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		_, ok := res.GetGuard("missing")
+		assert.False(t, ok)
+
+		_, ok = res.GetAction("missing")
+		assert.False(t, ok)
+	})
+
+	t.Run("lists", func(t *testing.T) {
+		assert.ElementsMatch(t, []string{"isAdmin", "isOwner"}, res.ListGuards())
+		assert.Equal(t, []string{"notify"}, res.ListActions())
+	})
+
+	t.Run("empty chain", func(t *testing.T) {
+		_, ok := Chain().GetGuard("isAdmin")
+		assert.False(t, ok)
+		assert.Empty(t, Chain().ListActions())
+	})
+}
@@ -0,0 +1,79 @@
+package registry
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// requirer is a NameRequirer with fixed names.
+type requirer struct {
+	guards  []string
+	actions []string
+}
+
+func (r requirer) RequiredNames() ([]string, []string) {
+	return r.guards, r.actions
+}
+
+func TestExport(t *testing.T) {
+	registry := New()
+
+	auth := Meta{Description: "Checks the user is a manager", Category: "auth"}
+	require.NoError(t, registry.RegisterGuardWithMeta(
+		"isManager", &testGuard{}, auth))
+	require.NoError(t, registry.RegisterGuard("described", &describedGuard{}))
+	require.NoError(t, registry.RegisterAction("notify", &testAction{}))
+	require.NoError(t, registry.RegisterAction("isManager", &testAction{}))
+
+	manifest := registry.Export()
+
+	described := Meta{Description: "self-described", Version: "1.0"}
+	assert.Equal(t, Manifest{
+		Guards: []ManifestEntry{
+			{Name: "described", Meta: &described},
+			{Name: "isManager", Meta: &auth},
+		},
+		Actions: []ManifestEntry{
+			{Name: "isManager"},
+			{Name: "notify"},
+		},
+	}, manifest)
+
+	assert.Equal(t, []string{"described", "isManager", "notify"},
+		manifest.ImportNames())
+
+	t.Run("json", func(t *testing.T) {
+		data, err := json.Marshal(manifest)
+		require.NoError(t, err)
+
+		var decoded Manifest
+		require.NoError(t, json.Unmarshal(data, &decoded))
+		assert.Equal(t, manifest, decoded)
+	})
+
+	t.Run("empty registry", func(t *testing.T) {
+		manifest := New().Export()
+		assert.Empty(t, manifest.Guards)
+		assert.Empty(t, manifest.ImportNames())
+	})
+}
+
+func TestMissingFor(t *testing.T) {
+	registry := New()
+	require.NoError(t, registry.RegisterGuard("isManager", &testGuard{}))
+	require.NoError(t, registry.RegisterAction("notify", &testAction{}))
+
+	assert.Empty(t, registry.MissingFor(requirer{
+		guards:  []string{"isManager"},
+		actions: []string{"notify"},
+	}))
+
+	assert.Equal(t, []string{"archive", "isOwner", "notify"},
+		registry.MissingFor(requirer{
+			guards:  []string{"isOwner", "isManager", "notify"},
+			actions: []string{"notify", "archive", "archive"},
+		}), "names must be registered with the required kind")
+}
@@ -0,0 +1,86 @@
+package registry
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// describedGuard is a guard providing its own metadata.
+type describedGuard struct {
+	testGuard
+}
+
+func (g *describedGuard) Meta() Meta {
+	return Meta{Description: "self-described", Version: "1.0"}
+}
+
+func TestDescribe(t *testing.T) {
+	registry := New()
+
+	isManager := Meta{
+		Description: "Checks the user is a manager",
+		Category:    "auth",
+		Version:     "2.1",
+	}
+	require.NoError(t, registry.RegisterGuardWithMeta(
+		"isManager", &testGuard{result: true}, isManager))
+	require.NoError(t, registry.RegisterGuard("plain", &testGuard{}))
+	require.NoError(t, registry.RegisterGuard("described", &describedGuard{}))
+
+	notify := Meta{Description: "Sends a notification", Category: "io"}
+	require.NoError(t, registry.RegisterActionWithMeta(
+		"notify", &testAction{}, notify))
+	require.NoError(t, registry.RegisterActionWithMeta(
+		"isManager", &testAction{}, notify))
+
+	t.Run("explicit metadata", func(t *testing.T) {
+		meta, ok := registry.Describe("isManager")
+		require.True(t, ok)
+		assert.Equal(t, isManager, meta)
+
+		meta, ok = registry.Describe("notify")
+		require.True(t, ok)
+		assert.Equal(t, notify, meta)
+
+		meta, ok = registry.DescribeAction("isManager")
+		require.True(t, ok)
+		assert.Equal(t, notify, meta)
+	})
+
+	t.Run("describable", func(t *testing.T) {
+		meta, ok := registry.DescribeGuard("described")
+		require.True(t, ok)
+		assert.Equal(t, "self-described", meta.Description)
+
+		override := Meta{Description: "overridden"}
+		require.NoError(t, registry.RegisterGuardWithMeta(
+			"overridden", &describedGuard{}, override))
+		meta, ok = registry.Describe("overridden")
+		require.True(t, ok)
+		assert.Equal(t, override, meta)
+	})
+
+	t.Run("no metadata", func(t *testing.T) {
+		_, ok := registry.Describe("plain")
+		assert.False(t, ok)
+
+		_, ok = registry.Describe("unknown")
+		assert.False(t, ok)
+
+		guard, ok := registry.GetGuard("plain")
+		require.True(t, ok)
+		assert.NotNil(t, guard)
+	})
+
+	t.Run("registration errors", func(t *testing.T) {
+		assert.Error(t, registry.RegisterGuardWithMeta(
+			"isManager", &testGuard{}, Meta{}))
+		assert.Error(t, registry.RegisterActionWithMeta("", &testAction{}, Meta{}))
+
+		// failed registration doesn't replace the metadata
+		meta, _ := registry.Describe("isManager")
+		assert.Equal(t, isManager, meta)
+	})
+}